@@ -7,11 +7,19 @@ import (
 	"Canteen-Backend/internal/repository"
 	"Canteen-Backend/internal/usecase"
 	"Canteen-Backend/internal/utils"
+	"Canteen-Backend/internal/worker"
+	"Canteen-Backend/pkg/auth"
+	"Canteen-Backend/pkg/helpers"
 	"Canteen-Backend/pkg/logger"
+	"Canteen-Backend/pkg/mailer"
+	"Canteen-Backend/pkg/payment"
+	"Canteen-Backend/pkg/sso"
+	"context"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 	"log"
 	"os"
+	"time"
 )
 
 func init() {
@@ -21,6 +29,9 @@ func init() {
 	if err := godotenv.Load(); err != nil {
 		logger.GetLogger().Fatal("error loading env variables", zap.Error(err))
 	}
+	if err := auth.LoadKeys(); err != nil {
+		logger.GetLogger().Fatal("error loading JWT signing keys", zap.Error(err))
+	}
 }
 
 // @title Canteen Management System API
@@ -39,6 +50,10 @@ func main() {
 		DBName:   os.Getenv("POSTGRES_NAME"),
 		Port:     os.Getenv("POSTGRES_PORT"),
 		SSLMode:  os.Getenv("POSTGRES_SSL_MODE"),
+
+		MaxOpenConns:    helpers.GetEnvInt("POSTGRES_MAX_OPEN_CONNS", 25),
+		MaxIdleConns:    helpers.GetEnvInt("POSTGRES_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime: helpers.GetEnvDuration("POSTGRES_CONN_MAX_LIFETIME", 30*time.Minute),
 	})
 	if err != nil {
 		logger.GetLogger().Fatal("error occurred while connecting to db", zap.Error(err))
@@ -52,12 +67,34 @@ func main() {
 		logger.GetLogger().Fatal("error occurred while running seeds", zap.Error(err))
 	}
 
+	ssoProvider, err := sso.NewProviderFromEnv()
+	if err != nil {
+		logger.GetLogger().Fatal("error configuring single sign-on", zap.Error(err))
+	}
+
+	paymentProvider, err := payment.NewProviderFromEnv()
+	if err != nil {
+		logger.GetLogger().Fatal("error configuring the payment provider", zap.Error(err))
+	}
+
 	repo := repository.NewRepository(db)
-	useCase := usecase.NewUseCase(repo)
-	handler := handlers.NewHandler(useCase)
+	useCase := usecase.NewUseCase(repo, mailer.NewSMTPMailerFromEnv(), ssoProvider, paymentProvider)
+
+	jobRunner := worker.NewRunner(repo.Locker)
+	worker.RegisterSweeperJobs(jobRunner, repo)
+	jobRunner.Start(context.Background())
+
+	handler := handlers.NewHandler(useCase, jobRunner)
+
+	// the API key IP allowlists and the sign-in throttle rely on the client IP, so X-Forwarded-For
+	// is only honoured from the reverse proxies listed in TRUSTED_PROXIES, e.g. "10.0.0.0/8,192.168.1.2"
+	router, err := handler.InitRoutes(helpers.GetEnvList("TRUSTED_PROXIES"))
+	if err != nil {
+		logger.GetLogger().Fatal("error configuring trusted proxies", zap.Error(err))
+	}
 
 	srv := new(server.Server)
-	if err := srv.Run("8080", handler.InitRoutes()); err != nil {
+	if err := srv.Run("8080", router); err != nil {
 		logger.GetLogger().Fatal("error occurred while running http server", zap.Error(err))
 	}
 }
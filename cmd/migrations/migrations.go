@@ -8,11 +8,38 @@ func RunMigrations(db *gorm.DB) error {
 	statements := []string{
 		`CREATE TABLE IF NOT EXISTS user_role (
 			user_role_id SERIAL PRIMARY KEY,
-			name VARCHAR(50) UNIQUE NOT NULL
+			name VARCHAR(50) UNIQUE NOT NULL,
+			description VARCHAR(255) NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`ALTER TABLE user_role ADD COLUMN IF NOT EXISTS description VARCHAR(255) NOT NULL DEFAULT '';`,
+		`ALTER TABLE user_role ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;`,
+		`ALTER TABLE user_role ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;`,
+		`CREATE TABLE IF NOT EXISTS permission (
+			permission_id SERIAL PRIMARY KEY,
+			name VARCHAR(50) UNIQUE NOT NULL,
+			description VARCHAR(255) NOT NULL DEFAULT ''
+		);`,
+		`CREATE TABLE IF NOT EXISTS role_permission (
+			user_role_id INT NOT NULL REFERENCES user_role(user_role_id) ON DELETE CASCADE,
+			permission_id INT NOT NULL REFERENCES permission(permission_id) ON DELETE CASCADE,
+			PRIMARY KEY (user_role_id, permission_id)
 		);`,
+		// the default permissions already granted to the seeded roles, each is granted once
+		`CREATE TABLE IF NOT EXISTS role_permission_seed (
+			role_name VARCHAR(50) NOT NULL,
+			permission_name VARCHAR(50) NOT NULL,
+			PRIMARY KEY (role_name, permission_name)
+		);`,
+		// permissions a role already has count as granted, so that a default revoked later is not granted again
+		`INSERT INTO role_permission_seed (role_name, permission_name)
+			SELECT user_role.name, permission.name FROM role_permission
+			JOIN user_role USING (user_role_id) JOIN permission USING (permission_id)
+			ON CONFLICT DO NOTHING;`,
 		`CREATE TABLE IF NOT EXISTS "user" (
 			user_id SERIAL PRIMARY KEY,
-			user_role_id INT NOT NULL REFERENCES user_role(user_role_id) ON DELETE CASCADE,
+			user_role_id INT NOT NULL REFERENCES user_role(user_role_id) ON DELETE RESTRICT,
 			username VARCHAR(50) UNIQUE NOT NULL,
 			first_name VARCHAR(50) NOT NULL,
 			last_name VARCHAR(50) NOT NULL,
@@ -21,8 +48,89 @@ func RunMigrations(db *gorm.DB) error {
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			deleted_at TIMESTAMP,
-			is_active BOOLEAN DEFAULT TRUE
+			is_active BOOLEAN DEFAULT TRUE,
+			failed_sign_in_attempts INT NOT NULL DEFAULT 0,
+			last_failed_sign_in_at TIMESTAMP,
+			locked_until TIMESTAMP,
+			totp_secret VARCHAR(64) NOT NULL DEFAULT '',
+			totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+			totp_last_used_step BIGINT,
+			must_change_password BOOLEAN NOT NULL DEFAULT FALSE
+		);`,
+		`ALTER TABLE "user" ADD COLUMN IF NOT EXISTS failed_sign_in_attempts INT NOT NULL DEFAULT 0;`,
+		`ALTER TABLE "user" ADD COLUMN IF NOT EXISTS last_failed_sign_in_at TIMESTAMP;`,
+		`ALTER TABLE "user" ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;`,
+		`ALTER TABLE "user" ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64) NOT NULL DEFAULT '';`,
+		`ALTER TABLE "user" ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`ALTER TABLE "user" ADD COLUMN IF NOT EXISTS totp_last_used_step BIGINT;`,
+		`ALTER TABLE "user" ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE;`,
+		// deleting a role used to delete all of its users
+		`DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM information_schema.referential_constraints WHERE constraint_name = 'user_user_role_id_fkey' AND delete_rule = 'CASCADE') THEN
+				ALTER TABLE "user" DROP CONSTRAINT user_user_role_id_fkey;
+				ALTER TABLE "user" ADD CONSTRAINT user_user_role_id_fkey FOREIGN KEY (user_role_id) REFERENCES user_role(user_role_id) ON DELETE RESTRICT;
+			END IF;
+		END $$;`,
+		`CREATE TABLE IF NOT EXISTS user_recovery_code (
+			user_recovery_code_id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES "user"(user_id) ON DELETE CASCADE,
+			code_hash VARCHAR(64) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			used_at TIMESTAMP,
+			UNIQUE (user_id, code_hash)
+		);`,
+		`CREATE TABLE IF NOT EXISTS password_reset_token (
+			password_reset_token_id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES "user"(user_id) ON DELETE CASCADE,
+			token_hash VARCHAR(64) UNIQUE NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			used_at TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS password_reset_token_user_id_idx ON password_reset_token (user_id);`,
+		`CREATE TABLE IF NOT EXISTS oidc_state (
+			oidc_state_id SERIAL PRIMARY KEY,
+			state_hash VARCHAR(64) UNIQUE NOT NULL,
+			nonce VARCHAR(64) NOT NULL,
+			code_verifier VARCHAR(128) NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			used_at TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS user_identity (
+			user_identity_id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES "user"(user_id) ON DELETE CASCADE,
+			issuer VARCHAR(255) NOT NULL,
+			subject VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_sign_in_at TIMESTAMP,
+			UNIQUE (issuer, subject)
+		);`,
+		`CREATE INDEX IF NOT EXISTS user_identity_user_id_idx ON user_identity (user_id);`,
+		`CREATE TABLE IF NOT EXISTS api_key (
+			api_key_id SERIAL PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			prefix VARCHAR(16) NOT NULL,
+			key_hash VARCHAR(64) UNIQUE NOT NULL,
+			user_role_id INT NOT NULL REFERENCES user_role(user_role_id) ON DELETE RESTRICT,
+			allowed_ips TEXT NOT NULL DEFAULT '',
+			expires_at TIMESTAMP,
+			last_used_at TIMESTAMP,
+			last_used_ip VARCHAR(45),
+			created_by INT REFERENCES "user"(user_id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMP
 		);`,
+		`CREATE TABLE IF NOT EXISTS sign_in_attempt (
+			sign_in_attempt_id SERIAL PRIMARY KEY,
+			username VARCHAR(50) NOT NULL,
+			ip_address VARCHAR(45) NOT NULL,
+			is_successful BOOLEAN NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS sign_in_attempt_ip_address_idx ON sign_in_attempt (ip_address, created_at);`,
+		`CREATE INDEX IF NOT EXISTS sign_in_attempt_username_idx ON sign_in_attempt (username, created_at);`,
 		`CREATE TABLE IF NOT EXISTS client_category (
 			client_category_id SERIAL PRIMARY KEY,
 			name VARCHAR(50) UNIQUE NOT NULL,
@@ -39,18 +147,133 @@ func RunMigrations(db *gorm.DB) error {
 			gender VARCHAR(10) NOT NULL,
 			email VARCHAR(100) UNIQUE NOT NULL,
 			client_category_id INT NOT NULL REFERENCES client_category(client_category_id) ON DELETE CASCADE,
-			balance FLOAT NOT NULL,
+			balance NUMERIC(14,2) NOT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			deleted_at TIMESTAMP,
 			is_active BOOLEAN DEFAULT TRUE
 		);`,
+		`ALTER TABLE client_category ADD COLUMN IF NOT EXISTS credit_limit NUMERIC(14,2) NOT NULL DEFAULT 0 CHECK (credit_limit >= 0);`,
+		`ALTER TABLE client ADD COLUMN IF NOT EXISTS credit_limit NUMERIC(14,2) CHECK (credit_limit >= 0);`,
+		`ALTER TABLE client ADD COLUMN IF NOT EXISTS daily_spending_limit NUMERIC(14,2) CHECK (daily_spending_limit >= 0);`,
+		`ALTER TABLE client ADD COLUMN IF NOT EXISTS weekly_spending_limit NUMERIC(14,2) CHECK (weekly_spending_limit >= 0);`,
+		`ALTER TABLE client ADD COLUMN IF NOT EXISTS transaction_spending_limit NUMERIC(14,2) CHECK (transaction_spending_limit >= 0);`,
+		`CREATE INDEX IF NOT EXISTS client_client_category_id_idx ON client (client_category_id);`,
+		`CREATE INDEX IF NOT EXISTS client_last_name_idx ON client (last_name, first_name);`,
+		`CREATE TABLE IF NOT EXISTS balance_transaction (
+			balance_transaction_id SERIAL PRIMARY KEY,
+			client_id INT NOT NULL REFERENCES client(client_id) ON DELETE CASCADE,
+			type VARCHAR(20) NOT NULL CHECK (type IN ('top-up', 'purchase', 'refund', 'correction')),
+			amount NUMERIC(14,2) NOT NULL,
+			balance_after NUMERIC(14,2) NOT NULL,
+			user_id INT REFERENCES "user"(user_id) ON DELETE SET NULL,
+			api_key_id INT REFERENCES api_key(api_key_id) ON DELETE SET NULL,
+			reason VARCHAR(255) NOT NULL DEFAULT '',
+			idempotency_key VARCHAR(100),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`ALTER TABLE balance_transaction ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(100);`,
+		`CREATE INDEX IF NOT EXISTS balance_transaction_client_id_idx ON balance_transaction (client_id, created_at);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS balance_transaction_idempotency_key_idx ON balance_transaction (client_id, idempotency_key);`,
+		// balances set before the ledger existed are carried over as opening corrections
+		`INSERT INTO balance_transaction (client_id, type, amount, balance_after, reason)
+		SELECT client_id, 'correction', balance, balance, 'opening balance' FROM client
+		WHERE balance <> 0 AND NOT EXISTS (SELECT 1 FROM balance_transaction WHERE balance_transaction.client_id = client.client_id);`,
+		`CREATE TABLE IF NOT EXISTS dish (
+			dish_id SERIAL PRIMARY KEY,
+			name VARCHAR(100) UNIQUE NOT NULL,
+			price NUMERIC(14,2) NOT NULL CHECK (price >= 0),
+			is_active BOOLEAN DEFAULT TRUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS client_category_pricing (
+			client_category_pricing_id SERIAL PRIMARY KEY,
+			client_category_id INT NOT NULL REFERENCES client_category(client_category_id) ON DELETE CASCADE,
+			effective_from TIMESTAMP NOT NULL,
+			discount_percent NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (discount_percent BETWEEN 0 AND 100),
+			subsidy NUMERIC(14,2) NOT NULL DEFAULT 0 CHECK (subsidy >= 0),
+			subsidy_period VARCHAR(10) NOT NULL DEFAULT 'meal' CHECK (subsidy_period IN ('meal', 'day')),
+			user_id INT REFERENCES "user"(user_id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (client_category_id, effective_from)
+		);`,
+		`CREATE TABLE IF NOT EXISTS client_category_price (
+			client_category_pricing_id INT NOT NULL REFERENCES client_category_pricing(client_category_pricing_id) ON DELETE CASCADE,
+			dish_id INT NOT NULL REFERENCES dish(dish_id) ON DELETE CASCADE,
+			price NUMERIC(14,2) NOT NULL CHECK (price >= 0),
+			PRIMARY KEY (client_category_pricing_id, dish_id)
+		);`,
+		`ALTER TABLE balance_transaction ADD COLUMN IF NOT EXISTS gross_amount NUMERIC(14,2);`,
+		`ALTER TABLE balance_transaction ADD COLUMN IF NOT EXISTS discount NUMERIC(14,2) NOT NULL DEFAULT 0;`,
+		`ALTER TABLE balance_transaction ADD COLUMN IF NOT EXISTS subsidy NUMERIC(14,2) NOT NULL DEFAULT 0;`,
+		`ALTER TABLE balance_transaction ADD COLUMN IF NOT EXISTS client_category_pricing_id INT
+			REFERENCES client_category_pricing(client_category_pricing_id) ON DELETE SET NULL;`,
+		`CREATE TABLE IF NOT EXISTS balance_transaction_item (
+			balance_transaction_id INT NOT NULL REFERENCES balance_transaction(balance_transaction_id) ON DELETE CASCADE,
+			dish_id INT NOT NULL REFERENCES dish(dish_id) ON DELETE RESTRICT,
+			quantity INT NOT NULL CHECK (quantity > 0),
+			unit_price NUMERIC(14,2) NOT NULL,
+			PRIMARY KEY (balance_transaction_id, dish_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS client_identifier (
+			client_identifier_id SERIAL PRIMARY KEY,
+			client_id INT NOT NULL REFERENCES client(client_id) ON DELETE CASCADE,
+			type VARCHAR(10) NOT NULL CHECK (type IN ('card', 'qr', 'rfid')),
+			value VARCHAR(255) NOT NULL,
+			status VARCHAR(10) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'lost', 'blocked')),
+			replaced_by_id INT REFERENCES client_identifier(client_identifier_id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (type, value)
+		);`,
+		`CREATE INDEX IF NOT EXISTS client_identifier_client_id_idx ON client_identifier (client_id);`,
+		`CREATE TABLE IF NOT EXISTS client_identifier_event (
+			client_identifier_event_id SERIAL PRIMARY KEY,
+			client_identifier_id INT NOT NULL REFERENCES client_identifier(client_identifier_id) ON DELETE CASCADE,
+			event VARCHAR(20) NOT NULL CHECK (event IN ('issued', 'status-changed', 'replaced')),
+			status VARCHAR(10) NOT NULL,
+			user_id INT REFERENCES "user"(user_id) ON DELETE SET NULL,
+			api_key_id INT REFERENCES api_key(api_key_id) ON DELETE SET NULL,
+			reason VARCHAR(255) NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS client_identifier_event_client_identifier_id_idx ON client_identifier_event (client_identifier_id, created_at);`,
 		`CREATE TABLE IF NOT EXISTS session (
 			session_id SERIAL PRIMARY KEY,
 			user_id INTEGER NOT NULL REFERENCES "user"(user_id) ON DELETE CASCADE,
-			refresh_token TEXT UNIQUE NOT NULL,
-			expires_at TIMESTAMP NOT NULL
+			family_id VARCHAR(32) NOT NULL,
+			refresh_token_hash VARCHAR(64) UNIQUE NOT NULL,
+			ip_address VARCHAR(45),
+			user_agent TEXT,
+			signed_in_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			rotated_at TIMESTAMP,
+			revoked_at TIMESTAMP
 		);`,
+		// sessions created before token families only stored the raw refresh token
+		`ALTER TABLE session ADD COLUMN IF NOT EXISTS family_id VARCHAR(32);`,
+		`ALTER TABLE session ADD COLUMN IF NOT EXISTS refresh_token_hash VARCHAR(64) UNIQUE;`,
+		`ALTER TABLE session ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;`,
+		`ALTER TABLE session ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMP;`,
+		`ALTER TABLE session ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP;`,
+		`DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'session' AND column_name = 'refresh_token') THEN
+				UPDATE session SET refresh_token_hash = encode(sha256(refresh_token::bytea), 'hex'), family_id = md5(session_id::text || random()::text);
+				ALTER TABLE session DROP COLUMN refresh_token;
+				ALTER TABLE session ALTER COLUMN family_id SET NOT NULL;
+				ALTER TABLE session ALTER COLUMN refresh_token_hash SET NOT NULL;
+			END IF;
+		END $$;`,
+		`CREATE INDEX IF NOT EXISTS session_family_id_idx ON session (family_id);`,
+		`ALTER TABLE session ADD COLUMN IF NOT EXISTS ip_address VARCHAR(45);`,
+		`ALTER TABLE session ADD COLUMN IF NOT EXISTS user_agent TEXT;`,
+		`ALTER TABLE session ADD COLUMN IF NOT EXISTS signed_in_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;`,
+		`ALTER TABLE session ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;`,
+		`CREATE INDEX IF NOT EXISTS session_user_id_idx ON session (user_id);`,
 		`CREATE TABLE IF NOT EXISTS ingredient_category (
     		ingredient_category_id SERIAL PRIMARY KEY,
     		name VARCHAR(100) UNIQUE NOT NULL,
@@ -63,7 +286,7 @@ func RunMigrations(db *gorm.DB) error {
 		ingredient_category_id INT NOT NULL REFERENCES ingredient_category(ingredient_category_id) ON DELETE CASCADE,
 		unit VARCHAR(20) NOT NULL,
 		quantity FLOAT,
-		unit_price FLOAT,
+		unit_price NUMERIC(14,2),
 		lack_limit FLOAT,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -78,15 +301,83 @@ func RunMigrations(db *gorm.DB) error {
     	purchase_id SERIAL PRIMARY KEY,
     	purchase_date TIMESTAMP NOT NULL,
     	supplier_id INT NOT NULL REFERENCES supplier(supplier_id) ON DELETE CASCADE,
-    	total_sum FLOAT NOT NULL
+    	total_sum NUMERIC(14,2) NOT NULL
 		);`,
 		`CREATE TABLE IF NOT EXISTS purchases_ingredients (
     	purchase_id INT NOT NULL REFERENCES purchase(purchase_id) ON DELETE CASCADE,
     	ingredient_id INT NOT NULL REFERENCES ingredient(ingredient_id) ON DELETE CASCADE,
     	amount FLOAT NOT NULL,
-    	cost FLOAT NOT NULL,
-    	current_unit_price FLOAT NOT NULL
+    	cost NUMERIC(14,2) NOT NULL,
+    	current_unit_price NUMERIC(14,2) NOT NULL
     	);`,
+		`CREATE TABLE IF NOT EXISTS allergen (
+			allergen_id SERIAL PRIMARY KEY,
+			name VARCHAR(50) UNIQUE NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS ingredient_allergen (
+			ingredient_id INT NOT NULL REFERENCES ingredient(ingredient_id) ON DELETE CASCADE,
+			allergen_id INT NOT NULL REFERENCES allergen(allergen_id) ON DELETE CASCADE,
+			PRIMARY KEY (ingredient_id, allergen_id)
+		);`,
+		`ALTER TABLE ingredient ADD COLUMN IF NOT EXISTS is_vegetarian BOOLEAN NOT NULL DEFAULT TRUE;`,
+		`ALTER TABLE ingredient ADD COLUMN IF NOT EXISTS is_halal BOOLEAN NOT NULL DEFAULT TRUE;`,
+		`CREATE TABLE IF NOT EXISTS dish_ingredient (
+			dish_id INT NOT NULL REFERENCES dish(dish_id) ON DELETE CASCADE,
+			ingredient_id INT NOT NULL REFERENCES ingredient(ingredient_id) ON DELETE CASCADE,
+			PRIMARY KEY (dish_id, ingredient_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS client_allergen (
+			client_id INT NOT NULL REFERENCES client(client_id) ON DELETE CASCADE,
+			allergen_id INT NOT NULL REFERENCES allergen(allergen_id) ON DELETE CASCADE,
+			PRIMARY KEY (client_id, allergen_id)
+		);`,
+		`ALTER TABLE client ADD COLUMN IF NOT EXISTS vegetarian BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`ALTER TABLE client ADD COLUMN IF NOT EXISTS halal BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`CREATE TABLE IF NOT EXISTS dietary_override (
+			dietary_override_id SERIAL PRIMARY KEY,
+			balance_transaction_id INT NOT NULL REFERENCES balance_transaction(balance_transaction_id) ON DELETE CASCADE,
+			dish_id INT NOT NULL REFERENCES dish(dish_id) ON DELETE CASCADE,
+			restriction VARCHAR(50) NOT NULL,
+			reason VARCHAR(255) NOT NULL,
+			user_id INT REFERENCES "user"(user_id) ON DELETE SET NULL,
+			api_key_id INT REFERENCES api_key(api_key_id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS dietary_override_balance_transaction_id_idx ON dietary_override (balance_transaction_id);`,
+		`CREATE TABLE IF NOT EXISTS top_up (
+			top_up_id SERIAL PRIMARY KEY,
+			client_id INT NOT NULL REFERENCES client(client_id) ON DELETE CASCADE,
+			amount NUMERIC(14,2) NOT NULL CHECK (amount > 0),
+			status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'succeeded', 'failed')),
+			provider VARCHAR(50) NOT NULL,
+			provider_payment_id VARCHAR(255),
+			provider_transaction_id VARCHAR(255),
+			checkout_url TEXT NOT NULL DEFAULT '',
+			balance_transaction_id INT REFERENCES balance_transaction(balance_transaction_id) ON DELETE SET NULL,
+			user_id INT REFERENCES "user"(user_id) ON DELETE SET NULL,
+			api_key_id INT REFERENCES api_key(api_key_id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS top_up_provider_payment_id_idx ON top_up (provider, provider_payment_id);`,
+		// a payment is credited once per transaction of the provider, however often its webhook is delivered
+		`CREATE UNIQUE INDEX IF NOT EXISTS top_up_provider_transaction_id_idx ON top_up (provider, provider_transaction_id);`,
+		`CREATE INDEX IF NOT EXISTS top_up_client_id_idx ON top_up (client_id, created_at);`,
+		// amounts of money used to be floats, they are rounded half away from zero to minor units
+		`DO $$
+		DECLARE
+			money_column RECORD;
+		BEGIN
+			FOR money_column IN SELECT table_name, column_name FROM information_schema.columns
+				WHERE table_schema = current_schema() AND data_type IN ('double precision', 'real')
+				AND (table_name, column_name) IN (('client', 'balance'), ('balance_transaction', 'amount'), ('balance_transaction', 'balance_after'),
+					('ingredient', 'unit_price'), ('purchase', 'total_sum'), ('purchases_ingredients', 'cost'), ('purchases_ingredients', 'current_unit_price'))
+			LOOP
+				EXECUTE format('ALTER TABLE %I ALTER COLUMN %I TYPE NUMERIC(14,2) USING round(%I::numeric, 2)',
+					money_column.table_name, money_column.column_name, money_column.column_name);
+			END LOOP;
+		END $$;`,
 	}
 
 	for _, statement := range statements {
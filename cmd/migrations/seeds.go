@@ -1,19 +1,27 @@
 package migrations
 
 import (
+	"Canteen-Backend/internal/constants"
 	"Canteen-Backend/pkg/helpers"
 	"gorm.io/gorm"
 	"os"
 )
 
 func RunSeeds(db *gorm.DB) error {
-	if err := seedIfNotExists(db, "user_role", "name", "admin"); err != nil {
+	if err := seedIfNotExists(db, "user_role", "name", constants.AdminRoleName); err != nil {
 		return err
 	}
-	if err := seedIfNotExists(db, "user_role", "name", "receptionist"); err != nil {
+	if err := seedIfNotExists(db, "user_role", "name", constants.ReceptionistRoleName); err != nil {
 		return err
 	}
-	if err := seedIfNotExists(db, "user_role", "name", "cashier"); err != nil {
+	if err := seedIfNotExists(db, "user_role", "name", constants.CashierRoleName); err != nil {
+		return err
+	}
+
+	if err := seedPermissions(db); err != nil {
+		return err
+	}
+	if err := seedRolePermissions(db); err != nil {
 		return err
 	}
 
@@ -66,6 +74,82 @@ func createAdmin(db *gorm.DB) error {
 	return nil
 }
 
+// seedPermissions synchronises the permission table with the catalogue in constants.Permissions.
+func seedPermissions(db *gorm.DB) error {
+	for name, description := range constants.Permissions {
+		insertStatement := `INSERT INTO permission (name, description) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description;`
+		result := db.Exec(insertStatement, name, description)
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+// seedRolePermissions grants the seeded roles each of their default permissions once, and records it in
+// role_permission_seed. A default added in a later release reaches the roles of existing deployments, while one
+// an admin revoked is not granted again. The admin role is granted every missing permission on each run,
+// so it keeps up with new permissions.
+func seedRolePermissions(db *gorm.DB) error {
+	for roleName, permissions := range constants.DefaultRolePermissions {
+		var seeded []string
+		result := db.Table("role_permission_seed").Where("role_name = ?", roleName).Pluck("permission_name", &seeded)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		missing := permissions
+		if roleName != constants.AdminRoleName {
+			missing = unseededPermissions(permissions, seeded)
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			insertStatement := `INSERT INTO role_permission (user_role_id, permission_id)
+				SELECT user_role.user_role_id, permission.permission_id FROM user_role, permission
+				WHERE user_role.name = ? AND permission.name IN ?
+				ON CONFLICT DO NOTHING;`
+			if err := tx.Exec(insertStatement, roleName, missing).Error; err != nil {
+				return err
+			}
+
+			for _, permission := range missing {
+				insertStatement := `INSERT INTO role_permission_seed (role_name, permission_name) VALUES (?, ?) ON CONFLICT DO NOTHING;`
+				if err := tx.Exec(insertStatement, roleName, permission).Error; err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unseededPermissions returns the permissions that are not among the seeded ones.
+func unseededPermissions(permissions, seeded []string) []string {
+	isSeeded := make(map[string]bool, len(seeded))
+	for _, permission := range seeded {
+		isSeeded[permission] = true
+	}
+
+	var missing []string
+	for _, permission := range permissions {
+		if !isSeeded[permission] {
+			missing = append(missing, permission)
+		}
+	}
+
+	return missing
+}
+
 func seedIfNotExists(db *gorm.DB, tableName, columnName, value string) error {
 	var count int64
 	result := db.Table(tableName).Where(columnName+" = ?", value).Count(&count)
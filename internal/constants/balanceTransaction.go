@@ -0,0 +1,16 @@
+package constants
+
+// The types of balance transactions. Top-ups and refunds add to the balance, purchases take from it,
+// and corrections fix mistakes in either direction.
+const (
+	BalanceTransactionTopUp      = "top-up"
+	BalanceTransactionPurchase   = "purchase"
+	BalanceTransactionRefund     = "refund"
+	BalanceTransactionCorrection = "correction"
+)
+
+// The periods a client category's subsidy is granted for: every purchase, or once a day in the canteen's time zone.
+const (
+	SubsidyPerMeal = "meal"
+	SubsidyPerDay  = "day"
+)
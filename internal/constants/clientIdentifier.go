@@ -0,0 +1,23 @@
+package constants
+
+// The types of identifiers a client presents at the till.
+const (
+	ClientIdentifierCard = "card"
+	ClientIdentifierQR   = "qr"
+	ClientIdentifierRFID = "rfid"
+)
+
+// The statuses of client identifiers. Only active identifiers can be used, lost and blocked ones
+// are turned away at the till.
+const (
+	ClientIdentifierActive  = "active"
+	ClientIdentifierLost    = "lost"
+	ClientIdentifierBlocked = "blocked"
+)
+
+// The events of a client identifier's history.
+const (
+	ClientIdentifierEventIssued        = "issued"
+	ClientIdentifierEventStatusChanged = "status-changed"
+	ClientIdentifierEventReplaced      = "replaced"
+)
@@ -0,0 +1,7 @@
+package constants
+
+// The diets a client can keep. Unlike allergies, they only warn when a dish does not fit them.
+const (
+	DietVegetarian = "vegetarian"
+	DietHalal      = "halal"
+)
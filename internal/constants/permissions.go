@@ -0,0 +1,101 @@
+package constants
+
+const (
+	AdminRoleName        = "admin"
+	ReceptionistRoleName = "receptionist"
+	CashierRoleName      = "cashier"
+)
+
+const (
+	PermissionUsersRead             = "users:read"
+	PermissionUsersWrite            = "users:write"
+	PermissionClientsRead           = "clients:read"
+	PermissionClientsWrite          = "clients:write"
+	PermissionClientsBalance        = "clients:balance"
+	PermissionTopUpsWrite           = "top-ups:write"
+	PermissionClientCategoriesRead  = "client-categories:read"
+	PermissionClientCategoriesWrite = "client-categories:write"
+	PermissionIngredientsRead       = "ingredients:read"
+	PermissionIngredientsWrite      = "ingredients:write"
+	PermissionDishesRead            = "dishes:read"
+	PermissionDishesWrite           = "dishes:write"
+	PermissionSuppliersRead         = "suppliers:read"
+	PermissionSuppliersWrite        = "suppliers:write"
+	PermissionPurchasesWrite        = "purchases:write"
+	PermissionRolesRead             = "roles:read"
+	PermissionRolesWrite            = "roles:write"
+	PermissionAPIKeysRead           = "api-keys:read"
+	PermissionAPIKeysWrite          = "api-keys:write"
+	PermissionJobsRead              = "jobs:read"
+)
+
+// Permissions is the catalogue of all permissions checked by the API, with their descriptions.
+// It is synchronised into the permission table on startup.
+var Permissions = map[string]string{
+	PermissionUsersRead:             "View users and their sessions",
+	PermissionUsersWrite:            "Create, update and delete users and manage their sessions",
+	PermissionClientsRead:           "View clients",
+	PermissionClientsWrite:          "Create, update and delete clients",
+	PermissionClientsBalance:        "Change client balances",
+	PermissionTopUpsWrite:           "Start online top-ups of client balances",
+	PermissionClientCategoriesRead:  "View client categories",
+	PermissionClientCategoriesWrite: "Create, update and delete client categories",
+	PermissionIngredientsRead:       "View ingredients and ingredient categories",
+	PermissionIngredientsWrite:      "Create, update and delete ingredients and ingredient categories",
+	PermissionDishesRead:            "View dishes",
+	PermissionDishesWrite:           "Create and update dishes",
+	PermissionSuppliersRead:         "View suppliers",
+	PermissionSuppliersWrite:        "Create, update and delete suppliers",
+	PermissionPurchasesWrite:        "Record purchases",
+	PermissionRolesRead:             "View roles and permissions",
+	PermissionRolesWrite:            "Create, update and delete roles",
+	PermissionAPIKeysRead:           "View API keys",
+	PermissionAPIKeysWrite:          "Create and revoke API keys",
+	PermissionJobsRead:              "View the status of background jobs",
+}
+
+// DefaultRolePermissions maps the seeded user roles to the permissions they are granted
+// when they are seeded. Afterwards the grants are managed through the roles API,
+// except for the admin role, which always has every permission.
+var DefaultRolePermissions = map[string][]string{
+	AdminRoleName: {
+		PermissionUsersRead,
+		PermissionUsersWrite,
+		PermissionClientsRead,
+		PermissionClientsWrite,
+		PermissionClientsBalance,
+		PermissionTopUpsWrite,
+		PermissionClientCategoriesRead,
+		PermissionClientCategoriesWrite,
+		PermissionIngredientsRead,
+		PermissionIngredientsWrite,
+		PermissionDishesRead,
+		PermissionDishesWrite,
+		PermissionSuppliersRead,
+		PermissionSuppliersWrite,
+		PermissionPurchasesWrite,
+		PermissionRolesRead,
+		PermissionRolesWrite,
+		PermissionAPIKeysRead,
+		PermissionAPIKeysWrite,
+		PermissionJobsRead,
+	},
+	ReceptionistRoleName: {
+		PermissionClientsRead,
+		PermissionClientsWrite,
+		PermissionClientsBalance,
+		PermissionClientCategoriesRead,
+	},
+	CashierRoleName: {
+		PermissionClientsRead,
+		PermissionClientsBalance,
+		PermissionClientCategoriesRead,
+		PermissionIngredientsRead,
+		PermissionDishesRead,
+	},
+}
+
+// TwoFactorRequiredRoles are the user roles that cannot sign in without two-factor authentication.
+var TwoFactorRequiredRoles = map[string]bool{
+	AdminRoleName: true,
+}
@@ -1,14 +1,46 @@
 package constants
 
 var (
-	UserTableName                 = "user"
-	RoleTableName                 = "user_role"
-	ClientCategoryTableName       = "client_category"
-	ClientTableName               = "client"
-	SessionTableName              = "session"
-	IngredientCategoryTableName   = "ingredient_category"
-	IngredientTableName           = "ingredient"
-	SupplierTableName             = "supplier"
-	PurchaseTableName             = "purchase"
-	PurchasesIngredientsTableName = "purchases_ingredients"
+	UserTableName                   = "user"
+	RoleTableName                   = "user_role"
+	PermissionTableName             = "permission"
+	RolePermissionTableName         = "role_permission"
+	ClientCategoryTableName         = "client_category"
+	ClientTableName                 = "client"
+	BalanceTransactionTableName     = "balance_transaction"
+	ClientIdentifierTableName       = "client_identifier"
+	ClientIdentifierEventTableName  = "client_identifier_event"
+	ClientCategoryPricingTableName  = "client_category_pricing"
+	ClientCategoryPriceTableName    = "client_category_price"
+	DishTableName                   = "dish"
+	BalanceTransactionItemTableName = "balance_transaction_item"
+	DietaryOverrideTableName        = "dietary_override"
+	TopUpTableName                  = "top_up"
+	ClientAllergenTableName         = "client_allergen"
+	SessionTableName                = "session"
+	SignInAttemptTableName          = "sign_in_attempt"
+	RecoveryCodeTableName           = "user_recovery_code"
+	PasswordResetTokenTableName     = "password_reset_token"
+	APIKeyTableName                 = "api_key"
+	OIDCStateTableName              = "oidc_state"
+	UserIdentityTableName           = "user_identity"
+	IngredientCategoryTableName     = "ingredient_category"
+	IngredientTableName             = "ingredient"
+	AllergenTableName               = "allergen"
+	IngredientAllergenTableName     = "ingredient_allergen"
+	DishIngredientTableName         = "dish_ingredient"
+	SupplierTableName               = "supplier"
+	PurchaseTableName               = "purchase"
+	PurchasesIngredientsTableName   = "purchases_ingredients"
 )
+
+// ClientSortColumns maps the fields the client list can be sorted by to their columns.
+var ClientSortColumns = map[string]string{
+	"id":         "client_id",
+	"first_name": "first_name",
+	"last_name":  "last_name",
+	"email":      "email",
+	"age":        "age",
+	"balance":    "balance",
+	"created_at": "created_at",
+}
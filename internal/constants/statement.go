@@ -0,0 +1,7 @@
+package constants
+
+// The file formats client statements are produced in.
+const (
+	StatementFormatXLSX = "xlsx"
+	StatementFormatPDF  = "pdf"
+)
@@ -0,0 +1,9 @@
+package constants
+
+// The statuses of online top-ups. A top-up is pending until the payment provider reports whether
+// the client paid, and the client's balance is only credited once it succeeded.
+const (
+	TopUpPending   = "pending"
+	TopUpSucceeded = "succeeded"
+	TopUpFailed    = "failed"
+)
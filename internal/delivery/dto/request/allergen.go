@@ -0,0 +1,23 @@
+package request
+
+import "Canteen-Backend/internal/models"
+
+type CreateAllergen struct {
+	Name string `json:"name" validate:"required,min=1,max=50"`
+}
+
+type UpdateAllergen struct {
+	Name string `json:"name" validate:"required,min=1,max=50"`
+}
+
+func MapCreateAllergenToAllergen(input *CreateAllergen) *models.Allergen {
+	return &models.Allergen{
+		Name: input.Name,
+	}
+}
+
+func MapUpdateAllergenToAllergen(input *UpdateAllergen) *models.Allergen {
+	return &models.Allergen{
+		Name: input.Name,
+	}
+}
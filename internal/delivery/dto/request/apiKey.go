@@ -0,0 +1,30 @@
+package request
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/helpers"
+	"strings"
+)
+
+type CreateAPIKey struct {
+	Name       string `json:"name" validate:"required,min=1,max=100"`
+	UserRoleID uint   `json:"user_role_id" validate:"required"`
+	// AllowedIPs are the IP addresses and CIDR ranges the key can be used from, empty allows any
+	AllowedIPs []string `json:"allowed_ips"`
+	ExpiresAt  string   `json:"expires_at" validate:"omitempty,datetime=2006-01-02 15:04"`
+}
+
+func MapCreateAPIKeyToAPIKey(input *CreateAPIKey) *models.APIKey {
+	apiKey := &models.APIKey{
+		Name:       input.Name,
+		UserRoleID: input.UserRoleID,
+		AllowedIPs: strings.Join(input.AllowedIPs, ","),
+	}
+
+	if input.ExpiresAt != "" {
+		expiresAt := helpers.ConvertStringToDate(input.ExpiresAt, "2006-01-02 15:04")
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	return apiKey
+}
@@ -1,39 +1,137 @@
 package request
 
-import "Canteen-Backend/internal/models"
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/money"
+	"strings"
+	"time"
+)
 
 type CreateClient struct {
-	Email            string  `json:"email" validate:"required,email"`
-	FirstName        string  `json:"first_name" validate:"required,min=1,max=20,alpha"`
-	LastName         string  `json:"last_name" validate:"required,min=1,max=20,alpha"`
-	Age              uint    `json:"age" validate:"required,min=1,max=100"`
-	Gender           string  `json:"gender" validate:"required,gender"`
-	Balance          float32 `json:"balance" validate:"omitempty"`
-	ClientCategoryID uint    `json:"client_category_id" validate:"required"`
+	Email            string      `json:"email" validate:"required,email"`
+	FirstName        string      `json:"first_name" validate:"required,min=1,max=20,alpha"`
+	LastName         string      `json:"last_name" validate:"required,min=1,max=20,alpha"`
+	Age              uint        `json:"age" validate:"required,min=1,max=100"`
+	Gender           string      `json:"gender" validate:"required,gender"`
+	Balance          money.Money `json:"balance" validate:"omitempty" swaggertype:"number"`
+	ClientCategoryID uint        `json:"client_category_id" validate:"required"`
 }
 
 type UpdateClient struct {
-	Email            string  `json:"email" validate:"omitempty,email"`
-	FirstName        string  `json:"first_name" validate:"omitempty,min=1,max=20,alpha"`
-	LastName         string  `json:"last_name" validate:"omitempty,min=1,max=20,alpha"`
-	Age              uint    `json:"age" validate:"omitempty,min=1,max=100"`
-	Gender           string  `json:"gender" validate:"omitempty"`
-	Balance          float32 `json:"balance" validate:"omitempty"`
-	ClientCategoryID uint    `json:"client_category_id" validate:"omitempty"`
-	IsActive         bool    `json:"is_active"`
+	Email            string `json:"email" validate:"omitempty,email"`
+	FirstName        string `json:"first_name" validate:"omitempty,min=1,max=20,alpha"`
+	LastName         string `json:"last_name" validate:"omitempty,min=1,max=20,alpha"`
+	Age              uint   `json:"age" validate:"omitempty,min=1,max=100"`
+	Gender           string `json:"gender" validate:"omitempty"`
+	ClientCategoryID uint   `json:"client_category_id" validate:"omitempty"`
+	IsActive         bool   `json:"is_active"`
+}
+
+type GetClients struct {
+	Name             string `form:"name" validate:"omitempty,max=100"`
+	Email            string `form:"email" validate:"omitempty,max=100"`
+	Category         string `form:"category" validate:"omitempty,max=50"`
+	ClientCategoryID uint   `form:"category_id" validate:"omitempty"`
+	IsActive         *bool  `form:"is_active" validate:"omitempty"`
+	MinBalance       string `form:"min_balance" validate:"omitempty,numeric"`
+	MaxBalance       string `form:"max_balance" validate:"omitempty,numeric"`
+	// Sort lists the fields to sort by, a leading "-" sorts in descending order, e.g. "last_name,-balance"
+	Sort     string `form:"sort" validate:"omitempty,max=200"`
+	Page     int    `form:"page" validate:"omitempty,min=1"`
+	PageSize int    `form:"page_size" validate:"omitempty,min=1,max=200"`
 }
 
 type ModifyBalance struct {
-	Difference float32 `json:"difference" validate:"required"`
+	Difference money.Money `json:"difference" validate:"required" swaggertype:"number"`
+}
+
+type CreateBalanceTransaction struct {
+	Type string `json:"type" validate:"required,oneof=top-up purchase refund correction"`
+	// Amount is added to the balance: positive for top-ups and refunds, negative for purchases
+	Amount money.Money `json:"amount" validate:"required_without=Items,excluded_with=Items" swaggertype:"number"`
+	// Items are the dishes bought, a purchase is given either as an amount or as the dishes bought
+	Items  []*BalanceTransactionItem `json:"items" validate:"omitempty,max=50,unique=DishID,dive"`
+	Reason string                    `json:"reason" validate:"omitempty,max=255"`
+	// DietaryOverrideReason is why the dishes are sold although the client is allergic to them
+	DietaryOverrideReason string `json:"dietary_override_reason" validate:"omitempty,max=255"`
+}
+
+type BalanceTransactionItem struct {
+	DishID   uint `json:"dish_id" validate:"required"`
+	Quantity int  `json:"quantity" validate:"required,min=1,max=100"`
+}
+
+type SetClientCreditLimit struct {
+	// CreditLimit overrides the credit limit of the client's category, null makes the category's limit apply again
+	CreditLimit *money.Money `json:"credit_limit" validate:"omitempty,min=0" swaggertype:"number"`
+}
+
+// SetClientSpendingLimits replaces the client's spending limits, a null limit is removed.
+type SetClientSpendingLimits struct {
+	Daily       *money.Money `json:"daily" validate:"omitempty,min=0" swaggertype:"number"`
+	Weekly      *money.Money `json:"weekly" validate:"omitempty,min=0" swaggertype:"number"`
+	Transaction *money.Money `json:"per_transaction" validate:"omitempty,min=0" swaggertype:"number"`
+}
+
+// SetClientDietaryRestrictions replaces the allergens the client is allergic to and the client's diets.
+type SetClientDietaryRestrictions struct {
+	AllergenIDs []uint `json:"allergen_ids" validate:"omitempty,max=50,unique"`
+	Vegetarian  bool   `json:"vegetarian"`
+	Halal       bool   `json:"halal"`
+}
+
+type CheckDietaryRestrictions struct {
+	Items []*BalanceTransactionItem `json:"items" validate:"required,min=1,max=50,unique=DishID,dive"`
+}
+
+type GetBalanceTransactions struct {
+	From string `form:"from" validate:"omitempty,datetime=2006-01-02"`
+	To   string `form:"to" validate:"omitempty,datetime=2006-01-02"`
+	Type string `form:"type" validate:"omitempty,oneof=top-up purchase refund correction"`
+}
+
+// GetStatement asks for a statement of the days from From to To, both inclusive, in the canteen's time zone.
+type GetStatement struct {
+	From   string `form:"from" validate:"required,datetime=2006-01-02"`
+	To     string `form:"to" validate:"required,datetime=2006-01-02"`
+	Format string `form:"format" validate:"omitempty,oneof=xlsx pdf"`
+}
+
+type IssueClientIdentifier struct {
+	Type   string `json:"type" validate:"required,oneof=card qr rfid"`
+	Value  string `json:"value" validate:"required,max=255"`
+	Reason string `json:"reason" validate:"omitempty,max=255"`
+}
+
+type SetClientIdentifierStatus struct {
+	Status string `json:"status" validate:"required,oneof=active lost blocked"`
+	Reason string `json:"reason" validate:"omitempty,max=255"`
+}
+
+type ReplaceClientIdentifier struct {
+	// Status is what became of the replaced identifier
+	Status string `json:"status" validate:"required,oneof=lost blocked"`
+	Value  string `json:"value" validate:"required,max=255"`
+	Reason string `json:"reason" validate:"omitempty,max=255"`
+}
+
+type LookUpClient struct {
+	Type  string `form:"type" validate:"required,oneof=card qr rfid"`
+	Value string `form:"value" validate:"required,max=255"`
 }
 
 type CreateClientCategory struct {
 	Name string `json:"name" validate:"required,min=1,max=20,alpha"`
+	// CreditLimit is how far below zero the balances of the category's clients may go
+	CreditLimit money.Money `json:"credit_limit" validate:"min=0" swaggertype:"number"`
 }
 
 type UpdateClientCategory struct {
-	Name     string `json:"name" validate:"omitempty,min=1,max=20,alpha"`
-	IsActive bool   `json:"is_active"`
+	Name        string       `json:"name" validate:"omitempty,min=1,max=20,alpha"`
+	IsActive    bool         `json:"is_active"`
+	CreditLimit *money.Money `json:"credit_limit" validate:"omitempty,min=0" swaggertype:"number"`
 }
 
 func MapCreateClientToClient(input *CreateClient) *models.Client {
@@ -57,21 +155,152 @@ func MapUpdateClientToClient(input *UpdateClient) *models.Client {
 		Gender:           input.Gender,
 		Email:            input.Email,
 		ClientCategoryID: input.ClientCategoryID,
-		Balance:          input.Balance,
 		IsActive:         input.IsActive,
 	}
 }
 
+// MapGetClientsToFilter turns the query into a filter. Pages hold 50 clients unless the page size is given.
+func MapGetClientsToFilter(input *GetClients) (*models.ClientFilter, error) {
+	filter := &models.ClientFilter{
+		Name:               strings.TrimSpace(input.Name),
+		Email:              strings.TrimSpace(input.Email),
+		ClientCategoryID:   input.ClientCategoryID,
+		ClientCategoryName: input.Category,
+		IsActive:           input.IsActive,
+		Limit:              50,
+	}
+
+	if input.MinBalance != "" {
+		minBalance, err := money.Parse(input.MinBalance)
+		if err != nil {
+			return nil, err
+		}
+		filter.MinBalance = &minBalance
+	}
+	if input.MaxBalance != "" {
+		maxBalance, err := money.Parse(input.MaxBalance)
+		if err != nil {
+			return nil, err
+		}
+		filter.MaxBalance = &maxBalance
+	}
+
+	for _, field := range strings.Split(input.Sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		filter.Sort = append(filter.Sort, models.SortField{Field: strings.TrimPrefix(field, "-"), Descending: strings.HasPrefix(field, "-")})
+	}
+
+	if input.PageSize != 0 {
+		filter.Limit = input.PageSize
+	}
+	if input.Page > 1 {
+		filter.Offset = (input.Page - 1) * filter.Limit
+	}
+
+	return filter, nil
+}
+
+// MapModifyBalanceToBalanceTransaction records a balance change made through the older modify-balance
+// endpoint as a top-up or a purchase, depending on its sign.
+func MapModifyBalanceToBalanceTransaction(input *ModifyBalance, clientID uint, idempotencyKey *string) *models.BalanceTransaction {
+	transactionType := constants.BalanceTransactionTopUp
+	if input.Difference < 0 {
+		transactionType = constants.BalanceTransactionPurchase
+	}
+
+	return &models.BalanceTransaction{
+		ClientID:       clientID,
+		Type:           transactionType,
+		Amount:         input.Difference,
+		IdempotencyKey: idempotencyKey,
+	}
+}
+
+func MapCreateBalanceTransactionToBalanceTransaction(input *CreateBalanceTransaction, clientID uint, idempotencyKey *string) *models.BalanceTransaction {
+	transaction := &models.BalanceTransaction{
+		ClientID:       clientID,
+		Type:           input.Type,
+		Amount:         input.Amount,
+		Reason:         input.Reason,
+		IdempotencyKey: idempotencyKey,
+
+		DietaryOverrideReason: input.DietaryOverrideReason,
+	}
+	for _, item := range input.Items {
+		transaction.Items = append(transaction.Items, models.BalanceTransactionItem{DishID: item.DishID, Quantity: item.Quantity})
+	}
+
+	return transaction
+}
+
+func MapSetClientSpendingLimitsToSpendingLimits(input *SetClientSpendingLimits) *models.SpendingLimits {
+	return &models.SpendingLimits{
+		Daily:       input.Daily,
+		Weekly:      input.Weekly,
+		Transaction: input.Transaction,
+	}
+}
+
+func MapSetClientDietaryRestrictionsToDietaryRestrictions(input *SetClientDietaryRestrictions) *models.DietaryRestrictions {
+	return &models.DietaryRestrictions{
+		AllergenIDs: input.AllergenIDs,
+		Vegetarian:  input.Vegetarian,
+		Halal:       input.Halal,
+	}
+}
+
+func MapCheckDietaryRestrictionsToBalanceTransactionItems(input *CheckDietaryRestrictions) []models.BalanceTransactionItem {
+	items := make([]models.BalanceTransactionItem, len(input.Items))
+	for i, item := range input.Items {
+		items[i] = models.BalanceTransactionItem{DishID: item.DishID, Quantity: item.Quantity}
+	}
+
+	return items
+}
+
+// MapGetStatementToPeriod returns the start of the first day of the statement and the end of its last day.
+func MapGetStatementToPeriod(input *GetStatement) (time.Time, time.Time) {
+	from := helpers.ConvertStringToCanteenDay(input.From)
+	to := helpers.ConvertStringToCanteenDay(input.To).In(helpers.CanteenLocation()).AddDate(0, 0, 1).In(time.Local)
+	return from, to
+}
+
+// MapGetBalanceTransactionsToFilter turns the date range into a filter. Both dates are inclusive.
+func MapGetBalanceTransactionsToFilter(input *GetBalanceTransactions) *models.BalanceTransactionFilter {
+	filter := &models.BalanceTransactionFilter{Type: input.Type}
+	if input.From != "" {
+		filter.From = helpers.ConvertStringToDate(input.From, "2006-01-02")
+	}
+	if input.To != "" {
+		filter.To = helpers.ConvertStringToDate(input.To, "2006-01-02").Add(24 * time.Hour)
+	}
+
+	return filter
+}
+
+func MapIssueClientIdentifierToClientIdentifier(input *IssueClientIdentifier, clientID uint) *models.ClientIdentifier {
+	return &models.ClientIdentifier{
+		ClientID: clientID,
+		Type:     input.Type,
+		Value:    input.Value,
+	}
+}
+
 func MapCreateClientCategoryToClientCategory(input *CreateClientCategory) *models.ClientCategory {
 	return &models.ClientCategory{
-		Name:     input.Name,
-		IsActive: true,
+		Name:        input.Name,
+		IsActive:    true,
+		CreditLimit: &input.CreditLimit,
 	}
 }
 
 func MapUpdateClientCategoryToClientCategory(input *UpdateClientCategory) *models.ClientCategory {
 	return &models.ClientCategory{
-		Name:     input.Name,
-		IsActive: input.IsActive,
+		Name:        input.Name,
+		IsActive:    input.IsActive,
+		CreditLimit: input.CreditLimit,
 	}
 }
@@ -0,0 +1,43 @@
+package request
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/money"
+)
+
+type CreateClientCategoryPricing struct {
+	// EffectiveFrom is the first day the rules apply on, today or later
+	EffectiveFrom   string      `json:"effective_from" validate:"required,datetime=2006-01-02"`
+	DiscountPercent float64     `json:"discount_percent" validate:"min=0,max=100"`
+	Subsidy         money.Money `json:"subsidy" validate:"min=0" swaggertype:"number"`
+	SubsidyPeriod   string      `json:"subsidy_period" validate:"omitempty,oneof=meal day"`
+	// Prices are the category's own prices of dishes, other dishes cost what the menu says
+	Prices []*ClientCategoryPrice `json:"prices" validate:"omitempty,max=500,unique=DishID,dive"`
+}
+
+type ClientCategoryPrice struct {
+	DishID uint        `json:"dish_id" validate:"required"`
+	Price  money.Money `json:"price" validate:"min=0" swaggertype:"number"`
+}
+
+// MapCreateClientCategoryPricingToClientCategoryPricing maps the rules, granting a subsidy per meal unless the period is given.
+func MapCreateClientCategoryPricingToClientCategoryPricing(input *CreateClientCategoryPricing, clientCategoryID uint) *models.ClientCategoryPricing {
+	pricing := &models.ClientCategoryPricing{
+		ClientCategoryID: clientCategoryID,
+		EffectiveFrom:    helpers.ConvertStringToCanteenDay(input.EffectiveFrom),
+		DiscountPercent:  input.DiscountPercent,
+		Subsidy:          input.Subsidy,
+		SubsidyPeriod:    input.SubsidyPeriod,
+		Prices:           make([]models.ClientCategoryPrice, len(input.Prices)),
+	}
+	if pricing.SubsidyPeriod == "" {
+		pricing.SubsidyPeriod = constants.SubsidyPerMeal
+	}
+	for i, price := range input.Prices {
+		pricing.Prices[i] = models.ClientCategoryPrice{DishID: price.DishID, Price: price.Price}
+	}
+
+	return pricing
+}
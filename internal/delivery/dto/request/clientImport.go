@@ -0,0 +1,117 @@
+package request
+
+import (
+	"Canteen-Backend/pkg/money"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxClientImportRows limits the number of clients imported from one file.
+const MaxClientImportRows = 5000
+
+// clientImportColumns maps the column headings an import file may use to the fields of CreateClient.
+// Headings are matched case-insensitively, with spaces and dashes read as underscores.
+var clientImportColumns = map[string]string{
+	"email":           "email",
+	"e_mail":          "email",
+	"first_name":      "first_name",
+	"firstname":       "first_name",
+	"last_name":       "last_name",
+	"lastname":        "last_name",
+	"surname":         "last_name",
+	"age":             "age",
+	"gender":          "gender",
+	"balance":         "balance",
+	"category":        "category",
+	"category_name":   "category",
+	"client_category": "category",
+}
+
+var requiredClientImportColumns = []string{"email", "first_name", "last_name", "age", "gender", "category"}
+
+// ImportClientRow is a client read from a row of an import file. The client's category is given by name,
+// and Error says why the row could not be read.
+type ImportClientRow struct {
+	Line     int
+	Input    *CreateClient
+	Category string
+	Error    string
+}
+
+// MapRowsToImportClientRows reads clients from the rows of an import file, the first of which names the columns.
+// Blank rows are skipped.
+func MapRowsToImportClientRows(rows [][]string) ([]*ImportClientRow, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("the file is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, heading := range rows[0] {
+		heading = strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(heading)))
+		if field, ok := clientImportColumns[heading]; ok {
+			columns[field] = i
+		}
+	}
+
+	var missing []string
+	for _, field := range requiredClientImportColumns {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("the file is missing the columns %s", strings.Join(missing, ", "))
+	}
+
+	var importRows []*ImportClientRow
+	for i, row := range rows[1:] {
+		cell := func(field string) string {
+			if column, ok := columns[field]; ok && column < len(row) {
+				return row[column]
+			}
+			return ""
+		}
+
+		if strings.Join(row, "") == "" {
+			continue
+		}
+		if len(importRows) == MaxClientImportRows {
+			return nil, fmt.Errorf("the file has more than %d clients", MaxClientImportRows)
+		}
+
+		importRow := &ImportClientRow{
+			Line: i + 2,
+			Input: &CreateClient{
+				Email:     cell("email"),
+				FirstName: cell("first_name"),
+				LastName:  cell("last_name"),
+				Gender:    strings.ToLower(cell("gender")),
+			},
+			Category: cell("category"),
+		}
+		importRows = append(importRows, importRow)
+
+		if age := cell("age"); age != "" {
+			parsedAge, err := strconv.ParseUint(age, 10, 32)
+			if err != nil {
+				importRow.Error = "Age: not a whole number"
+				continue
+			}
+			importRow.Input.Age = uint(parsedAge)
+		}
+
+		// spreadsheets in locales with a decimal comma write 12,50
+		if balance := strings.Replace(cell("balance"), ",", ".", 1); balance != "" {
+			parsedBalance, err := money.Parse(balance)
+			if err != nil {
+				importRow.Error = "Balance: " + err.Error()
+				continue
+			}
+			importRow.Input.Balance = parsedBalance
+		}
+	}
+
+	return importRows, nil
+}
@@ -0,0 +1,38 @@
+package request
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/money"
+)
+
+type CreateDish struct {
+	Name  string      `json:"name" validate:"required,min=1,max=100"`
+	Price money.Money `json:"price" validate:"min=0" swaggertype:"number"`
+}
+
+type UpdateDish struct {
+	Name     string      `json:"name" validate:"required,min=1,max=100"`
+	Price    money.Money `json:"price" validate:"min=0" swaggertype:"number"`
+	IsActive bool        `json:"is_active"`
+}
+
+// SetDishIngredients replaces the ingredients the dish is made of.
+type SetDishIngredients struct {
+	IngredientIDs []uint `json:"ingredient_ids" validate:"omitempty,max=100,unique"`
+}
+
+func MapCreateDishToDish(input *CreateDish) *models.Dish {
+	return &models.Dish{
+		Name:     input.Name,
+		Price:    input.Price,
+		IsActive: true,
+	}
+}
+
+func MapUpdateDishToDish(input *UpdateDish) *models.Dish {
+	return &models.Dish{
+		Name:     input.Name,
+		Price:    input.Price,
+		IsActive: input.IsActive,
+	}
+}
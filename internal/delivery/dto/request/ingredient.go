@@ -3,6 +3,7 @@ package request
 import (
 	"Canteen-Backend/internal/models"
 	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/money"
 )
 
 type CreateIngredient struct {
@@ -13,14 +14,14 @@ type CreateIngredient struct {
 
 // todo добавить валидацию больше нуля
 type UpdateIngredient struct {
-	Name                 string  `json:"name" validate:"omitempty,min=1,max=50,alphanumunicode_and_space"`
-	IngredientCategoryID uint    `json:"ingredient_category_id" validate:"omitempty,number"`
-	Unit                 string  `json:"unit" validate:"omitempty,alphaunicode"`
-	Quantity             float64 `json:"quantity" validate:"omitempty,number"`
-	UnitPrice            float64 `json:"unit_price" validate:"omitempty,number"`
-	LackLimit            float64 `json:"lack_limit" validate:"omitempty,number"`
-	PurchaseDate         string  `json:"purchase_date" validate:"omitempty,datetime=2006-01-02 15:04"`
-	ExpirationDate       string  `json:"expiration_date" validate:"omitempty,datetime=2006-01-02"`
+	Name                 string      `json:"name" validate:"omitempty,min=1,max=50,alphanumunicode_and_space"`
+	IngredientCategoryID uint        `json:"ingredient_category_id" validate:"omitempty,number"`
+	Unit                 string      `json:"unit" validate:"omitempty,alphaunicode"`
+	Quantity             float64     `json:"quantity" validate:"omitempty,number"`
+	UnitPrice            money.Money `json:"unit_price" validate:"omitempty,min=0" swaggertype:"number"`
+	LackLimit            float64     `json:"lack_limit" validate:"omitempty,number"`
+	PurchaseDate         string      `json:"purchase_date" validate:"omitempty,datetime=2006-01-02 15:04"`
+	ExpirationDate       string      `json:"expiration_date" validate:"omitempty,datetime=2006-01-02"`
 }
 
 func MapCreateIngredientToIngredient(input *CreateIngredient) *models.Ingredient {
@@ -63,3 +64,18 @@ func MapUpdateIngredientCategoryToIngredientCategory(input *UpdateIngredientCate
 		Name: input.Name,
 	}
 }
+
+// SetIngredientDietaryInfo replaces the allergens the ingredient contains and whether it is vegetarian and halal.
+type SetIngredientDietaryInfo struct {
+	AllergenIDs  []uint `json:"allergen_ids" validate:"omitempty,max=50,unique"`
+	IsVegetarian bool   `json:"is_vegetarian"`
+	IsHalal      bool   `json:"is_halal"`
+}
+
+func MapSetIngredientDietaryInfoToIngredientDietaryInfo(input *SetIngredientDietaryInfo) *models.IngredientDietaryInfo {
+	return &models.IngredientDietaryInfo{
+		AllergenIDs:  input.AllergenIDs,
+		IsVegetarian: input.IsVegetarian,
+		IsHalal:      input.IsHalal,
+	}
+}
@@ -3,6 +3,7 @@ package request
 import (
 	"Canteen-Backend/internal/models"
 	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/money"
 )
 
 type CreateSupplier struct {
@@ -28,17 +29,17 @@ func MapUpdateSupplierToSupplier(input *UpdateSupplier) *models.Supplier {
 type CreatePurchase struct {
 	PurchaseDate         string                `json:"purchase_date" validate:"required,datetime=2006-01-02 15:04"`
 	SupplierID           uint                  `json:"supplier_id" validate:"required,numeric"`
-	TotalSum             float64               `json:"total_sum" validate:"required,numeric"`
+	TotalSum             money.Money           `json:"total_sum" validate:"required,min=0" swaggertype:"number"`
 	PurchasedIngredients []PurchasedIngredient `json:"ingredients"`
 }
 
 // todo добавить валидацию больше нуля
 type PurchasedIngredient struct {
-	ID             uint    `json:"id" validate:"required,numeric"`
-	Name           string  `json:"name" validate:"required,min=1,max=50,alphanumunicode_and_space"`
-	Amount         float64 `json:"amount" validate:"required,numeric"`
-	Cost           float64 `json:"cost" validate:"required,numeric"`
-	ExpirationDate string  `json:"expiration_date" validate:"required,datetime=2006-01-02"`
+	ID             uint        `json:"id" validate:"required,numeric"`
+	Name           string      `json:"name" validate:"required,min=1,max=50,alphanumunicode_and_space"`
+	Amount         float64     `json:"amount" validate:"required,numeric"`
+	Cost           money.Money `json:"cost" validate:"required,min=0" swaggertype:"number"`
+	ExpirationDate string      `json:"expiration_date" validate:"required,datetime=2006-01-02"`
 }
 
 func MapCreatePurchaseToPurchase(input *CreatePurchase) *models.Purchase {
@@ -0,0 +1,32 @@
+package request
+
+import "Canteen-Backend/internal/models"
+
+type CreateRole struct {
+	Name        string   `json:"name" validate:"required,min=1,max=50"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRole struct {
+	Name        string `json:"name" validate:"omitempty,min=1,max=50"`
+	Description string `json:"description" validate:"omitempty,max=255"`
+	// Permissions replace the role's permissions if present, an empty list revokes all of them
+	Permissions []string `json:"permissions"`
+}
+
+func MapCreateRoleToRole(input *CreateRole) *models.UserRole {
+	return &models.UserRole{
+		Name:        input.Name,
+		Description: input.Description,
+		Permissions: input.Permissions,
+	}
+}
+
+func MapUpdateRoleToRole(input *UpdateRole) *models.UserRole {
+	return &models.UserRole{
+		Name:        input.Name,
+		Description: input.Description,
+		Permissions: input.Permissions,
+	}
+}
@@ -0,0 +1,18 @@
+package request
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/money"
+)
+
+type CreateTopUp struct {
+	ClientID uint        `json:"client_id" validate:"required"`
+	Amount   money.Money `json:"amount" validate:"required,gt=0" swaggertype:"number"`
+}
+
+func MapCreateTopUpToTopUp(input *CreateTopUp) *models.TopUp {
+	return &models.TopUp{
+		ClientID: input.ClientID,
+		Amount:   input.Amount,
+	}
+}
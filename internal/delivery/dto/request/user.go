@@ -11,6 +11,33 @@ type RefreshToken struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
+type TwoFactorSignIn struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type TwoFactorChallenge struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+type TwoFactorCode struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type ForgotPassword struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPassword struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8,max=20,any_uppercase,any_lowercase,any_digit,english_chars,any_special_char"`
+}
+
+type PasswordChangeSignIn struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	NewPassword    string `json:"new_password" validate:"required,min=8,max=20,any_uppercase,any_lowercase,any_digit,english_chars,any_special_char"`
+}
+
 type CreateUser struct {
 	Username   string `json:"username" validate:"required,min=4,max=20,alphanum"`
 	UserRoleID uint   `json:"user_role_id" validate:"required"`
@@ -18,6 +45,8 @@ type CreateUser struct {
 	Password   string `json:"password" validate:"required,min=8,max=20,any_uppercase,any_lowercase,any_digit,english_chars,any_special_char"`
 	FirstName  string `json:"first_name" validate:"required,min=1,max=20,alpha"`
 	LastName   string `json:"last_name" validate:"required,min=1,max=20,alpha"`
+	// MustChangePassword makes the user replace the initial password on the first sign-in
+	MustChangePassword bool `json:"must_change_password"`
 }
 
 type UpdateUser struct {
@@ -27,7 +56,20 @@ type UpdateUser struct {
 	Password   string `json:"password" validate:"omitempty,min=8,max=20,any_digit,any_uppercase,any_lowercase,english_chars,any_special_char"`
 	FirstName  string `json:"first_name" validate:"omitempty,min=1,max=20,alpha"`
 	LastName   string `json:"last_name" validate:"omitempty,min=1,max=20,alpha"`
-	IsActive   bool   `json:"is_active"`
+	IsActive   *bool  `json:"is_active"`
+	// MustChangePassword makes the user choose a new password on the next sign-in
+	MustChangePassword *bool `json:"must_change_password"`
+}
+
+type UpdateProfile struct {
+	Email     string `json:"email" validate:"omitempty,email"`
+	FirstName string `json:"first_name" validate:"omitempty,min=1,max=20,alpha"`
+	LastName  string `json:"last_name" validate:"omitempty,min=1,max=20,alpha"`
+}
+
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=20,any_uppercase,any_lowercase,any_digit,english_chars,any_special_char"`
 }
 
 func MapSignInToUser(input *SignIn) *models.User {
@@ -39,13 +81,14 @@ func MapSignInToUser(input *SignIn) *models.User {
 
 func MapCreateUserToUser(input *CreateUser) *models.User {
 	return &models.User{
-		Username:   input.Username,
-		Email:      input.Email,
-		Password:   input.Password,
-		FirstName:  input.FirstName,
-		LastName:   input.LastName,
-		UserRoleID: input.UserRoleID,
-		IsActive:   true,
+		Username:           input.Username,
+		Email:              input.Email,
+		Password:           input.Password,
+		FirstName:          input.FirstName,
+		LastName:           input.LastName,
+		UserRoleID:         input.UserRoleID,
+		IsActive:           true,
+		MustChangePassword: input.MustChangePassword,
 	}
 }
 
@@ -57,6 +100,13 @@ func MapUpdateUserToUser(input *UpdateUser) *models.User {
 		FirstName:  input.FirstName,
 		LastName:   input.LastName,
 		UserRoleID: input.UserRoleID,
-		IsActive:   input.IsActive,
+	}
+}
+
+func MapUpdateProfileToUser(input *UpdateProfile) *models.User {
+	return &models.User{
+		Email:     input.Email,
+		FirstName: input.FirstName,
+		LastName:  input.LastName,
 	}
 }
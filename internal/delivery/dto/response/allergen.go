@@ -0,0 +1,15 @@
+package response
+
+import "Canteen-Backend/internal/models"
+
+type GetAllergen struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+func MapAllergenToGetAllergen(allergen *models.Allergen) *GetAllergen {
+	return &GetAllergen{
+		ID:   allergen.ID,
+		Name: allergen.Name,
+	}
+}
@@ -0,0 +1,55 @@
+package response
+
+import (
+	"Canteen-Backend/internal/models"
+	"strings"
+	"time"
+)
+
+type GetAPIKey struct {
+	ID         uint     `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	UserRoleID uint     `json:"user_role_id"`
+	AllowedIPs []string `json:"allowed_ips"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	LastUsedIP string   `json:"last_used_ip,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+	IsActive   bool     `json:"is_active"`
+}
+
+func MapAPIKeyToGetAPIKey(apiKey *models.APIKey) *GetAPIKey {
+	allowedIPs := []string{}
+	if apiKey.AllowedIPs != "" {
+		allowedIPs = strings.Split(apiKey.AllowedIPs, ",")
+	}
+
+	var lastUsedIP string
+	if apiKey.LastUsedIP != nil {
+		lastUsedIP = *apiKey.LastUsedIP
+	}
+
+	return &GetAPIKey{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		UserRoleID: apiKey.UserRoleID,
+		AllowedIPs: allowedIPs,
+		ExpiresAt:  formatOptionalTime(apiKey.ExpiresAt),
+		LastUsedAt: formatOptionalTime(apiKey.LastUsedAt),
+		LastUsedIP: lastUsedIP,
+		CreatedAt:  apiKey.CreatedAt.Format("2006-01-02 15:04"),
+		RevokedAt:  formatOptionalTime(apiKey.RevokedAt),
+		IsActive:   apiKey.RevokedAt == nil && (apiKey.ExpiresAt == nil || apiKey.ExpiresAt.After(time.Now())),
+	}
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format("2006-01-02 15:04")
+}
@@ -1,22 +1,122 @@
 package response
 
-import "Canteen-Backend/internal/models"
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/money"
+)
 
 type GetClient struct {
-	ID               uint    `json:"id"`
-	FirstName        string  `json:"first_name"`
-	LastName         string  `json:"last_name"`
-	Age              uint    `json:"age"`
-	Gender           string  `json:"gender"`
-	Email            string  `json:"email"`
-	ClientCategoryID uint    `json:"client_category_id"`
-	Balance          float32 `json:"balance"`
-	IsActive         bool    `json:"is_active"`
+	ID               uint        `json:"id"`
+	FirstName        string      `json:"first_name"`
+	LastName         string      `json:"last_name"`
+	Age              uint        `json:"age"`
+	Gender           string      `json:"gender"`
+	Email            string      `json:"email"`
+	ClientCategoryID uint        `json:"client_category_id"`
+	Balance          money.Money `json:"balance" swaggertype:"number"`
+	IsActive         bool        `json:"is_active"`
+	// CreditLimit overrides the credit limit of the client's category
+	CreditLimit    *money.Money       `json:"credit_limit,omitempty" swaggertype:"number"`
+	SpendingLimits *GetSpendingLimits `json:"spending_limits"`
+}
+
+type GetSpendingLimits struct {
+	Daily       *money.Money `json:"daily" swaggertype:"number"`
+	Weekly      *money.Money `json:"weekly" swaggertype:"number"`
+	Transaction *money.Money `json:"per_transaction" swaggertype:"number"`
+}
+
+// GetClientPage is a page of the client list. Total is the number of all clients that match the filter.
+type GetClientPage struct {
+	Items    []*GetClient `json:"items"`
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+}
+
+type GetBalanceTransaction struct {
+	ID           uint        `json:"id"`
+	ClientID     uint        `json:"client_id"`
+	Type         string      `json:"type"`
+	Amount       money.Money `json:"amount" swaggertype:"number"`
+	BalanceAfter money.Money `json:"balance_after" swaggertype:"number"`
+	UserID       *uint       `json:"user_id,omitempty"`
+	APIKeyID     *uint       `json:"api_key_id,omitempty"`
+	Reason       string      `json:"reason,omitempty"`
+	// GrossAmount, Discount and Subsidy explain how a purchase was priced
+	GrossAmount             *money.Money                 `json:"gross_amount,omitempty" swaggertype:"number"`
+	Discount                money.Money                  `json:"discount" swaggertype:"number"`
+	Subsidy                 money.Money                  `json:"subsidy" swaggertype:"number"`
+	ClientCategoryPricingID *uint                        `json:"client_category_pricing_id,omitempty"`
+	Items                   []*GetBalanceTransactionItem `json:"items,omitempty"`
+	// DietaryConflicts warn of dishes sold that do not fit the client's diets, or that the client is allergic to
+	DietaryConflicts []*GetDietaryConflict `json:"dietary_conflicts,omitempty"`
+	CreatedAt        string                `json:"created_at"`
+}
+
+type GetBalanceTransactionItem struct {
+	DishID    uint        `json:"dish_id"`
+	Quantity  int         `json:"quantity"`
+	UnitPrice money.Money `json:"unit_price" swaggertype:"number"`
+}
+
+type GetDietaryRestrictions struct {
+	AllergenIDs []uint `json:"allergen_ids"`
+	Vegetarian  bool   `json:"vegetarian"`
+	Halal       bool   `json:"halal"`
+}
+
+// GetDietaryConflict is a dish the client should not eat. Restriction is the allergen's name, or the diet.
+// Blocking conflicts, the allergies, refuse the purchase unless a reason to override them is given.
+type GetDietaryConflict struct {
+	DishID      uint   `json:"dish_id"`
+	DishName    string `json:"dish_name"`
+	Restriction string `json:"restriction"`
+	Blocking    bool   `json:"blocking"`
+}
+
+type GetDietaryOverride struct {
+	ID                   uint   `json:"id"`
+	BalanceTransactionID uint   `json:"balance_transaction_id"`
+	DishID               uint   `json:"dish_id"`
+	Restriction          string `json:"restriction"`
+	Reason               string `json:"reason"`
+	UserID               *uint  `json:"user_id,omitempty"`
+	APIKeyID             *uint  `json:"api_key_id,omitempty"`
+	CreatedAt            string `json:"created_at"`
+}
+
+type GetClientIdentifier struct {
+	ID           uint   `json:"id"`
+	ClientID     uint   `json:"client_id"`
+	Type         string `json:"type"`
+	Value        string `json:"value"`
+	Status       string `json:"status"`
+	ReplacedByID *uint  `json:"replaced_by_id,omitempty"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+}
+
+type GetClientIdentifierEvent struct {
+	ID        uint   `json:"id"`
+	Event     string `json:"event"`
+	Status    string `json:"status"`
+	UserID    *uint  `json:"user_id,omitempty"`
+	APIKeyID  *uint  `json:"api_key_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type GetClientLookup struct {
+	Client     *GetClient           `json:"client"`
+	Category   *GetClientCategory   `json:"category"`
+	Identifier *GetClientIdentifier `json:"identifier"`
 }
 
 type GetClientCategory struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID          uint        `json:"id"`
+	Name        string      `json:"name"`
+	CreditLimit money.Money `json:"credit_limit" swaggertype:"number"`
 }
 
 func MapClientToGetClient(client *models.Client) *GetClient {
@@ -30,12 +130,126 @@ func MapClientToGetClient(client *models.Client) *GetClient {
 		ClientCategoryID: client.ClientCategoryID,
 		Balance:          client.Balance,
 		IsActive:         client.IsActive,
+		CreditLimit:      client.CreditLimit,
+		SpendingLimits: &GetSpendingLimits{
+			Daily:       client.SpendingLimits.Daily,
+			Weekly:      client.SpendingLimits.Weekly,
+			Transaction: client.SpendingLimits.Transaction,
+		},
+	}
+}
+
+func MapClientsToGetClientPage(clients *[]models.Client, total int64, filter *models.ClientFilter) *GetClientPage {
+	items := make([]*GetClient, len(*clients))
+	for i, client := range *clients {
+		items[i] = MapClientToGetClient(&client)
+	}
+
+	return &GetClientPage{
+		Items:    items,
+		Total:    total,
+		Page:     filter.Offset/filter.Limit + 1,
+		PageSize: filter.Limit,
+	}
+}
+
+func MapBalanceTransactionToGetBalanceTransaction(transaction *models.BalanceTransaction) *GetBalanceTransaction {
+	data := &GetBalanceTransaction{
+		ID:                      transaction.ID,
+		ClientID:                transaction.ClientID,
+		Type:                    transaction.Type,
+		Amount:                  transaction.Amount,
+		BalanceAfter:            transaction.BalanceAfter,
+		UserID:                  transaction.UserID,
+		APIKeyID:                transaction.APIKeyID,
+		Reason:                  transaction.Reason,
+		GrossAmount:             transaction.GrossAmount,
+		Discount:                transaction.Discount,
+		Subsidy:                 transaction.Subsidy,
+		ClientCategoryPricingID: transaction.ClientCategoryPricingID,
+		CreatedAt:               transaction.CreatedAt.Format("2006-01-02 15:04"),
+	}
+	for _, item := range transaction.Items {
+		data.Items = append(data.Items, &GetBalanceTransactionItem{DishID: item.DishID, Quantity: item.Quantity, UnitPrice: item.UnitPrice})
+	}
+	for i := range transaction.DietaryConflicts {
+		data.DietaryConflicts = append(data.DietaryConflicts, MapDietaryConflictToGetDietaryConflict(&transaction.DietaryConflicts[i]))
+	}
+
+	return data
+}
+
+func MapDietaryRestrictionsToGetDietaryRestrictions(restrictions *models.DietaryRestrictions) *GetDietaryRestrictions {
+	return &GetDietaryRestrictions{
+		AllergenIDs: restrictions.AllergenIDs,
+		Vegetarian:  restrictions.Vegetarian,
+		Halal:       restrictions.Halal,
+	}
+}
+
+func MapDietaryConflictToGetDietaryConflict(conflict *models.DietaryConflict) *GetDietaryConflict {
+	return &GetDietaryConflict{
+		DishID:      conflict.DishID,
+		DishName:    conflict.DishName,
+		Restriction: conflict.Restriction,
+		Blocking:    conflict.Blocking,
+	}
+}
+
+func MapDietaryOverrideToGetDietaryOverride(override *models.DietaryOverride) *GetDietaryOverride {
+	return &GetDietaryOverride{
+		ID:                   override.ID,
+		BalanceTransactionID: override.BalanceTransactionID,
+		DishID:               override.DishID,
+		Restriction:          override.Restriction,
+		Reason:               override.Reason,
+		UserID:               override.UserID,
+		APIKeyID:             override.APIKeyID,
+		CreatedAt:            override.CreatedAt.Format("2006-01-02 15:04"),
+	}
+}
+
+func MapClientIdentifierToGetClientIdentifier(identifier *models.ClientIdentifier) *GetClientIdentifier {
+	return &GetClientIdentifier{
+		ID:           identifier.ID,
+		ClientID:     identifier.ClientID,
+		Type:         identifier.Type,
+		Value:        identifier.Value,
+		Status:       identifier.Status,
+		ReplacedByID: identifier.ReplacedByID,
+		CreatedAt:    identifier.CreatedAt.Format("2006-01-02 15:04"),
+		UpdatedAt:    identifier.UpdatedAt.Format("2006-01-02 15:04"),
+	}
+}
+
+func MapClientIdentifierEventToGetClientIdentifierEvent(event *models.ClientIdentifierEvent) *GetClientIdentifierEvent {
+	return &GetClientIdentifierEvent{
+		ID:        event.ID,
+		Event:     event.Event,
+		Status:    event.Status,
+		UserID:    event.UserID,
+		APIKeyID:  event.APIKeyID,
+		Reason:    event.Reason,
+		CreatedAt: event.CreatedAt.Format("2006-01-02 15:04"),
+	}
+}
+
+func MapClientLookupToGetClientLookup(lookup *models.ClientLookup) *GetClientLookup {
+	return &GetClientLookup{
+		Client:     MapClientToGetClient(lookup.Client),
+		Category:   MapClientCategoryToGetClientCategory(lookup.Category),
+		Identifier: MapClientIdentifierToGetClientIdentifier(lookup.Identifier),
 	}
 }
 
 func MapClientCategoryToGetClientCategory(clientCategory *models.ClientCategory) *GetClientCategory {
-	return &GetClientCategory{
+	data := &GetClientCategory{
 		ID:   clientCategory.ID,
 		Name: clientCategory.Name,
 	}
+	if clientCategory.CreditLimit != nil {
+		data.CreditLimit = *clientCategory.CreditLimit
+	}
+
+	return data
 }
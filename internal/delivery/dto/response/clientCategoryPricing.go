@@ -0,0 +1,42 @@
+package response
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/money"
+)
+
+type GetClientCategoryPricing struct {
+	ID               uint                      `json:"id"`
+	ClientCategoryID uint                      `json:"client_category_id"`
+	EffectiveFrom    string                    `json:"effective_from"`
+	DiscountPercent  float64                   `json:"discount_percent"`
+	Subsidy          money.Money               `json:"subsidy" swaggertype:"number"`
+	SubsidyPeriod    string                    `json:"subsidy_period"`
+	Prices           []*GetClientCategoryPrice `json:"prices"`
+	UserID           *uint                     `json:"user_id,omitempty"`
+	CreatedAt        string                    `json:"created_at"`
+}
+
+type GetClientCategoryPrice struct {
+	DishID uint        `json:"dish_id"`
+	Price  money.Money `json:"price" swaggertype:"number"`
+}
+
+func MapClientCategoryPricingToGetClientCategoryPricing(pricing *models.ClientCategoryPricing) *GetClientCategoryPricing {
+	prices := make([]*GetClientCategoryPrice, len(pricing.Prices))
+	for i, price := range pricing.Prices {
+		prices[i] = &GetClientCategoryPrice{DishID: price.DishID, Price: price.Price}
+	}
+
+	return &GetClientCategoryPricing{
+		ID:               pricing.ID,
+		ClientCategoryID: pricing.ClientCategoryID,
+		EffectiveFrom:    pricing.EffectiveFrom.Format("2006-01-02 15:04"),
+		DiscountPercent:  pricing.DiscountPercent,
+		Subsidy:          pricing.Subsidy,
+		SubsidyPeriod:    pricing.SubsidyPeriod,
+		Prices:           prices,
+		UserID:           pricing.UserID,
+		CreatedAt:        pricing.CreatedAt.Format("2006-01-02 15:04"),
+	}
+}
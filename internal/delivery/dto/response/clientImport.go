@@ -0,0 +1,36 @@
+package response
+
+import "Canteen-Backend/internal/models"
+
+// ImportClients is the outcome of a client import. Valid rows are imported unless it was a dry run.
+type ImportClients struct {
+	DryRun   bool                  `json:"dry_run"`
+	Rows     int                   `json:"rows"`
+	Valid    int                   `json:"valid"`
+	Imported int                   `json:"imported"`
+	Errors   []*ImportClientsError `json:"errors"`
+}
+
+type ImportClientsError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+func MapClientImportRowsToImportClients(rows []*models.ClientImportRow, imported int, dryRun bool) *ImportClients {
+	result := &ImportClients{
+		DryRun:   dryRun,
+		Rows:     len(rows),
+		Imported: imported,
+		Errors:   []*ImportClientsError{},
+	}
+
+	for _, row := range rows {
+		if row.Error != "" {
+			result.Errors = append(result.Errors, &ImportClientsError{Line: row.Line, Error: row.Error})
+		} else {
+			result.Valid++
+		}
+	}
+
+	return result
+}
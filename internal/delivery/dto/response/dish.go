@@ -0,0 +1,46 @@
+package response
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/money"
+)
+
+type GetDish struct {
+	ID       uint        `json:"id"`
+	Name     string      `json:"name"`
+	Price    money.Money `json:"price" swaggertype:"number"`
+	IsActive bool        `json:"is_active"`
+}
+
+func MapDishToGetDish(dish *models.Dish) *GetDish {
+	return &GetDish{
+		ID:       dish.ID,
+		Name:     dish.Name,
+		Price:    dish.Price,
+		IsActive: dish.IsActive,
+	}
+}
+
+// GetDishDietaryInfo is worked out from the dish's ingredients.
+type GetDishDietaryInfo struct {
+	DishID        uint           `json:"dish_id"`
+	IngredientIDs []uint         `json:"ingredient_ids"`
+	Allergens     []*GetAllergen `json:"allergens"`
+	IsVegetarian  bool           `json:"is_vegetarian"`
+	IsHalal       bool           `json:"is_halal"`
+}
+
+func MapDishDietaryInfoToGetDishDietaryInfo(info *models.DishDietaryInfo) *GetDishDietaryInfo {
+	data := &GetDishDietaryInfo{
+		DishID:        info.DishID,
+		IngredientIDs: info.IngredientIDs,
+		Allergens:     []*GetAllergen{},
+		IsVegetarian:  info.IsVegetarian,
+		IsHalal:       info.IsHalal,
+	}
+	for i := range info.Allergens {
+		data.Allergens = append(data.Allergens, MapAllergenToGetAllergen(&info.Allergens[i]))
+	}
+
+	return data
+}
@@ -1,17 +1,20 @@
 package response
 
-import "Canteen-Backend/internal/models"
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/money"
+)
 
 type GetIngredient struct {
-	ID                   uint    `json:"id"`
-	Name                 string  `json:"name"`
-	IngredientCategoryID uint    `json:"ingredient_category_id"`
-	Unit                 string  `json:"unit"`
-	Quantity             float64 `json:"quantity"`
-	UnitPrice            float64 `json:"unit_price"`
-	LackLimit            float64 `json:"lack_limit"`
-	PurchaseDate         string  `json:"purchase_date"`
-	ExpirationDate       string  `json:"expiration_date"`
+	ID                   uint        `json:"id"`
+	Name                 string      `json:"name"`
+	IngredientCategoryID uint        `json:"ingredient_category_id"`
+	Unit                 string      `json:"unit"`
+	Quantity             float64     `json:"quantity"`
+	UnitPrice            money.Money `json:"unit_price" swaggertype:"number"`
+	LackLimit            float64     `json:"lack_limit"`
+	PurchaseDate         string      `json:"purchase_date"`
+	ExpirationDate       string      `json:"expiration_date"`
 }
 
 func MapIngredientToGetIngredient(ingredient *models.Ingredient) *GetIngredient {
@@ -39,3 +42,17 @@ func MapIngredientCategoryToGetIngredientCategory(ingredientCategory *models.Ing
 		Name: ingredientCategory.Name,
 	}
 }
+
+type GetIngredientDietaryInfo struct {
+	AllergenIDs  []uint `json:"allergen_ids"`
+	IsVegetarian bool   `json:"is_vegetarian"`
+	IsHalal      bool   `json:"is_halal"`
+}
+
+func MapIngredientDietaryInfoToGetIngredientDietaryInfo(info *models.IngredientDietaryInfo) *GetIngredientDietaryInfo {
+	return &GetIngredientDietaryInfo{
+		AllergenIDs:  info.AllergenIDs,
+		IsVegetarian: info.IsVegetarian,
+		IsHalal:      info.IsHalal,
+	}
+}
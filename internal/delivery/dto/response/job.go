@@ -0,0 +1,29 @@
+package response
+
+import "Canteen-Backend/internal/worker"
+
+type GetJob struct {
+	Name           string `json:"name"`
+	Interval       string `json:"interval"`
+	Running        bool   `json:"running"`
+	LastStartedAt  string `json:"last_started_at,omitempty"`
+	LastFinishedAt string `json:"last_finished_at,omitempty"`
+	LastResult     string `json:"last_result,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	AffectedRows   int64  `json:"affected_rows"`
+	NextRunAt      string `json:"next_run_at,omitempty"`
+}
+
+func MapJobStatusToGetJob(status *worker.JobStatus) *GetJob {
+	return &GetJob{
+		Name:           status.Name,
+		Interval:       status.Interval,
+		Running:        status.Running,
+		LastStartedAt:  formatOptionalTime(status.LastStartedAt),
+		LastFinishedAt: formatOptionalTime(status.LastFinishedAt),
+		LastResult:     status.LastResult,
+		LastError:      status.LastError,
+		AffectedRows:   status.AffectedRows,
+		NextRunAt:      formatOptionalTime(status.NextRunAt),
+	}
+}
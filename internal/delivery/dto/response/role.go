@@ -0,0 +1,36 @@
+package response
+
+import "Canteen-Backend/internal/models"
+
+type GetRole struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type GetPermission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func MapRoleToGetRole(role *models.UserRole) *GetRole {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	return &GetRole{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+	}
+}
+
+func MapPermissionToGetPermission(permission *models.Permission) *GetPermission {
+	return &GetPermission{
+		Name:        permission.Name,
+		Description: permission.Description,
+	}
+}
@@ -0,0 +1,42 @@
+package response
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/money"
+)
+
+type GetTopUp struct {
+	ID       uint        `json:"id"`
+	ClientID uint        `json:"client_id"`
+	Amount   money.Money `json:"amount" swaggertype:"number"`
+	Status   string      `json:"status"`
+	Provider string      `json:"provider"`
+	// CheckoutURL is the provider's page the client pays on
+	CheckoutURL           string  `json:"checkout_url,omitempty"`
+	ProviderPaymentID     *string `json:"provider_payment_id,omitempty"`
+	ProviderTransactionID *string `json:"provider_transaction_id,omitempty"`
+	// BalanceTransactionID is the top-up transaction that credited the balance once the payment succeeded
+	BalanceTransactionID *uint  `json:"balance_transaction_id,omitempty"`
+	UserID               *uint  `json:"user_id,omitempty"`
+	APIKeyID             *uint  `json:"api_key_id,omitempty"`
+	CreatedAt            string `json:"created_at"`
+	UpdatedAt            string `json:"updated_at"`
+}
+
+func MapTopUpToGetTopUp(topUp *models.TopUp) *GetTopUp {
+	return &GetTopUp{
+		ID:                    topUp.ID,
+		ClientID:              topUp.ClientID,
+		Amount:                topUp.Amount,
+		Status:                topUp.Status,
+		Provider:              topUp.Provider,
+		CheckoutURL:           topUp.CheckoutURL,
+		ProviderPaymentID:     topUp.ProviderPaymentID,
+		ProviderTransactionID: topUp.ProviderTransactionID,
+		BalanceTransactionID:  topUp.BalanceTransactionID,
+		UserID:                topUp.UserID,
+		APIKeyID:              topUp.APIKeyID,
+		CreatedAt:             topUp.CreatedAt.Format("2006-01-02 15:04"),
+		UpdatedAt:             topUp.UpdatedAt.Format("2006-01-02 15:04"),
+	}
+}
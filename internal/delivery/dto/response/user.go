@@ -1,6 +1,9 @@
 package response
 
-import "Canteen-Backend/internal/models"
+import (
+	"Canteen-Backend/internal/models"
+	"time"
+)
 
 type GetUser struct {
 	ID         uint   `json:"id"`
@@ -10,16 +13,93 @@ type GetUser struct {
 	LastName   string `json:"last_name"`
 	IsActive   bool   `json:"is_active"`
 	UserRoleID uint   `json:"user_role"`
+	// true once a TOTP enrollment has been confirmed
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+	// true until the user has replaced a password set by an admin
+	MustChangePassword bool `json:"must_change_password"`
+	// set while the user is locked out after too many failed sign-in attempts
+	LockedUntil string `json:"locked_until,omitempty"`
 }
 
 func MapUserToGetUser(user *models.User) *GetUser {
+	var lockedUntil string
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		lockedUntil = user.LockedUntil.Format("2006-01-02 15:04")
+	}
+
 	return &GetUser{
-		ID:         user.ID,
-		Username:   user.Username,
-		Email:      user.Email,
-		FirstName:  user.FirstName,
-		LastName:   user.LastName,
-		IsActive:   user.IsActive,
-		UserRoleID: user.UserRoleID,
+		ID:                 user.ID,
+		Username:           user.Username,
+		Email:              user.Email,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		IsActive:           user.IsActive,
+		UserRoleID:         user.UserRoleID,
+		TwoFactorEnabled:   user.TOTPEnabled,
+		MustChangePassword: user.MustChangePassword,
+		LockedUntil:        lockedUntil,
+	}
+}
+
+type GetProfile struct {
+	ID               uint        `json:"id"`
+	Username         string      `json:"username"`
+	Email            string      `json:"email"`
+	FirstName        string      `json:"first_name"`
+	LastName         string      `json:"last_name"`
+	Role             ProfileRole `json:"role"`
+	TwoFactorEnabled bool        `json:"two_factor_enabled"`
+}
+
+type ProfileRole struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+func MapUserToGetProfile(user *models.User, role *models.UserRole) *GetProfile {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	return &GetProfile{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Role: ProfileRole{
+			ID:          role.ID,
+			Name:        role.Name,
+			Permissions: permissions,
+		},
+		TwoFactorEnabled: user.TOTPEnabled,
+	}
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type GetSession struct {
+	ID         string `json:"id"`
+	IPAddress  string `json:"ip_address"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+	IsCurrent  bool   `json:"is_current"`
+}
+
+func MapSessionToGetSession(session *models.Session, currentSessionID string) *GetSession {
+	return &GetSession{
+		ID:         session.FamilyID,
+		IPAddress:  session.IPAddress,
+		UserAgent:  session.UserAgent,
+		CreatedAt:  session.SignedInAt.Format("2006-01-02 15:04"),
+		LastUsedAt: session.LastUsedAt.Format("2006-01-02 15:04"),
+		ExpiresAt:  session.ExpiresAt.Format("2006-01-02 15:04"),
+		IsCurrent:  session.FamilyID == currentSessionID,
 	}
 }
@@ -0,0 +1,334 @@
+// Package export renders documents staff download, such as client statements, as XLSX and PDF files.
+package export
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/excelizer"
+	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/money"
+	"Canteen-Backend/pkg/pdf"
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	statementFont     pdf.Font
+	statementFontErr  error
+	statementFontOnce sync.Once
+)
+
+var transactionTypeNames = map[string]string{
+	constants.BalanceTransactionTopUp:      "Top-up",
+	constants.BalanceTransactionPurchase:   "Purchase",
+	constants.BalanceTransactionRefund:     "Refund",
+	constants.BalanceTransactionCorrection: "Correction",
+}
+
+// statementColumns are the columns of a statement's table of transactions. Widths are in characters for XLSX.
+var statementColumns = []excelizer.Column{
+	{Title: "Date", Width: 18},
+	{Title: "Type", Width: 12},
+	{Title: "Details", Width: 36},
+	{Title: "Discount", Width: 12, Money: true},
+	{Title: "Subsidy", Width: 12, Money: true},
+	{Title: "Amount", Width: 14, Money: true},
+	{Title: "Balance", Width: 14, Money: true},
+}
+
+// ContentType returns the MIME type of files of the statement format.
+func ContentType(format string) string {
+	if format == constants.StatementFormatPDF {
+		return "application/pdf"
+	}
+
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+// StatementFileName returns the name of the statement's file, e.g. "statement-42-2024-01-01-2024-01-31.pdf".
+func StatementFileName(statement *models.Statement, format string) string {
+	from, to := statementPeriod(statement)
+	return fmt.Sprintf("statement-%d-%s-%s.%s", statement.Client.ID, from, to, format)
+}
+
+// WriteStatement writes the statement in the format, constants.StatementFormatXLSX or constants.StatementFormatPDF.
+func WriteStatement(w io.Writer, statement *models.Statement, format string) error {
+	if format == constants.StatementFormatPDF {
+		return writeStatementPDF(w, statement)
+	}
+
+	return writeStatementXLSX(w, statement)
+}
+
+// WriteStatementArchive writes the statements as a ZIP archive of a file per client, named after the client.
+func WriteStatementArchive(w io.Writer, statements []models.Statement, format string) error {
+	archive := zip.NewWriter(w)
+	for i := range statements {
+		statement := &statements[i]
+		name := fmt.Sprintf("%d %s %s.%s", statement.Client.ID, statement.Client.LastName, statement.Client.FirstName, format)
+		file, err := archive.Create(sanitizeFileName(name))
+		if err != nil {
+			return err
+		}
+		if err := WriteStatement(file, statement, format); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func writeStatementXLSX(w io.Writer, statement *models.Statement) error {
+	report, err := excelizer.NewReport("Statement", excelizer.Brand{Name: brandName(), Color: brandColor()}, statementColumns)
+	if err != nil {
+		return err
+	}
+
+	from, to := statementPeriod(statement)
+	report.AddTitle("Account statement")
+	for _, field := range statementFields(statement) {
+		report.AddField(field[0], field[1])
+	}
+	report.AddField("Period", from+" – "+to)
+	report.AddMoneyField("Opening balance", statement.OpeningBalance.Float64())
+	report.AddBlankRow()
+
+	rows := make([][]interface{}, len(statement.Transactions))
+	for i, transaction := range statement.Transactions {
+		rows[i] = []interface{}{
+			transaction.CreatedAt.Format("2006-01-02 15:04"),
+			transactionTypeNames[transaction.Type],
+			transaction.Reason,
+			transaction.Discount.Float64(),
+			transaction.Subsidy.Float64(),
+			transaction.Amount.Float64(),
+			transaction.BalanceAfter.Float64(),
+		}
+	}
+	report.AddTable(rows)
+	report.AddBlankRow()
+
+	credits, debits := statementTotals(statement)
+	report.AddMoneyField("Total credits", credits.Float64())
+	report.AddMoneyField("Total debits", debits.Float64())
+	report.AddMoneyField("Closing balance", statement.ClosingBalance.Float64())
+	report.AddBlankRow()
+	report.AddField("Generated", time.Now().Format("2006-01-02 15:04"))
+
+	return report.Write(w)
+}
+
+// The layout of PDF statements, in points.
+const (
+	pageMargin     = 40.0
+	pageBottom     = pdf.A4Height - 50
+	lineHeight     = 15.0
+	rowHeight      = 16.0
+	tableFontSize  = 8.5
+	detailFontSize = 10.0
+)
+
+// pdfColumnWidths are the widths of the statement's columns in PDF, filling the page between the margins.
+var pdfColumnWidths = []float64{76, 54, 134.28, 54, 54, 67, 76}
+
+func writeStatementPDF(w io.Writer, statement *models.Statement) error {
+	font, err := loadStatementFont()
+	if err != nil {
+		return err
+	}
+
+	brand, white, grey, band := parseColor(brandColor()), pdf.Color{R: 255, G: 255, B: 255}, pdf.Color{R: 110, G: 110, B: 110}, pdf.Color{R: 242, G: 242, B: 242}
+	doc := pdf.New(pdf.A4Width, pdf.A4Height, font)
+	doc.SetTitle("Account statement")
+
+	from, to := statementPeriod(statement)
+	var y float64
+	newPage := func() {
+		doc.AddPage()
+		doc.Rect(0, 0, pdf.A4Width, 70, brand)
+		doc.Text(pageMargin, 28, 10, false, white, brandName())
+		doc.Text(pageMargin, 52, 18, true, white, "Account statement")
+		doc.TextRight(pdf.A4Width-pageMargin, 52, 10, false, white, from+" – "+to)
+		doc.TextRight(pdf.A4Width-pageMargin, pdf.A4Height-25, 8, false, grey, "Page "+strconv.Itoa(doc.PageCount()))
+		y = 100
+	}
+	tableHeader := func() {
+		doc.Rect(pageMargin, y, pdf.A4Width-2*pageMargin, rowHeight+2, brand)
+		x := pageMargin
+		for i, column := range statementColumns {
+			if column.Money {
+				doc.TextRight(x+pdfColumnWidths[i]-4, y+12, tableFontSize, true, white, column.Title)
+			} else {
+				doc.Text(x+4, y+12, tableFontSize, true, white, column.Title)
+			}
+			x += pdfColumnWidths[i]
+		}
+		y += rowHeight + 2
+	}
+
+	newPage()
+	fields := append(statementFields(statement), [2]string{"Opening balance", statement.OpeningBalance.String()})
+	for _, field := range fields {
+		doc.Text(pageMargin, y, detailFontSize, true, pdf.Black, field[0])
+		doc.Text(pageMargin+110, y, detailFontSize, false, pdf.Black, field[1])
+		y += lineHeight
+	}
+	y += lineHeight
+
+	tableHeader()
+	for i, transaction := range statement.Transactions {
+		if y+rowHeight > pageBottom {
+			newPage()
+			tableHeader()
+		}
+		if i%2 == 1 {
+			doc.Rect(pageMargin, y, pdf.A4Width-2*pageMargin, rowHeight, band)
+		}
+
+		cells := []string{
+			transaction.CreatedAt.Format("2006-01-02 15:04"),
+			transactionTypeNames[transaction.Type],
+			transaction.Reason,
+			transaction.Discount.String(),
+			transaction.Subsidy.String(),
+			transaction.Amount.String(),
+			transaction.BalanceAfter.String(),
+		}
+		x := pageMargin
+		for j, cell := range cells {
+			if statementColumns[j].Money {
+				doc.TextRight(x+pdfColumnWidths[j]-4, y+11.5, tableFontSize, false, pdf.Black, cell)
+			} else {
+				doc.Text(x+4, y+11.5, tableFontSize, false, pdf.Black, fitText(doc, cell, pdfColumnWidths[j]-8, tableFontSize))
+			}
+			x += pdfColumnWidths[j]
+		}
+		y += rowHeight
+	}
+	if len(statement.Transactions) == 0 {
+		doc.Text(pageMargin+4, y+11.5, tableFontSize, false, grey, "No transactions in the period")
+		y += rowHeight
+	}
+	doc.Line(pageMargin, y, pdf.A4Width-pageMargin, y, 0.5, grey)
+
+	credits, debits := statementTotals(statement)
+	totals := [][2]string{
+		{"Total credits", credits.String()},
+		{"Total debits", debits.String()},
+		{"Closing balance", statement.ClosingBalance.String()},
+	}
+	if y+lineHeight*float64(len(totals)+2) > pageBottom {
+		newPage()
+	}
+	y += lineHeight * 1.5
+	for _, total := range totals {
+		doc.Text(pdf.A4Width-pageMargin-220, y, detailFontSize, true, pdf.Black, total[0])
+		doc.TextRight(pdf.A4Width-pageMargin-4, y, detailFontSize, true, pdf.Black, total[1])
+		y += lineHeight
+	}
+	doc.Text(pageMargin, pdf.A4Height-25, 8, false, grey, "Generated "+time.Now().Format("2006-01-02 15:04"))
+
+	return doc.Write(w)
+}
+
+// statementFields are the details of the client heading a statement.
+func statementFields(statement *models.Statement) [][2]string {
+	client := &statement.Client
+	return [][2]string{
+		{"Client", strings.TrimSpace(client.FirstName + " " + client.LastName)},
+		{"Client ID", strconv.FormatUint(uint64(client.ID), 10)},
+		{"Category", statement.ClientCategoryName},
+		{"Email", client.Email},
+	}
+}
+
+// statementPeriod returns the first and the last day of the statement's period, in the canteen's time zone.
+func statementPeriod(statement *models.Statement) (string, string) {
+	location := helpers.CanteenLocation()
+	return statement.From.In(location).Format("2006-01-02"), statement.To.In(location).AddDate(0, 0, -1).Format("2006-01-02")
+}
+
+// statementTotals returns how much was added to the balance and how much taken off it in the period.
+func statementTotals(statement *models.Statement) (money.Money, money.Money) {
+	var credits, debits money.Money
+	for _, transaction := range statement.Transactions {
+		if transaction.Amount > 0 {
+			credits += transaction.Amount
+		} else {
+			debits -= transaction.Amount
+		}
+	}
+
+	return credits, debits
+}
+
+// fitText shortens the text with an ellipsis until it fits the width.
+func fitText(doc *pdf.Document, text string, width, size float64) string {
+	if doc.TextWidth(text, size) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && doc.TextWidth(string(runes)+"…", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+
+	return string(runes) + "…"
+}
+
+// sanitizeFileName replaces the characters file systems do not allow in names.
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// brandName returns the canteen's name, CANTEEN_NAME, that heads exported documents.
+func brandName() string {
+	return helpers.GetEnv("CANTEEN_NAME", "Canteen")
+}
+
+// brandColor returns the colour, CANTEEN_BRAND_COLOR, of the headers of exported documents, e.g. "#1F4E78".
+func brandColor() string {
+	color := helpers.GetEnv("CANTEEN_BRAND_COLOR", "#1F4E78")
+	if _, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32); err != nil || len(strings.TrimPrefix(color, "#")) != 6 {
+		return "#1F4E78"
+	}
+
+	return "#" + strings.TrimPrefix(color, "#")
+}
+
+func parseColor(color string) pdf.Color {
+	value, _ := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	return pdf.Color{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value)}
+}
+
+// loadStatementFont returns the font of PDF statements: the TrueType font at STATEMENT_PDF_FONT, for names
+// in alphabets Helvetica does not have, or Helvetica if none is set.
+func loadStatementFont() (pdf.Font, error) {
+	statementFontOnce.Do(func() {
+		path := helpers.GetEnv("STATEMENT_PDF_FONT", "")
+		if path == "" {
+			statementFont = pdf.Helvetica()
+			return
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			statementFontErr = err
+			return
+		}
+		statementFont, statementFontErr = pdf.LoadTrueType(data)
+	})
+
+	return statementFont, statementFontErr
+}
@@ -0,0 +1,153 @@
+package export
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/money"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testStatement is January's statement of a client whose name Helvetica does not have the letters for.
+func testStatement() *models.Statement {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &models.Statement{
+		Client:             models.Client{ID: 42, FirstName: "Иван", LastName: "Петров", Email: "ivan@example.com"},
+		ClientCategoryName: "Students",
+		From:               from,
+		To:                 from.AddDate(0, 1, 0),
+		OpeningBalance:     money.FromMinorUnits(1000),
+		Transactions: []models.BalanceTransaction{
+			{Type: constants.BalanceTransactionTopUp, Amount: money.FromMinorUnits(2000), BalanceAfter: money.FromMinorUnits(3000),
+				Reason: "Cash", CreatedAt: from.Add(9 * time.Hour)},
+			{Type: constants.BalanceTransactionPurchase, Amount: money.FromMinorUnits(-1250), BalanceAfter: money.FromMinorUnits(1750),
+				Reason: "Lunch", CreatedAt: from.AddDate(0, 0, 1).Add(12 * time.Hour)},
+		},
+		ClosingBalance: money.FromMinorUnits(1750),
+	}
+}
+
+var pdfStream = regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`)
+
+// pdfContent returns the inflated streams of the PDF file, page contents and embedded fonts alike.
+func pdfContent(t *testing.T, data []byte) string {
+	t.Helper()
+
+	var content strings.Builder
+	for _, match := range pdfStream.FindAllSubmatch(data, -1) {
+		r, err := zlib.NewReader(bytes.NewReader(match[1]))
+		if err != nil {
+			t.Fatalf("inflating a stream: %v", err)
+		}
+		inflated, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("inflating a stream: %v", err)
+		}
+		content.Write(inflated)
+	}
+
+	return content.String()
+}
+
+// helveticaText returns how the text, in letters Helvetica has, is drawn in a page's content.
+func helveticaText(text string) string {
+	return fmt.Sprintf("<%X>", text)
+}
+
+func TestWriteStatementPDF(t *testing.T) {
+	t.Setenv("STATEMENT_PDF_FONT", "")
+
+	var buf bytes.Buffer
+	if err := WriteStatement(&buf, testStatement(), constants.StatementFormatPDF); err != nil {
+		t.Fatalf("WriteStatement() error = %v", err)
+	}
+	data := buf.String()
+	if !strings.HasPrefix(data, "%PDF-1.4\n") || !strings.HasSuffix(data, "%%EOF\n") {
+		t.Fatalf("WriteStatement() did not write a PDF file")
+	}
+
+	// without STATEMENT_PDF_FONT the text is in Helvetica, which draws the Cyrillic name as question marks
+	if !strings.Contains(data, "/BaseFont /Helvetica") || strings.Contains(data, "/FontFile2") {
+		t.Errorf("statement is not in the Helvetica every reader has")
+	}
+	content := pdfContent(t, buf.Bytes())
+	if !strings.Contains(content, helveticaText("???? ??????")) {
+		t.Errorf("client name not drawn as question marks in Helvetica")
+	}
+
+	tests := []struct {
+		label, value string
+	}{
+		{"Opening balance", "10.00"},
+		{"Total credits", "20.00"},
+		{"Total debits", "12.50"},
+		{"Closing balance", "17.50"},
+	}
+	for _, tt := range tests {
+		at := strings.Index(content, helveticaText(tt.label))
+		if at < 0 {
+			t.Errorf("%s not in the statement", tt.label)
+			continue
+		}
+		if !strings.Contains(content[at:], helveticaText(tt.value)) {
+			t.Errorf("%s not followed by %s", tt.label, tt.value)
+		}
+	}
+
+	// every cell is drawn whole, a date in particular is not shortened to fit its column
+	for _, cell := range []string{"Cash", "Lunch", "-12.50", "30.00", "2024-01-02 12:00"} {
+		if !strings.Contains(content, helveticaText(cell)) {
+			t.Errorf("transaction cell %q not in the statement", cell)
+		}
+	}
+}
+
+func TestWriteStatementXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteStatement(&buf, testStatement(), constants.StatementFormatXLSX); err != nil {
+		t.Fatalf("WriteStatement() error = %v", err)
+	}
+
+	file, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	fields := map[string]string{}
+	for _, row := range file.GetRows("Statement") {
+		if len(row) >= 2 {
+			fields[row[0]] = row[1]
+		}
+	}
+
+	if fields["Client"] != "Иван Петров" || fields["Period"] != "2024-01-01 – 2024-01-31" {
+		t.Errorf("client %q and period %q, want Иван Петров and January", fields["Client"], fields["Period"])
+	}
+	tests := []struct {
+		label string
+		want  float64
+	}{
+		{"Opening balance", 10},
+		{"Total credits", 20},
+		{"Total debits", 12.5},
+		{"Closing balance", 17.5},
+	}
+	for _, tt := range tests {
+		if got, err := strconv.ParseFloat(fields[tt.label], 64); err != nil || got != tt.want {
+			t.Errorf("%s = %q, want %v", tt.label, fields[tt.label], tt.want)
+		}
+	}
+}
+
+func TestStatementFileName(t *testing.T) {
+	if name := StatementFileName(testStatement(), constants.StatementFormatPDF); name != "statement-42-2024-01-01-2024-01-31.pdf" {
+		t.Fatalf("StatementFileName() = %q", name)
+	}
+}
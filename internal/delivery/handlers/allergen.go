@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/delivery/dto/request"
+	"Canteen-Backend/internal/delivery/dto/response"
+	"Canteen-Backend/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// CreateAllergen godoc
+// @Summary Create a new allergen
+// @Description Add an allergen to the catalogue that ingredients are tagged with and clients can be allergic to
+// @Tags allergens
+// @Accept json
+// @Produce json
+// @Param input body request.CreateAllergen true "Allergen object to be created"
+// @Success 200 {integer} integer 1
+// @Failure 400 {string} string
+// @Failure 409 {string} string "Allergen already exists"
+// @Failure 500 {string} string
+// @Router /api/allergens [post]
+func (h *IngredientHandler) CreateAllergen(c *gin.Context) {
+	var input *request.CreateAllergen
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
+		return
+	}
+
+	id, customErr := h.ingredientUseCase.CreateAllergen(request.MapCreateAllergenToAllergen(input))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "allergen created", gin.H{"id": id})
+}
+
+// GetAllAllergens godoc
+// @Summary Get all allergens
+// @Description Get the allergen catalogue
+// @Tags allergens
+// @Produce json
+// @Success 200 {array} response.GetAllergen "Successful response"
+// @Failure 500 {string} string
+// @Router /api/allergens [get]
+func (h *IngredientHandler) GetAllAllergens(c *gin.Context) {
+	allergens, customErr := h.ingredientUseCase.GetAllAllergens()
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	data := make([]*response.GetAllergen, len(*allergens))
+	for i, allergen := range *allergens {
+		data[i] = response.MapAllergenToGetAllergen(&allergen)
+	}
+	NewSuccessResponse(c, http.StatusOK, "allergens received", data)
+}
+
+// UpdateAllergen godoc
+// @Summary Rename the existing allergen
+// @Description Update the allergen's name
+// @Tags allergens
+// @Accept json
+// @Produce json
+// @Param id path int true "Allergen ID" Format(int64)
+// @Param input body request.UpdateAllergen true "Allergen object to be updated"
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string "Allergen already exists"
+// @Failure 500 {string} string
+// @Router /api/allergens/{id} [put]
+func (h *IngredientHandler) UpdateAllergen(c *gin.Context) {
+	var input *request.UpdateAllergen
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"id": id})
+		return
+	}
+
+	allergen := request.MapUpdateAllergenToAllergen(input)
+	allergen.ID = uint(id)
+	if customErr := h.ingredientUseCase.UpdateAllergen(allergen); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "allergen updated", nil)
+}
+
+// DeleteAllergen godoc
+// @Summary Delete an allergen
+// @Description Delete the allergen. Ingredients are no longer tagged with it and clients no longer count as allergic to it
+// @Tags allergens
+// @Produce json
+// @Param id path int true "Allergen ID" Format(int64)
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/allergens/{id} [delete]
+func (h *IngredientHandler) DeleteAllergen(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if customErr := h.ingredientUseCase.DeleteAllergen(uint(id)); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "allergen deleted", nil)
+}
+
+// GetIngredientDietaryInfo godoc
+// @Summary Get the dietary info of an ingredient
+// @Description Get the allergens the ingredient contains and whether it is vegetarian and halal
+// @Tags ingredients
+// @Produce json
+// @Param id path int true "Ingredient ID" Format(int64)
+// @Success 200 {object} response.GetIngredientDietaryInfo "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/ingredients/{id}/dietary-info [get]
+func (h *IngredientHandler) GetIngredientDietaryInfo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	info, customErr := h.ingredientUseCase.GetIngredientDietaryInfo(uint(id))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "ingredient dietary info received", response.MapIngredientDietaryInfoToGetIngredientDietaryInfo(info))
+}
+
+// SetIngredientDietaryInfo godoc
+// @Summary Set the dietary info of an ingredient
+// @Description Replace the allergens the ingredient contains and whether it is vegetarian and halal.
+// @Description The dietary info of the dishes made of it changes accordingly
+// @Tags ingredients
+// @Accept json
+// @Produce json
+// @Param id path int true "Ingredient ID" Format(int64)
+// @Param input body request.SetIngredientDietaryInfo true "Dietary info"
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Failure 404 {string} string "Ingredient or allergen not found"
+// @Failure 500 {string} string
+// @Router /api/ingredients/{id}/dietary-info [put]
+func (h *IngredientHandler) SetIngredientDietaryInfo(c *gin.Context) {
+	var input *request.SetIngredientDietaryInfo
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"id": id})
+		return
+	}
+
+	info := request.MapSetIngredientDietaryInfoToIngredientDietaryInfo(input)
+	if customErr := h.ingredientUseCase.SetIngredientDietaryInfo(uint(id), info); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "ingredient dietary info set", nil)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/delivery/dto/request"
+	"Canteen-Backend/internal/delivery/dto/response"
+	"Canteen-Backend/internal/usecase"
+	"Canteen-Backend/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) initAPIKeyRoutes(api *gin.RouterGroup) {
+
+	apiKeys := api.Group("/api-keys")
+	{
+		apiKeys.Use(h.authenticateUser)
+		{
+			apiKeys.POST("/", h.requirePermissions(constants.PermissionAPIKeysWrite), h.apiKeyHandler.CreateAPIKey)
+			apiKeys.GET("/", h.requirePermissions(constants.PermissionAPIKeysRead), h.apiKeyHandler.GetAllAPIKeys)
+			apiKeys.GET("/:id", h.requirePermissions(constants.PermissionAPIKeysRead), h.apiKeyHandler.GetAPIKeyByID)
+			apiKeys.DELETE("/:id", h.requirePermissions(constants.PermissionAPIKeysWrite), h.apiKeyHandler.RevokeAPIKey)
+		}
+	}
+}
+
+type APIKeyHandler struct {
+	apiKeyUseCase usecase.APIKey
+}
+
+func NewAPIKeyHandler(apiKeyUseCase usecase.APIKey) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyUseCase: apiKeyUseCase}
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create a long-lived API key for a machine client with the permissions of a role.
+// @Description The key is only returned in this response
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param input body request.CreateAPIKey true "API key object to be created"
+// @Success 200 {object} map[string]interface{} "ID and key"
+// @Failure 400 {string} string "Invalid input JSON"
+// @Failure 404 {string} string "Role not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var input *request.CreateAPIKey
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	apiKey := request.MapCreateAPIKeyToAPIKey(input)
+	if userID := c.GetUint("user_id"); userID != 0 {
+		apiKey.CreatedBy = &userID
+	}
+
+	id, key, customErr := h.apiKeyUseCase.CreateAPIKey(apiKey)
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSensitiveSuccessResponse(c, http.StatusOK, "API key created", gin.H{
+		"id":  id,
+		"key": key,
+	})
+}
+
+// GetAllAPIKeys godoc
+// @Summary Get all API keys
+// @Description Get all API keys, including revoked and expired ones
+// @Tags api-keys
+// @Produce json
+// @Success 200 {array} response.GetAPIKey "Successful response"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/api-keys [get]
+func (h *APIKeyHandler) GetAllAPIKeys(c *gin.Context) {
+	apiKeys, customErr := h.apiKeyUseCase.GetAllAPIKeys()
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	data := make([]*response.GetAPIKey, len(*apiKeys))
+	for i, apiKey := range *apiKeys {
+		data[i] = response.MapAPIKeyToGetAPIKey(&apiKey)
+	}
+	NewSuccessResponse(c, http.StatusOK, "all API keys retrieved", data)
+}
+
+// GetAPIKeyByID godoc
+// @Summary Get an API key by ID
+// @Description Get an API key based on ID
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "API key ID" Format(int64)
+// @Success 200 {object} response.GetAPIKey "Successful response"
+// @Failure 400 {string} string "Invalid API key id"
+// @Failure 404 {string} string "API key not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/api-keys/{id} [get]
+func (h *APIKeyHandler) GetAPIKeyByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	apiKey, customErr := h.apiKeyUseCase.GetAPIKeyByID(uint(id))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "API key retrieved", response.MapAPIKeyToGetAPIKey(apiKey))
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key based on ID. It stops working immediately
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "API key ID" Format(int64)
+// @Success 200 {string} string "API key revoked"
+// @Failure 400 {string} string "Invalid API key id"
+// @Failure 404 {string} string "API key not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if customErr := h.apiKeyUseCase.RevokeAPIKey(uint(id)); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "API key revoked", nil)
+}
@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"Canteen-Backend/internal/constants"
 	"Canteen-Backend/internal/delivery/dto/request"
 	"Canteen-Backend/internal/delivery/dto/response"
 	"Canteen-Backend/internal/usecase"
+	"Canteen-Backend/pkg/customErr"
 	"Canteen-Backend/pkg/validator"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 func (h *Handler) initClientRoutes(api *gin.RouterGroup) {
@@ -16,22 +19,47 @@ func (h *Handler) initClientRoutes(api *gin.RouterGroup) {
 	{
 		clients := api.Group("/clients")
 		{
-			clients.POST("/", h.clientHandler.CreateClient)
-			clients.GET("/", h.clientHandler.GetAllClients)
-			clients.GET("/:id", h.clientHandler.GetClientByID)
-			clients.PUT("/:id", h.clientHandler.UpdateClient)
-			clients.DELETE("/:id", h.clientHandler.DeleteClient)
-
-			clients.PUT("/:id/modify-balance", h.clientHandler.ModifyBalanceByClientID)
+			clients.POST("/", h.requirePermissions(constants.PermissionClientsWrite), h.clientHandler.CreateClient)
+			clients.POST("/import", h.requirePermissions(constants.PermissionClientsWrite), h.clientHandler.ImportClients)
+			clients.GET("/", h.requirePermissions(constants.PermissionClientsRead), h.clientHandler.GetAllClients)
+			clients.GET("/lookup", h.requirePermissions(constants.PermissionClientsRead), h.clientHandler.LookUpClient)
+			clients.GET("/:id", h.requirePermissions(constants.PermissionClientsRead), h.clientHandler.GetClientByID)
+			clients.PUT("/:id", h.requirePermissions(constants.PermissionClientsWrite), h.clientHandler.UpdateClient)
+			clients.DELETE("/:id", h.requirePermissions(constants.PermissionClientsWrite), h.clientHandler.DeleteClient)
+
+			clients.PUT("/:id/modify-balance", h.requirePermissions(constants.PermissionClientsBalance), h.clientHandler.ModifyBalanceByClientID)
+			clients.POST("/:id/transactions", h.requirePermissions(constants.PermissionClientsBalance), h.clientHandler.CreateBalanceTransaction)
+			clients.GET("/:id/transactions", h.requirePermissions(constants.PermissionClientsRead), h.clientHandler.GetBalanceTransactions)
+			clients.GET("/:id/statement", h.requirePermissions(constants.PermissionClientsRead), h.clientHandler.GetClientStatement)
+			clients.GET("/:id/top-ups", h.requirePermissions(constants.PermissionClientsRead), h.topUpHandler.GetClientTopUps)
+			clients.PUT("/:id/credit-limit", h.requirePermissions(constants.PermissionClientsWrite), h.clientHandler.SetClientCreditLimit)
+			clients.PUT("/:id/spending-limits", h.requirePermissions(constants.PermissionClientsWrite), h.clientHandler.SetClientSpendingLimits)
+
+			clients.GET("/:id/dietary-restrictions", h.requirePermissions(constants.PermissionClientsRead), h.clientHandler.GetClientDietaryRestrictions)
+			clients.PUT("/:id/dietary-restrictions", h.requirePermissions(constants.PermissionClientsWrite), h.clientHandler.SetClientDietaryRestrictions)
+			clients.POST("/:id/dietary-check", h.requirePermissions(constants.PermissionClientsBalance), h.clientHandler.CheckDietaryRestrictions)
+			clients.GET("/:id/dietary-overrides", h.requirePermissions(constants.PermissionClientsRead), h.clientHandler.GetDietaryOverrides)
+
+			clients.POST("/:id/identifiers", h.requirePermissions(constants.PermissionClientsWrite), h.clientHandler.IssueClientIdentifier)
+			clients.GET("/:id/identifiers", h.requirePermissions(constants.PermissionClientsRead), h.clientHandler.GetClientIdentifiers)
+			clients.PUT("/:id/identifiers/:identifier_id/status", h.requirePermissions(constants.PermissionClientsWrite), h.clientHandler.SetClientIdentifierStatus)
+			clients.POST("/:id/identifiers/:identifier_id/replace", h.requirePermissions(constants.PermissionClientsWrite), h.clientHandler.ReplaceClientIdentifier)
+			clients.GET("/:id/identifiers/:identifier_id/history", h.requirePermissions(constants.PermissionClientsRead), h.clientHandler.GetClientIdentifierHistory)
 		}
 
 		clientCategories := api.Group("/client-categories")
 		{
-			clientCategories.POST("/", h.clientHandler.CreateClientCategory)
-			clientCategories.GET("/", h.clientHandler.GetAllClientCategories)
-			clientCategories.GET("/:id", h.clientHandler.GetClientCategoryByID)
-			clientCategories.PUT("/:id", h.clientHandler.UpdateClientCategory)
-			clientCategories.DELETE("/:id", h.clientHandler.DeleteClientCategory)
+			clientCategories.POST("/", h.requirePermissions(constants.PermissionClientCategoriesWrite), h.clientHandler.CreateClientCategory)
+			clientCategories.GET("/", h.requirePermissions(constants.PermissionClientCategoriesRead), h.clientHandler.GetAllClientCategories)
+			clientCategories.GET("/:id", h.requirePermissions(constants.PermissionClientCategoriesRead), h.clientHandler.GetClientCategoryByID)
+			clientCategories.PUT("/:id", h.requirePermissions(constants.PermissionClientCategoriesWrite), h.clientHandler.UpdateClientCategory)
+			clientCategories.DELETE("/:id", h.requirePermissions(constants.PermissionClientCategoriesWrite), h.clientHandler.DeleteClientCategory)
+
+			clientCategories.POST("/:id/pricing", h.requirePermissions(constants.PermissionClientCategoriesWrite), h.clientHandler.CreateClientCategoryPricing)
+			clientCategories.GET("/:id/pricing", h.requirePermissions(constants.PermissionClientCategoriesRead), h.clientHandler.GetClientCategoryPricings)
+			clientCategories.DELETE("/:id/pricing/:pricing_id", h.requirePermissions(constants.PermissionClientCategoriesWrite), h.clientHandler.DeleteClientCategoryPricing)
+
+			clientCategories.GET("/:id/statements", h.requirePermissions(constants.PermissionClientsRead), h.clientHandler.GetClientCategoryStatements)
 		}
 	}
 }
@@ -67,7 +95,7 @@ func (h *ClientHandler) CreateClient(c *gin.Context) {
 		return
 	}
 
-	id, err := h.clientUseCase.CreateClient(request.MapCreateClientToClient(input))
+	id, err := h.clientUseCase.CreateClient(request.MapCreateClientToClient(input), operator(c))
 	if err != nil {
 		NewErrorResponse(c, err.StatusCode, err.Message, err.Error, nil)
 		return
@@ -79,27 +107,52 @@ func (h *ClientHandler) CreateClient(c *gin.Context) {
 }
 
 // GetAllClients godoc
-// @Summary Get all clients
-// @Description Get all clients available
+// @Summary Get clients
+// @Description Get a page of the clients that match the filters, with the number of all matching clients
 // @ID get-all-clients
 // @Tags clients
 // @Accept json
 // @Produce json
-// @Success 200 {array} response.GetClient "Successful response"
+// @Param name query string false "Part of the first, last or full name"
+// @Param email query string false "Part of the email address"
+// @Param category query string false "Client category name"
+// @Param category_id query int false "Client category ID"
+// @Param is_active query bool false "Active status"
+// @Param min_balance query number false "Lowest balance"
+// @Param max_balance query number false "Highest balance"
+// @Param sort query string false "Fields to sort by, a leading - sorts in descending order, e.g. last_name,-balance" Enums(id, first_name, last_name, email, age, balance, created_at)
+// @Param page query int false "Page, starting from 1"
+// @Param page_size query int false "Clients per page, 50 by default and at most 200"
+// @Success 200 {object} response.GetClientPage "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string "Client category not found"
 // @Failure 500 {string} string
 // @Router /api/clients [get]
 func (h *ClientHandler) GetAllClients(c *gin.Context) {
-	clientCategoryName := c.Query("category")
-	clients, err := h.clientUseCase.GetAllClients(clientCategoryName)
+	var input request.GetClients
+	if err := c.BindQuery(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid query parameters", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
+		return
+	}
+
+	filter, err := request.MapGetClientsToFilter(&input)
 	if err != nil {
-		NewErrorResponse(c, err.StatusCode, err.Message, err.Error, nil)
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
 		return
 	}
-	data := make([]*response.GetClient, len(*clients))
-	for i, client := range *clients {
-		data[i] = response.MapClientToGetClient(&client)
+
+	clients, total, customErr := h.clientUseCase.GetClients(filter)
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
 	}
-	NewSuccessResponse(c, http.StatusOK, "all clients received", data)
+
+	NewSuccessResponse(c, http.StatusOK, "clients received", response.MapClientsToGetClientPage(clients, total, filter))
 }
 
 // GetClientByID godoc
@@ -205,14 +258,19 @@ func (h *ClientHandler) DeleteClient(c *gin.Context) {
 
 // ModifyBalanceByClientID godoc
 // @Summary Modify the balance of a client by ID
-// @Description Modify the balance of a client based on ID and provided JSON input
+// @Description Modify the balance of a client based on ID and provided JSON input. A positive difference is
+// @Description recorded as a top-up and a negative one as a purchase, priced under the rules of the client's category. Deprecated, use POST /api/clients/{id}/transactions
 // @Tags clients
 // @Accept json
 // @Produce json
 // @Param id path int true "Client ID" Format(int64)
 // @Param input body request.ModifyBalance true "Balance modification object"
-// @Success 200 {string} string
+// @Param Idempotency-Key header string false "Unique key of the request, a retry with the same key is not applied again"
+// @Success 200 {object} response.GetBalanceTransaction "Successful response"
 // @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 402 {string} string "Credit or spending limit exceeded, with the amount the client can still spend"
+// @Failure 409 {string} string "Idempotency key reused for a different request, or used by one still in progress"
 // @Failure 500 {string} string
 // @Router /api/clients/{id}/modify-balance [put]
 func (h *ClientHandler) ModifyBalanceByClientID(c *gin.Context) {
@@ -233,13 +291,208 @@ func (h *ClientHandler) ModifyBalanceByClientID(c *gin.Context) {
 		return
 	}
 
-	customErr := h.clientUseCase.ModifyBalanceByClientID(uint(id), input.Difference)
+	idempotencyKey, err := idempotencyKeyFromHeader(c)
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
+		return
+	}
+
+	transaction, customErr := h.clientUseCase.CreateBalanceTransaction(request.MapModifyBalanceToBalanceTransaction(input, uint(id), idempotencyKey), operator(c))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "client balance modified", response.MapBalanceTransactionToGetBalanceTransaction(transaction))
+}
+
+// CreateBalanceTransaction godoc
+// @Summary Change the balance of a client
+// @Description Record a top-up, purchase, refund or correction in the client's ledger and apply it to the balance.
+// @Description The amount is added to the balance, so it is negative for purchases. Corrections need a reason.
+// @Description A purchase can list the dishes bought instead of an amount. The discount and subsidy of the client's category are taken off purchases.
+// @Description Dishes the client is allergic to are only sold with a dietary override reason, dishes that do not fit the client's diets come back as warnings
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Param input body request.CreateBalanceTransaction true "Balance transaction object"
+// @Param Idempotency-Key header string false "Unique key of the request, a retry with the same key is not applied again"
+// @Success 200 {object} response.GetBalanceTransaction "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string "Client or dish not found"
+// @Failure 402 {string} string "Credit or spending limit exceeded, with the amount the client can still spend"
+// @Failure 409 {string} string "Idempotency key reused for a different request or used by one still in progress, or dishes the client is allergic to"
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/transactions [post]
+func (h *ClientHandler) CreateBalanceTransaction(c *gin.Context) {
+	var input *request.CreateBalanceTransaction
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, gin.H{"id": id})
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
+		return
+	}
+
+	idempotencyKey, err := idempotencyKeyFromHeader(c)
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
+		return
+	}
+
+	transaction, customErr := h.clientUseCase.CreateBalanceTransaction(request.MapCreateBalanceTransactionToBalanceTransaction(input, uint(id), idempotencyKey), operator(c))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "balance transaction created", response.MapBalanceTransactionToGetBalanceTransaction(transaction))
+}
+
+// GetBalanceTransactions godoc
+// @Summary Get the balance transactions of a client
+// @Description Get the client's balance ledger, most recent transactions first, optionally within a date range
+// @Tags clients
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Param from query string false "First day, e.g. 2024-01-31"
+// @Param to query string false "Last day, e.g. 2024-02-29"
+// @Param type query string false "Transaction type" Enums(top-up, purchase, refund, correction)
+// @Success 200 {array} response.GetBalanceTransaction "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/transactions [get]
+func (h *ClientHandler) GetBalanceTransactions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	var input request.GetBalanceTransactions
+	if err := c.BindQuery(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid query parameters", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
+		return
+	}
+
+	transactions, customErr := h.clientUseCase.GetBalanceTransactions(uint(id), request.MapGetBalanceTransactionsToFilter(&input))
 	if customErr != nil {
 		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
 		return
 	}
 
-	NewSuccessResponse(c, http.StatusOK, "money withdrawn from client", nil)
+	data := make([]*response.GetBalanceTransaction, len(*transactions))
+	for i, transaction := range *transactions {
+		data[i] = response.MapBalanceTransactionToGetBalanceTransaction(&transaction)
+	}
+	NewSuccessResponse(c, http.StatusOK, "balance transactions received", data)
+}
+
+// SetClientCreditLimit godoc
+// @Summary Set the credit limit of a client
+// @Description Set how far below zero the client's balance may go, overriding the credit limit of the client's category.
+// @Description A null credit limit makes the category's limit apply again
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Param input body request.SetClientCreditLimit true "Credit limit"
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/credit-limit [put]
+func (h *ClientHandler) SetClientCreditLimit(c *gin.Context) {
+	var input *request.SetClientCreditLimit
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"id": id})
+		return
+	}
+
+	if customErr := h.clientUseCase.SetClientCreditLimit(uint(id), input.CreditLimit); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "client credit limit set", nil)
+}
+
+// SetClientSpendingLimits godoc
+// @Summary Set the spending limits of a client
+// @Description Replace the caps on the client's purchases, net of refunds, per day and per week in the canteen's time zone
+// @Description and per purchase. A null limit is removed
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Param input body request.SetClientSpendingLimits true "Spending limits"
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/spending-limits [put]
+func (h *ClientHandler) SetClientSpendingLimits(c *gin.Context) {
+	var input *request.SetClientSpendingLimits
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"id": id})
+		return
+	}
+
+	if customErr := h.clientUseCase.SetClientSpendingLimits(uint(id), request.MapSetClientSpendingLimitsToSpendingLimits(input)); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "client spending limits set", nil)
+}
+
+// idempotencyKeyFromHeader returns the Idempotency-Key header, or nil if the request has none.
+func idempotencyKeyFromHeader(c *gin.Context) (*string, error) {
+	idempotencyKey := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	if idempotencyKey == "" {
+		return nil, nil
+	} else if len(idempotencyKey) > 100 {
+		return nil, customErr.IdempotencyKeyInvalid
+	}
+
+	return &idempotencyKey, nil
 }
 
 // CreateClientCategory godoc
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/delivery/dto/request"
+	"Canteen-Backend/internal/delivery/dto/response"
+	"Canteen-Backend/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// CreateClientCategoryPricing godoc
+// @Summary Set the pricing rules of a client category
+// @Description Add a version of the category's pricing rules, in effect from the given day, today or later, until the next version.
+// @Description Purchases are priced from the category's price list or the menu, then the discount and the subsidy are taken off
+// @Tags client_categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Client category ID" Format(int64)
+// @Param input body request.CreateClientCategoryPricing true "Pricing rules"
+// @Success 200 {integer} integer 1
+// @Failure 400 {string} string
+// @Failure 404 {string} string "Client category or dish not found"
+// @Failure 409 {string} string "Pricing rules taking effect at that time already exist"
+// @Failure 500 {string} string
+// @Router /api/client-categories/{id}/pricing [post]
+func (h *ClientHandler) CreateClientCategoryPricing(c *gin.Context) {
+	var input *request.CreateClientCategoryPricing
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"id": id})
+		return
+	}
+
+	pricingID, customErr := h.clientUseCase.CreateClientCategoryPricing(request.MapCreateClientCategoryPricingToClientCategoryPricing(input, uint(id)), operator(c))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "client category pricing created", gin.H{"id": pricingID})
+}
+
+// GetClientCategoryPricings godoc
+// @Summary Get the pricing rules of a client category
+// @Description Get every version of the category's pricing rules, the latest first, including the ones not in effect yet
+// @Tags client_categories
+// @Produce json
+// @Param id path int true "Client category ID" Format(int64)
+// @Success 200 {array} response.GetClientCategoryPricing "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/client-categories/{id}/pricing [get]
+func (h *ClientHandler) GetClientCategoryPricings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	pricings, customErr := h.clientUseCase.GetClientCategoryPricings(uint(id))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	data := make([]*response.GetClientCategoryPricing, len(*pricings))
+	for i, pricing := range *pricings {
+		data[i] = response.MapClientCategoryPricingToGetClientCategoryPricing(&pricing)
+	}
+	NewSuccessResponse(c, http.StatusOK, "client category pricing received", data)
+}
+
+// DeleteClientCategoryPricing godoc
+// @Summary Delete upcoming pricing rules of a client category
+// @Description Delete a version of the category's pricing rules that has not come into effect yet
+// @Tags client_categories
+// @Produce json
+// @Param id path int true "Client category ID" Format(int64)
+// @Param pricing_id path int true "Pricing rules ID" Format(int64)
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string "Pricing rules are or were in effect"
+// @Failure 500 {string} string
+// @Router /api/client-categories/{id}/pricing/{pricing_id} [delete]
+func (h *ClientHandler) DeleteClientCategoryPricing(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	pricingID, err := strconv.Atoi(c.Param("pricing_id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid pricing id", err, gin.H{"id": id})
+		return
+	}
+
+	if customErr := h.clientUseCase.DeleteClientCategoryPricing(uint(id), uint(pricingID)); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id, "pricing_id": pricingID})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "client category pricing deleted", nil)
+}
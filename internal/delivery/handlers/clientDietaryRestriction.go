@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/delivery/dto/request"
+	"Canteen-Backend/internal/delivery/dto/response"
+	"Canteen-Backend/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// GetClientDietaryRestrictions godoc
+// @Summary Get the dietary restrictions of a client
+// @Description Get the allergens the client is allergic to and whether the client is vegetarian or eats halal
+// @Tags clients
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Success 200 {object} response.GetDietaryRestrictions "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/dietary-restrictions [get]
+func (h *ClientHandler) GetClientDietaryRestrictions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	restrictions, customErr := h.clientUseCase.GetClientDietaryRestrictions(uint(id))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "client dietary restrictions received", response.MapDietaryRestrictionsToGetDietaryRestrictions(restrictions))
+}
+
+// SetClientDietaryRestrictions godoc
+// @Summary Set the dietary restrictions of a client
+// @Description Replace the allergens the client is allergic to and the client's diets.
+// @Description Purchases of dishes the client is allergic to are refused unless overridden, dishes that do not fit the diets only warn
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Param input body request.SetClientDietaryRestrictions true "Dietary restrictions"
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Failure 404 {string} string "Client or allergen not found"
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/dietary-restrictions [put]
+func (h *ClientHandler) SetClientDietaryRestrictions(c *gin.Context) {
+	var input *request.SetClientDietaryRestrictions
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"id": id})
+		return
+	}
+
+	restrictions := request.MapSetClientDietaryRestrictionsToDietaryRestrictions(input)
+	if customErr := h.clientUseCase.SetClientDietaryRestrictions(uint(id), restrictions); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "client dietary restrictions set", nil)
+}
+
+// CheckDietaryRestrictions godoc
+// @Summary Check dishes against the dietary restrictions of a client
+// @Description Get the conflicts between the dishes and the client's allergies and diets, to warn the cashier before the purchase
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Param input body request.CheckDietaryRestrictions true "Dishes to be bought"
+// @Success 200 {array} response.GetDietaryConflict "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string "Client or dish not found"
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/dietary-check [post]
+func (h *ClientHandler) CheckDietaryRestrictions(c *gin.Context) {
+	var input *request.CheckDietaryRestrictions
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"id": id})
+		return
+	}
+
+	items := request.MapCheckDietaryRestrictionsToBalanceTransactionItems(input)
+	conflicts, customErr := h.clientUseCase.CheckDietaryRestrictions(uint(id), items)
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	data := make([]*response.GetDietaryConflict, len(*conflicts))
+	for i, conflict := range *conflicts {
+		data[i] = response.MapDietaryConflictToGetDietaryConflict(&conflict)
+	}
+	NewSuccessResponse(c, http.StatusOK, "dietary restrictions checked", data)
+}
+
+// GetDietaryOverrides godoc
+// @Summary Get the dietary overrides of a client
+// @Description Get the allergies cashiers overrode to sell dishes to the client, with the reasons they gave, most recent first
+// @Tags clients
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Success 200 {array} response.GetDietaryOverride "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/dietary-overrides [get]
+func (h *ClientHandler) GetDietaryOverrides(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	overrides, customErr := h.clientUseCase.GetDietaryOverrides(uint(id))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	data := make([]*response.GetDietaryOverride, len(*overrides))
+	for i, override := range *overrides {
+		data[i] = response.MapDietaryOverrideToGetDietaryOverride(&override)
+	}
+	NewSuccessResponse(c, http.StatusOK, "dietary overrides received", data)
+}
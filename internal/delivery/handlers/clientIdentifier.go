@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/delivery/dto/request"
+	"Canteen-Backend/internal/delivery/dto/response"
+	"Canteen-Backend/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// LookUpClient godoc
+// @Summary Look up a client by card, QR code or RFID tag
+// @Description Get the client an identifier presented at the till belongs to, with the client's balance and category.
+// @Description Lost and blocked identifiers are refused
+// @Tags clients
+// @Produce json
+// @Param type query string true "Identifier type" Enums(card, qr, rfid)
+// @Param value query string true "Card number, QR payload or RFID UID"
+// @Success 200 {object} response.GetClientLookup "Successful response"
+// @Failure 400 {string} string
+// @Failure 403 {string} string "Identifier lost or blocked"
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/clients/lookup [get]
+func (h *ClientHandler) LookUpClient(c *gin.Context) {
+	var input request.LookUpClient
+	if err := c.BindQuery(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid query parameters", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
+		return
+	}
+
+	lookup, customErr := h.clientUseCase.LookUpClient(input.Type, input.Value)
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"type": input.Type, "value": input.Value})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "client looked up", response.MapClientLookupToGetClientLookup(lookup))
+}
+
+// IssueClientIdentifier godoc
+// @Summary Issue a card, QR code or RFID tag to a client
+// @Description Issue an identifier to the client. An identifier can only ever be issued once
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Param input body request.IssueClientIdentifier true "Identifier object to be issued"
+// @Success 200 {integer} integer 1
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string "Identifier already issued"
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/identifiers [post]
+func (h *ClientHandler) IssueClientIdentifier(c *gin.Context) {
+	var input *request.IssueClientIdentifier
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
+		return
+	}
+
+	identifierID, customErr := h.clientUseCase.IssueClientIdentifier(request.MapIssueClientIdentifierToClientIdentifier(input, uint(id)), input.Reason, operator(c))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "client identifier issued", gin.H{"id": identifierID})
+}
+
+// GetClientIdentifiers godoc
+// @Summary Get the cards, QR codes and RFID tags of a client
+// @Description Get all identifiers ever issued to the client, most recently issued first
+// @Tags clients
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Success 200 {array} response.GetClientIdentifier "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/identifiers [get]
+func (h *ClientHandler) GetClientIdentifiers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	identifiers, customErr := h.clientUseCase.GetClientIdentifiers(uint(id))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	data := make([]*response.GetClientIdentifier, len(*identifiers))
+	for i, identifier := range *identifiers {
+		data[i] = response.MapClientIdentifierToGetClientIdentifier(&identifier)
+	}
+	NewSuccessResponse(c, http.StatusOK, "client identifiers received", data)
+}
+
+// SetClientIdentifierStatus godoc
+// @Summary Block, report lost or reactivate a client's identifier
+// @Description Change the status of the client's identifier. Replaced identifiers cannot be reactivated
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Param identifier_id path int true "Identifier ID" Format(int64)
+// @Param input body request.SetClientIdentifierStatus true "New status"
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string "Identifier was replaced"
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/identifiers/{identifier_id}/status [put]
+func (h *ClientHandler) SetClientIdentifierStatus(c *gin.Context) {
+	var input *request.SetClientIdentifierStatus
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, identifierID, err := clientIdentifierParams(c)
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
+		return
+	}
+
+	customErr := h.clientUseCase.SetClientIdentifierStatus(id, identifierID, input.Status, input.Reason, operator(c))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id, "identifier_id": identifierID})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "client identifier status changed", nil)
+}
+
+// ReplaceClientIdentifier godoc
+// @Summary Replace a client's identifier
+// @Description Issue a new identifier of the same type in place of a lost or blocked one
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Param identifier_id path int true "Identifier ID" Format(int64)
+// @Param input body request.ReplaceClientIdentifier true "Replacement object"
+// @Success 200 {object} response.GetClientIdentifier "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string "Identifier already issued or already replaced"
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/identifiers/{identifier_id}/replace [post]
+func (h *ClientHandler) ReplaceClientIdentifier(c *gin.Context) {
+	var input *request.ReplaceClientIdentifier
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, identifierID, err := clientIdentifierParams(c)
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
+		return
+	}
+
+	replacement, customErr := h.clientUseCase.ReplaceClientIdentifier(id, identifierID, input.Status, input.Value, input.Reason, operator(c))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id, "identifier_id": identifierID})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "client identifier replaced", response.MapClientIdentifierToGetClientIdentifier(replacement))
+}
+
+// GetClientIdentifierHistory godoc
+// @Summary Get the history of a client's identifier
+// @Description Get when the identifier was issued, blocked, reported lost, reactivated or replaced, and by whom
+// @Tags clients
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Param identifier_id path int true "Identifier ID" Format(int64)
+// @Success 200 {array} response.GetClientIdentifierEvent "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/identifiers/{identifier_id}/history [get]
+func (h *ClientHandler) GetClientIdentifierHistory(c *gin.Context) {
+	id, identifierID, err := clientIdentifierParams(c)
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	events, customErr := h.clientUseCase.GetClientIdentifierHistory(id, identifierID)
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id, "identifier_id": identifierID})
+		return
+	}
+
+	data := make([]*response.GetClientIdentifierEvent, len(*events))
+	for i, event := range *events {
+		data[i] = response.MapClientIdentifierEventToGetClientIdentifierEvent(&event)
+	}
+	NewSuccessResponse(c, http.StatusOK, "client identifier history received", data)
+}
+
+func clientIdentifierParams(c *gin.Context) (uint, uint, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	identifierID, err := strconv.Atoi(c.Param("identifier_id"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return uint(id), uint(identifierID), nil
+}
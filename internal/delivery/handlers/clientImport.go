@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/delivery/dto/request"
+	"Canteen-Backend/internal/delivery/dto/response"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/spreadsheet"
+	"Canteen-Backend/pkg/validator"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// maxImportSize bounds the body of import requests, the file and the rest of the form.
+const maxImportSize = 10 << 20
+
+// maxImportRows is how many clients a file can list, below its header row.
+const maxImportRows = 5000
+
+// ImportClients godoc
+// @Summary Import clients from a CSV or XLSX file
+// @Description Create the clients listed in a CSV or XLSX file. The first row names the columns: email, first_name,
+// @Description last_name, age, gender, category (the client category's name) and optionally balance. Every row is
+// @Description validated like a new client, and all valid rows are created in one transaction. Rows with errors are
+// @Description skipped and reported by line. A dry run only reports the errors. Files are limited to 10 MB and 5000 clients
+// @Tags clients
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "CSV or XLSX file"
+// @Param dry_run query bool false "Only check the file, without creating clients"
+// @Success 200 {object} response.ImportClients "Successful response"
+// @Failure 400 {string} string
+// @Failure 409 {string} string "A client with one of the email addresses was created meanwhile"
+// @Failure 413 {string} string "File too large"
+// @Failure 500 {string} string
+// @Router /api/clients/import [post]
+func (h *ClientHandler) ImportClients(c *gin.Context) {
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			NewErrorResponse(c, http.StatusBadRequest, "invalid dry_run", err, nil)
+			return
+		}
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			NewErrorResponse(c, http.StatusRequestEntityTooLarge, "file too large, at most 10 MB", err, nil)
+		} else {
+			NewErrorResponse(c, http.StatusBadRequest, "file is required", err, nil)
+		}
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid file", err, nil)
+		return
+	}
+	defer file.Close()
+
+	rows, err := spreadsheet.ReadRows(fileHeader.Filename, file, maxImportRows+1)
+	if errors.Is(err, spreadsheet.ErrXLSXTooLarge) {
+		NewErrorResponse(c, http.StatusRequestEntityTooLarge, err.Error(), err, gin.H{"filename": fileHeader.Filename})
+		return
+	} else if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"filename": fileHeader.Filename})
+		return
+	}
+
+	importRows, err := request.MapRowsToImportClientRows(rows)
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"filename": fileHeader.Filename})
+		return
+	}
+
+	categoryNames := make([]string, len(importRows))
+	for i, importRow := range importRows {
+		categoryNames[i] = importRow.Category
+	}
+	categoryIDs, customError := h.clientUseCase.GetClientCategoryIDsByName(categoryNames)
+	if customError != nil {
+		NewErrorResponse(c, customError.StatusCode, customError.Message, customError.Error, nil)
+		return
+	}
+
+	clientRows := make([]*models.ClientImportRow, len(importRows))
+	for i, importRow := range importRows {
+		clientRows[i] = &models.ClientImportRow{Line: importRow.Line, Error: importRow.Error}
+		if importRow.Error != "" {
+			continue
+		}
+
+		categoryID, ok := categoryIDs[importRow.Category]
+		if !ok && importRow.Category != "" {
+			clientRows[i].Error = customErr.ClientCategoryNotFound.Error()
+			continue
+		}
+		importRow.Input.ClientCategoryID = categoryID
+
+		if err := validator.ValidatePayload(importRow.Input); err != nil {
+			clientRows[i].Error = err.Error()
+			continue
+		}
+		clientRows[i].Client = request.MapCreateClientToClient(importRow.Input)
+	}
+
+	imported, customError := h.clientUseCase.ImportClients(clientRows, dryRun, operator(c))
+	if customError != nil {
+		NewErrorResponse(c, customError.StatusCode, customError.Message, customError.Error, gin.H{"filename": fileHeader.Filename})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "clients imported", response.MapClientImportRowsToImportClients(clientRows, imported, dryRun))
+}
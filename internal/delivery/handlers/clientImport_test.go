@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"Canteen-Backend/pkg/logger"
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestImportClientsRefusesLargeFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if logger.GetLogger() == nil {
+		logger.Logger = zap.NewNop()
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "clients.csv")
+	if err != nil {
+		t.Fatalf("CreateFormFile() error = %v", err)
+	}
+	file.Write(bytes.Repeat([]byte("a@example.com,Ann,Smith,30,female,Students\n"), maxImportSize/40))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/clients/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = req
+
+	// the file is refused before the use case is needed
+	new(ClientHandler).ImportClients(c)
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/delivery/dto/request"
+	"Canteen-Backend/internal/delivery/export"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/validator"
+	"bytes"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// GetClientStatement godoc
+// @Summary Get the statement of a client
+// @Description Get the client's account over the period as a file: the opening balance, every balance transaction and the closing balance.
+// @Description Both days of the period are included, in the canteen's time zone. The statement is an XLSX file, unless a PDF is asked for
+// @Tags clients
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/pdf
+// @Param id path int true "Client ID" Format(int64)
+// @Param from query string true "First day of the period, e.g. 2024-01-01"
+// @Param to query string true "Last day of the period, e.g. 2024-01-31"
+// @Param format query string false "File format, xlsx or pdf" Enums(xlsx, pdf)
+// @Success 200 {file} file "Statement"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/statement [get]
+func (h *ClientHandler) GetClientStatement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	var input request.GetStatement
+	if err := c.BindQuery(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid query parameters", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"id": id})
+		return
+	}
+
+	from, to := request.MapGetStatementToPeriod(&input)
+	statement, customErr := h.clientUseCase.GetClientStatement(uint(id), from, to)
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	format := statementFormat(&input)
+	sendStatementFile(c, "client statement created", export.StatementFileName(statement, format), export.ContentType(format),
+		func(w io.Writer) error { return export.WriteStatement(w, statement, format) }, gin.H{"id": id})
+}
+
+// GetClientCategoryStatements godoc
+// @Summary Get the statements of the clients of a category
+// @Description Get the statements of the period of every client of the category, active or not, as a ZIP archive of a file per client
+// @Tags client_categories
+// @Produce application/zip
+// @Param id path int true "Client category ID" Format(int64)
+// @Param from query string true "First day of the period, e.g. 2024-01-01"
+// @Param to query string true "Last day of the period, e.g. 2024-01-31"
+// @Param format query string false "Format of the statements, xlsx or pdf" Enums(xlsx, pdf)
+// @Success 200 {file} file "ZIP archive of statements"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/client-categories/{id}/statements [get]
+func (h *ClientHandler) GetClientCategoryStatements(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	var input request.GetStatement
+	if err := c.BindQuery(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid query parameters", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"id": id})
+		return
+	}
+
+	from, to := request.MapGetStatementToPeriod(&input)
+	statements, customErr := h.clientUseCase.GetClientCategoryStatements(uint(id), from, to)
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	fileName := fmt.Sprintf("statements-%d-%s-%s.zip", id, input.From, input.To)
+	sendStatementFile(c, "client category statements created", fileName, "application/zip",
+		func(w io.Writer) error { return export.WriteStatementArchive(w, *statements, statementFormat(&input)) }, gin.H{"id": id})
+}
+
+// sendStatementFile renders the file with write, and sends it once it is complete, so that a failure is still answered with an error.
+func sendStatementFile(c *gin.Context, message, fileName, contentType string, write func(w io.Writer) error, data interface{}) {
+	var file bytes.Buffer
+	if err := write(&file); err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, customErr.ServerError.Error(), err, data)
+		return
+	}
+
+	NewFileResponse(c, message, fileName, contentType, file.Bytes())
+}
+
+// statementFormat returns the format asked for, XLSX by default.
+func statementFormat(input *request.GetStatement) string {
+	if input.Format == "" {
+		return constants.StatementFormatXLSX
+	}
+
+	return input.Format
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/delivery/dto/request"
+	"Canteen-Backend/internal/delivery/dto/response"
+	"Canteen-Backend/internal/usecase"
+	"Canteen-Backend/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) initDishRoutes(api *gin.RouterGroup) {
+
+	api.Use(h.authenticateUser)
+	{
+		dishes := api.Group("/dishes")
+		{
+			dishes.POST("/", h.requirePermissions(constants.PermissionDishesWrite), h.dishHandler.CreateDish)
+			dishes.GET("/", h.requirePermissions(constants.PermissionDishesRead), h.dishHandler.GetAllDishes)
+			dishes.GET("/:id", h.requirePermissions(constants.PermissionDishesRead), h.dishHandler.GetDishByID)
+			dishes.PUT("/:id", h.requirePermissions(constants.PermissionDishesWrite), h.dishHandler.UpdateDish)
+			dishes.GET("/:id/dietary-info", h.requirePermissions(constants.PermissionDishesRead), h.dishHandler.GetDishDietaryInfo)
+			dishes.PUT("/:id/ingredients", h.requirePermissions(constants.PermissionDishesWrite), h.dishHandler.SetDishIngredients)
+		}
+	}
+}
+
+type DishHandler struct {
+	dishUseCase usecase.Dish
+}
+
+func NewDishHandler(dishUseCase usecase.Dish) *DishHandler {
+	return &DishHandler{dishUseCase: dishUseCase}
+}
+
+// CreateDish godoc
+// @Summary Create a new dish
+// @Description Add a dish to the menu with the provided JSON input
+// @ID create-dish
+// @Tags dishes
+// @Accept json
+// @Produce json
+// @Param input body request.CreateDish true "Dish object to be created"
+// @Success 200 {integer} integer 1
+// @Failure 400 {string} string
+// @Failure 409 {string} string "Dish already exists"
+// @Failure 500 {string} string
+// @Router /api/dishes [post]
+func (h *DishHandler) CreateDish(c *gin.Context) {
+	var input *request.CreateDish
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
+		return
+	}
+
+	id, customErr := h.dishUseCase.CreateDish(request.MapCreateDishToDish(input))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "dish created", gin.H{"id": id})
+}
+
+// GetAllDishes godoc
+// @Summary Get all dishes
+// @Description Get all dishes of the menu, including the ones that are not on sale
+// @ID get-all-dishes
+// @Tags dishes
+// @Produce json
+// @Success 200 {array} response.GetDish "Successful response"
+// @Failure 500 {string} string
+// @Router /api/dishes [get]
+func (h *DishHandler) GetAllDishes(c *gin.Context) {
+	dishes, customErr := h.dishUseCase.GetAllDishes()
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	data := make([]*response.GetDish, len(*dishes))
+	for i, dish := range *dishes {
+		data[i] = response.MapDishToGetDish(&dish)
+	}
+	NewSuccessResponse(c, http.StatusOK, "dishes received", data)
+}
+
+// GetDishByID godoc
+// @Summary Get a dish by ID
+// @Description Get a dish based on ID
+// @ID get-dish-by-id
+// @Tags dishes
+// @Produce json
+// @Param id path int true "Dish ID" Format(int64)
+// @Success 200 {object} response.GetDish "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/dishes/{id} [get]
+func (h *DishHandler) GetDishByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	dish, customErr := h.dishUseCase.GetDishByID(uint(id))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "dish received", response.MapDishToGetDish(dish))
+}
+
+// UpdateDish godoc
+// @Summary Update the existing dish
+// @Description Update the dish's name, price and whether it is on sale. Dishes are taken off sale rather than deleted
+// @ID update-dish
+// @Tags dishes
+// @Accept json
+// @Produce json
+// @Param id path int true "Dish ID" Format(int64)
+// @Param input body request.UpdateDish true "Dish object to be updated"
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string "Dish already exists"
+// @Failure 500 {string} string
+// @Router /api/dishes/{id} [put]
+func (h *DishHandler) UpdateDish(c *gin.Context) {
+	var input *request.UpdateDish
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"id": id})
+		return
+	}
+
+	dish := request.MapUpdateDishToDish(input)
+	dish.ID = uint(id)
+	if customErr := h.dishUseCase.UpdateDish(dish); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "dish updated", nil)
+}
+
+// GetDishDietaryInfo godoc
+// @Summary Get the dietary info of a dish
+// @Description Get the allergens the dish contains and whether it is vegetarian and halal, going by its ingredients
+// @ID get-dish-dietary-info
+// @Tags dishes
+// @Produce json
+// @Param id path int true "Dish ID" Format(int64)
+// @Success 200 {object} response.GetDishDietaryInfo "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/dishes/{id}/dietary-info [get]
+func (h *DishHandler) GetDishDietaryInfo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	info, customErr := h.dishUseCase.GetDishDietaryInfo(uint(id))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "dish dietary info received", response.MapDishDietaryInfoToGetDishDietaryInfo(info))
+}
+
+// SetDishIngredients godoc
+// @Summary Set the ingredients of a dish
+// @Description Replace the ingredients the dish is made of, which its allergens and diets are worked out from
+// @ID set-dish-ingredients
+// @Tags dishes
+// @Accept json
+// @Produce json
+// @Param id path int true "Dish ID" Format(int64)
+// @Param input body request.SetDishIngredients true "Ingredients of the dish"
+// @Success 200 {string} string
+// @Failure 400 {string} string
+// @Failure 404 {string} string "Dish or ingredient not found"
+// @Failure 500 {string} string
+// @Router /api/dishes/{id}/ingredients [put]
+func (h *DishHandler) SetDishIngredients(c *gin.Context) {
+	var input *request.SetDishIngredients
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, gin.H{"id": id})
+		return
+	}
+
+	if customErr := h.dishUseCase.SetDishIngredients(uint(id), input.IngredientIDs); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "dish ingredients set", nil)
+}
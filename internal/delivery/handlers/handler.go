@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"Canteen-Backend/internal/usecase"
+	"Canteen-Backend/internal/worker"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -9,32 +10,53 @@ import (
 
 type Handler struct {
 	userHandler       *UserHandler
+	roleHandler       *RoleHandler
+	apiKeyHandler     *APIKeyHandler
 	clientHandler     *ClientHandler
+	topUpHandler      *TopUpHandler
 	ingredientHandler *IngredientHandler
+	dishHandler       *DishHandler
 	purchaseHandler   *PurchaseHandler
+	jobHandler        *JobHandler
 }
 
-func NewHandler(useCase *usecase.UseCase) *Handler {
+func NewHandler(useCase *usecase.UseCase, jobRunner *worker.Runner) *Handler {
 	userHandler := NewUserHandler(useCase.User)
+	roleHandler := NewRoleHandler(useCase.Role)
+	apiKeyHandler := NewAPIKeyHandler(useCase.APIKey)
 	clientHandler := NewClientHandler(useCase.Client)
+	topUpHandler := NewTopUpHandler(useCase.TopUp)
 	ingredientHandler := NewIngredientHandler(useCase.Ingredient)
+	dishHandler := NewDishHandler(useCase.Dish)
 	purchaseHandler := NewPurchaseHandler(useCase.Purchase)
+	jobHandler := NewJobHandler(jobRunner)
 
-	return &Handler{userHandler: userHandler, clientHandler: clientHandler, ingredientHandler: ingredientHandler, purchaseHandler: purchaseHandler}
+	return &Handler{userHandler: userHandler, roleHandler: roleHandler, apiKeyHandler: apiKeyHandler, clientHandler: clientHandler, topUpHandler: topUpHandler, ingredientHandler: ingredientHandler, dishHandler: dishHandler, purchaseHandler: purchaseHandler, jobHandler: jobHandler}
 }
 
-func (h *Handler) InitRoutes() *gin.Engine {
+// InitRoutes builds the router. Client IP addresses are read from X-Forwarded-For only when the request
+// comes from one of trustedProxies (IP addresses or CIDRs); with none, the address of the connecting peer is used.
+func (h *Handler) InitRoutes(trustedProxies []string) (*gin.Engine, error) {
 	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/.well-known/jwks.json", h.GetJWKS)
 
 	api := router.Group("/api")
 	{
 		h.initUserRoutes(api)
+		h.initRoleRoutes(api)
+		h.initAPIKeyRoutes(api)
+		h.initTopUpRoutes(api)
 		h.initClientRoutes(api)
 		h.initIngredientRoutes(api)
+		h.initDishRoutes(api)
 		h.initPurchaseRoutes(api)
+		h.initJobRoutes(api)
 	}
 
-	return router
+	return router, nil
 }
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// signInIPAddress returns the IP address a sign-in from remoteAddr with the given X-Forwarded-For
+// header is recorded and throttled under.
+func signInIPAddress(t *testing.T, trustedProxies []string, remoteAddr, forwardedFor string) string {
+	gin.SetMode(gin.TestMode)
+	router, err := new(Handler).InitRoutes(trustedProxies)
+	if err != nil {
+		t.Fatalf("InitRoutes: %v", err)
+	}
+
+	var ipAddress string
+	router.GET("/test/ip", func(c *gin.Context) {
+		ipAddress = sessionMetadata(c).IPAddress
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test/ip", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	return ipAddress
+}
+
+func TestSignInIPAddress(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		want           string
+	}{
+		{"forged header without trusted proxies", nil, "203.0.113.7:41000", "198.51.100.1", "203.0.113.7"},
+		{"header from an untrusted peer", []string{"10.0.0.0/8"}, "203.0.113.7:41000", "198.51.100.1", "203.0.113.7"},
+		{"header from a trusted proxy", []string{"10.0.0.0/8"}, "10.1.2.3:41000", "198.51.100.1", "198.51.100.1"},
+		{"entry forged by the client behind a trusted proxy", []string{"10.0.0.0/8"}, "10.1.2.3:41000", "198.51.100.1, 203.0.113.7", "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signInIPAddress(t, tt.trustedProxies, tt.remoteAddr, tt.forwardedFor); got != tt.want {
+				t.Errorf("got IP address %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitRoutesRejectsInvalidTrustedProxy(t *testing.T) {
+	if _, err := new(Handler).InitRoutes([]string{"not-an-ip"}); err == nil {
+		t.Fatal("InitRoutes accepted an invalid trusted proxy")
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"Canteen-Backend/internal/constants"
 	"Canteen-Backend/internal/delivery/dto/request"
 	"Canteen-Backend/internal/delivery/dto/response"
 	"Canteen-Backend/internal/usecase"
@@ -17,20 +18,30 @@ func (h *Handler) initIngredientRoutes(api *gin.RouterGroup) {
 	{
 		ingredientCategories := api.Group("/ingredient-categories")
 		{
-			ingredientCategories.POST("/", h.ingredientHandler.CreateIngredientCategory)
-			ingredientCategories.GET("/", h.ingredientHandler.GetAllIngredientCategories)
-			ingredientCategories.GET("/:id", h.ingredientHandler.GetIngredientCategoryByID)
-			ingredientCategories.PUT("/:id", h.ingredientHandler.UpdateIngredientCategory)
-			ingredientCategories.DELETE("/:id", h.ingredientHandler.DeleteIngredientCategory)
+			ingredientCategories.POST("/", h.requirePermissions(constants.PermissionIngredientsWrite), h.ingredientHandler.CreateIngredientCategory)
+			ingredientCategories.GET("/", h.requirePermissions(constants.PermissionIngredientsRead), h.ingredientHandler.GetAllIngredientCategories)
+			ingredientCategories.GET("/:id", h.requirePermissions(constants.PermissionIngredientsRead), h.ingredientHandler.GetIngredientCategoryByID)
+			ingredientCategories.PUT("/:id", h.requirePermissions(constants.PermissionIngredientsWrite), h.ingredientHandler.UpdateIngredientCategory)
+			ingredientCategories.DELETE("/:id", h.requirePermissions(constants.PermissionIngredientsWrite), h.ingredientHandler.DeleteIngredientCategory)
 		}
 
 		ingredients := api.Group("/ingredients")
 		{
-			ingredients.POST("/", h.ingredientHandler.CreateIngredient)
-			ingredients.GET("/", h.ingredientHandler.GetAllIngredients)
-			ingredients.GET("/:id", h.ingredientHandler.GetIngredientByID)
-			ingredients.PUT("/:id", h.ingredientHandler.UpdateIngredient)
-			ingredients.DELETE("/:id", h.ingredientHandler.DeleteIngredient)
+			ingredients.POST("/", h.requirePermissions(constants.PermissionIngredientsWrite), h.ingredientHandler.CreateIngredient)
+			ingredients.GET("/", h.requirePermissions(constants.PermissionIngredientsRead), h.ingredientHandler.GetAllIngredients)
+			ingredients.GET("/:id", h.requirePermissions(constants.PermissionIngredientsRead), h.ingredientHandler.GetIngredientByID)
+			ingredients.PUT("/:id", h.requirePermissions(constants.PermissionIngredientsWrite), h.ingredientHandler.UpdateIngredient)
+			ingredients.DELETE("/:id", h.requirePermissions(constants.PermissionIngredientsWrite), h.ingredientHandler.DeleteIngredient)
+			ingredients.GET("/:id/dietary-info", h.requirePermissions(constants.PermissionIngredientsRead), h.ingredientHandler.GetIngredientDietaryInfo)
+			ingredients.PUT("/:id/dietary-info", h.requirePermissions(constants.PermissionIngredientsWrite), h.ingredientHandler.SetIngredientDietaryInfo)
+		}
+
+		allergens := api.Group("/allergens")
+		{
+			allergens.POST("/", h.requirePermissions(constants.PermissionIngredientsWrite), h.ingredientHandler.CreateAllergen)
+			allergens.GET("/", h.requirePermissions(constants.PermissionIngredientsRead), h.ingredientHandler.GetAllAllergens)
+			allergens.PUT("/:id", h.requirePermissions(constants.PermissionIngredientsWrite), h.ingredientHandler.UpdateAllergen)
+			allergens.DELETE("/:id", h.requirePermissions(constants.PermissionIngredientsWrite), h.ingredientHandler.DeleteAllergen)
 		}
 	}
 
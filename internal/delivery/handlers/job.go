@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/delivery/dto/response"
+	"Canteen-Backend/internal/worker"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func (h *Handler) initJobRoutes(api *gin.RouterGroup) {
+
+	jobs := api.Group("/jobs")
+	{
+		jobs.Use(h.authenticateUser)
+		{
+			jobs.GET("/", h.requirePermissions(constants.PermissionJobsRead), h.jobHandler.GetAllJobs)
+		}
+	}
+}
+
+type JobHandler struct {
+	jobRunner *worker.Runner
+}
+
+func NewJobHandler(jobRunner *worker.Runner) *JobHandler {
+	return &JobHandler{jobRunner: jobRunner}
+}
+
+// GetAllJobs godoc
+// @Summary Get background jobs
+// @Description Get the background jobs and the status of their last run in the replica serving the request.
+// @Description A run is skipped if another replica was running the job at the same time
+// @Tags jobs
+// @Produce json
+// @Success 200 {array} response.GetJob "Successful response"
+// @Failure 401 {string} string "Unauthorized"
+// @Router /api/jobs [get]
+func (h *JobHandler) GetAllJobs(c *gin.Context) {
+	statuses := h.jobRunner.Statuses()
+
+	data := make([]*response.GetJob, len(statuses))
+	for i, status := range statuses {
+		data[i] = response.MapJobStatusToGetJob(&status)
+	}
+	NewSuccessResponse(c, http.StatusOK, "all jobs retrieved", data)
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"Canteen-Backend/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GetJWKS godoc
+// @Summary Get the public signing keys
+// @Description Get the public keys access tokens are signed with as a JSON Web Key Set, so that other services
+// @Description can verify access tokens. Keys are matched to tokens by the kid header
+// @Tags auth
+// @Produce json
+// @Success 200 {object} auth.JWKS "Successful response"
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) GetJWKS(c *gin.Context) {
+	// JWKS clients expect the key set as the response body, so it is not wrapped in a success response
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, auth.PublicJWKS())
+}
@@ -1,39 +1,118 @@
 package handlers
 
 import (
+	"Canteen-Backend/internal/models"
 	"Canteen-Backend/pkg/auth"
+	"Canteen-Backend/pkg/customErr"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strings"
 )
 
+// authenticateUser accepts either a Bearer access token or an API key, given in the X-API-Key header
+// or as "Authorization: ApiKey <key>". Requests authenticated with an API key have no user_id.
 func (h *Handler) authenticateUser(c *gin.Context) {
-	userId, userRoleId, err := parseAuthHeader(c)
+	if key := apiKeyFromHeader(c); key != "" {
+		h.authenticateAPIKey(c, key)
+		return
+	}
+
+	claims, err := parseAuthHeader(c)
 	if err != nil {
 		NewErrorResponse(c, http.StatusUnauthorized, err.Error(), err, nil)
 		c.Abort()
 		return
 	}
 
-	c.Set("user_id", userId)
-	c.Set("user_role_id", userRoleId)
+	// access tokens are stateless, so make sure their session has not been revoked in the meantime
+	if customErr := h.userHandler.userUseCase.CheckSession(claims.UserID, claims.SessionID); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"user_id": claims.UserID})
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", claims.UserID)
+	c.Set("user_role_id", claims.UserRoleID)
+	c.Set("user_role_name", claims.UserRoleName)
+	c.Set("session_id", claims.SessionID)
+}
+
+func (h *Handler) authenticateAPIKey(c *gin.Context, key string) {
+	apiKey, customErr := h.apiKeyHandler.apiKeyUseCase.AuthenticateAPIKey(key, c.ClientIP())
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		c.Abort()
+		return
+	}
+
+	c.Set("api_key_id", apiKey.ID)
+	c.Set("user_role_id", apiKey.UserRoleID)
+}
+
+func apiKeyFromHeader(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+
+	headerParts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(headerParts) == 2 && headerParts[0] == "ApiKey" {
+		return headerParts[1]
+	}
+
+	return ""
+}
+
+// requireUserSession aborts the request with 403 if it was authenticated with an API key,
+// for endpoints that act on the signed in user. It must run after authenticateUser.
+func (h *Handler) requireUserSession(c *gin.Context) {
+	if c.GetUint("user_id") == 0 {
+		NewErrorResponse(c, http.StatusForbidden, customErr.APIKeyNotAllowed.Error(), customErr.APIKeyNotAllowed, gin.H{"api_key_id": c.GetUint("api_key_id")})
+		c.Abort()
+		return
+	}
 }
 
-func parseAuthHeader(c *gin.Context) (uint, uint, error) {
+func parseAuthHeader(c *gin.Context) (*auth.Claims, error) {
 	header := c.GetHeader("Authorization")
 	if header == "" {
-		return 0, 0, errors.New("empty auth header")
+		return nil, errors.New("empty auth header")
 	}
 
 	headerParts := strings.Split(header, " ")
 	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-		return 0, 0, errors.New("invalid auth header")
+		return nil, errors.New("invalid auth header")
 	}
 
 	if len(headerParts[1]) == 0 {
-		return 0, 0, errors.New("token is empty")
+		return nil, errors.New("token is empty")
 	}
 
 	return auth.ParseToken(headerParts[1])
 }
+
+// operator returns who authenticated the request, for recording who made a change.
+func operator(c *gin.Context) *models.Operator {
+	operator := &models.Operator{}
+	if userID := c.GetUint("user_id"); userID != 0 {
+		operator.UserID = &userID
+	}
+	if apiKeyID := c.GetUint("api_key_id"); apiKeyID != 0 {
+		operator.APIKeyID = &apiKeyID
+	}
+
+	return operator
+}
+
+// requirePermissions aborts the request with 403 unless the authenticated user's role
+// is granted all the given permissions. It must run after authenticateUser.
+func (h *Handler) requirePermissions(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRoleId := c.GetUint("user_role_id")
+		if customErr := h.roleHandler.roleUseCase.CheckPermissions(userRoleId, permissions...); customErr != nil {
+			NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"user_role_id": userRoleId, "permissions": permissions})
+			c.Abort()
+			return
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/delivery/dto/request"
+	"Canteen-Backend/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a single-use password reset link to the user with the given email address.
+// @Description The response is the same whether or not a user has the address
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body request.ForgotPassword true "Email address"
+// @Success 200 {string} string "Password reset requested"
+// @Failure 400 {string} string "Invalid input JSON"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/forgot-password [post]
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var input *request.ForgotPassword
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	if customErr := h.userUseCase.ForgotPassword(input.Email); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "if the email belongs to a user, a password reset link has been sent", nil)
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Set a new password with the token from a password reset email. The user is signed out everywhere
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body request.ResetPassword true "Reset token and new password"
+// @Success 200 {string} string "Password reset"
+// @Failure 400 {string} string "Invalid input JSON or reset token"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/reset-password [post]
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var input *request.ResetPassword
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	if customErr := h.userUseCase.ResetPassword(input.Token, input.NewPassword); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "password reset", nil)
+}
+
+// ChangePasswordOnSignIn godoc
+// @Summary Change the password during sign-in
+// @Description Set a new password for a user who has to change the password before signing in, and finish the sign-in
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body request.PasswordChangeSignIn true "Challenge token and new password"
+// @Success 200 {object} models.SignInResult "Successful response"
+// @Failure 400 {string} string "Invalid input JSON or unchanged password"
+// @Failure 401 {string} string "Invalid challenge"
+// @Failure 429 {string} string "Too many sign-in attempts"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/sign-in/password [post]
+func (h *UserHandler) ChangePasswordOnSignIn(c *gin.Context) {
+	var input *request.PasswordChangeSignIn
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	result, customErr := h.userUseCase.ChangePasswordOnSignIn(input.ChallengeToken, input.NewPassword, sessionMetadata(c))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSensitiveSuccessResponse(c, http.StatusOK, "user signed in", result)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"Canteen-Backend/internal/constants"
 	"Canteen-Backend/internal/delivery/dto/request"
 	"Canteen-Backend/internal/delivery/dto/response"
 	"Canteen-Backend/internal/usecase"
@@ -16,16 +17,16 @@ func (h *Handler) initPurchaseRoutes(api *gin.RouterGroup) {
 	{
 		suppliers := api.Group("/suppliers")
 		{
-			suppliers.POST("/", h.purchaseHandler.CreateSupplier)
-			suppliers.GET("/", h.purchaseHandler.GetAllSuppliers)
-			suppliers.GET("/:id", h.purchaseHandler.GetSupplierByID)
-			suppliers.PUT("/:id", h.purchaseHandler.UpdateSupplier)
-			suppliers.DELETE("/:id", h.purchaseHandler.DeleteSupplier)
+			suppliers.POST("/", h.requirePermissions(constants.PermissionSuppliersWrite), h.purchaseHandler.CreateSupplier)
+			suppliers.GET("/", h.requirePermissions(constants.PermissionSuppliersRead), h.purchaseHandler.GetAllSuppliers)
+			suppliers.GET("/:id", h.requirePermissions(constants.PermissionSuppliersRead), h.purchaseHandler.GetSupplierByID)
+			suppliers.PUT("/:id", h.requirePermissions(constants.PermissionSuppliersWrite), h.purchaseHandler.UpdateSupplier)
+			suppliers.DELETE("/:id", h.requirePermissions(constants.PermissionSuppliersWrite), h.purchaseHandler.DeleteSupplier)
 		}
 
 		purchases := api.Group("/purchases")
 		{
-			purchases.POST("/", h.purchaseHandler.CreatePurchase)
+			purchases.POST("/", h.requirePermissions(constants.PermissionPurchasesWrite), h.purchaseHandler.CreatePurchase)
 			//purchases.GET("/", h.purchaseHandler.GetAllPurchases)
 			//purchases.GET("/:id", h.purchaseHandler.GetPurchaseByID)
 			//purchases.PUT("/:id", h.purchaseHandler.UpdatePurchase)
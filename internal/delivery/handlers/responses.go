@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"Canteen-Backend/pkg/logger"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"net/http"
 )
 
 func NewSuccessResponse(c *gin.Context, statusCode int, message string, data interface{}) {
@@ -17,6 +19,22 @@ func NewSuccessResponse(c *gin.Context, statusCode int, message string, data int
 
 }
 
+// NewSensitiveSuccessResponse is NewSuccessResponse for data that must not end up in the logs,
+// such as tokens, TOTP secrets and recovery codes.
+func NewSensitiveSuccessResponse(c *gin.Context, statusCode int, message string, data interface{}) {
+	logger.GetLogger().Info(message)
+
+	c.JSON(statusCode, data)
+}
+
+// NewFileResponse sends the file as a download, logging its name rather than its content.
+func NewFileResponse(c *gin.Context, message, fileName, contentType string, content []byte) {
+	logger.GetLogger().Info(message, zap.String("file", fileName))
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, contentType, content)
+}
+
 func NewErrorResponse(c *gin.Context, statusCode int, message string, err error, data interface{}) {
 	if data != nil {
 		logger.GetLogger().Error(message, zap.Error(err), zap.Any("data", data))
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/delivery/dto/request"
+	"Canteen-Backend/internal/delivery/dto/response"
+	"Canteen-Backend/internal/usecase"
+	"Canteen-Backend/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) initRoleRoutes(api *gin.RouterGroup) {
+
+	roles := api.Group("/roles")
+	{
+		roles.Use(h.authenticateUser)
+		{
+			roles.POST("/", h.requirePermissions(constants.PermissionRolesWrite), h.roleHandler.CreateRole)
+			roles.GET("/", h.requirePermissions(constants.PermissionRolesRead), h.roleHandler.GetAllRoles)
+			roles.GET("/:id", h.requirePermissions(constants.PermissionRolesRead), h.roleHandler.GetRoleByID)
+			roles.PUT("/:id", h.requirePermissions(constants.PermissionRolesWrite), h.roleHandler.UpdateRole)
+			roles.DELETE("/:id", h.requirePermissions(constants.PermissionRolesWrite), h.roleHandler.DeleteRole)
+		}
+	}
+
+	permissions := api.Group("/permissions")
+	{
+		permissions.Use(h.authenticateUser)
+		{
+			permissions.GET("/", h.requirePermissions(constants.PermissionRolesRead), h.roleHandler.GetAllPermissions)
+		}
+	}
+}
+
+type RoleHandler struct {
+	roleUseCase usecase.Role
+}
+
+func NewRoleHandler(roleUseCase usecase.Role) *RoleHandler {
+	return &RoleHandler{roleUseCase: roleUseCase}
+}
+
+// CreateRole godoc
+// @Summary Create a new role
+// @Description Create a new user role with the given permissions
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param input body request.CreateRole true "Role object to be created"
+// @Success 200 {integer} integer 1 "Successful response"
+// @Failure 400 {string} string "Invalid input JSON"
+// @Failure 404 {string} string "Permission not found"
+// @Failure 409 {string} string "Role already exists"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var input *request.CreateRole
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	id, customErr := h.roleUseCase.CreateRole(request.MapCreateRoleToRole(input))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "role created", gin.H{
+		"id": id,
+	})
+}
+
+// GetAllRoles godoc
+// @Summary Get all roles
+// @Description Get all user roles with their permissions
+// @Tags roles
+// @Produce json
+// @Success 200 {array} response.GetRole "Successful response"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/roles [get]
+func (h *RoleHandler) GetAllRoles(c *gin.Context) {
+	roles, customErr := h.roleUseCase.GetAllRoles()
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	data := make([]*response.GetRole, len(*roles))
+	for i, role := range *roles {
+		data[i] = response.MapRoleToGetRole(&role)
+	}
+	NewSuccessResponse(c, http.StatusOK, "all roles retrieved", data)
+}
+
+// GetRoleByID godoc
+// @Summary Get a role by ID
+// @Description Get a user role with its permissions based on ID
+// @Tags roles
+// @Produce json
+// @Param id path int true "Role ID" Format(int64)
+// @Success 200 {object} response.GetRole "Successful response"
+// @Failure 400 {string} string "Invalid role id"
+// @Failure 404 {string} string "Role not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/roles/{id} [get]
+func (h *RoleHandler) GetRoleByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	role, customErr := h.roleUseCase.GetRoleByID(uint(id))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "role retrieved", response.MapRoleToGetRole(role))
+}
+
+// UpdateRole godoc
+// @Summary Update the existing role
+// @Description Update a user role. If permissions are given, they replace the role's permissions.
+// @Description The admin role cannot be updated
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path int true "Role ID" Format(int64)
+// @Param input body request.UpdateRole true "Role object to be updated"
+// @Success 200 {string} string "Role updated"
+// @Failure 400 {string} string "Invalid input JSON"
+// @Failure 403 {string} string "Role cannot be modified"
+// @Failure 404 {string} string "Role or permission not found"
+// @Failure 409 {string} string "Role already exists"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/roles/{id} [put]
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	var input *request.UpdateRole
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	role := request.MapUpdateRoleToRole(input)
+	role.ID = uint(id)
+
+	if customErr := h.roleUseCase.UpdateRole(role); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "role updated", nil)
+}
+
+// DeleteRole godoc
+// @Summary Delete a role by ID
+// @Description Delete a user role that is not assigned to any user or API key. The admin role cannot be deleted
+// @Tags roles
+// @Produce json
+// @Param id path int true "Role ID" Format(int64)
+// @Success 200 {string} string "Role deleted"
+// @Failure 400 {string} string "Invalid role id"
+// @Failure 403 {string} string "Role cannot be modified"
+// @Failure 404 {string} string "Role not found"
+// @Failure 409 {string} string "Role is assigned to users or API keys"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/roles/{id} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if customErr := h.roleUseCase.DeleteRole(uint(id)); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "role deleted", nil)
+}
+
+// GetAllPermissions godoc
+// @Summary Get all permissions
+// @Description Get the catalogue of permissions that can be granted to roles
+// @Tags roles
+// @Produce json
+// @Success 200 {array} response.GetPermission "Successful response"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/permissions [get]
+func (h *RoleHandler) GetAllPermissions(c *gin.Context) {
+	permissions, customErr := h.roleUseCase.GetAllPermissions()
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	data := make([]*response.GetPermission, len(*permissions))
+	for i, permission := range *permissions {
+		data[i] = response.MapPermissionToGetPermission(&permission)
+	}
+	NewSuccessResponse(c, http.StatusOK, "all permissions retrieved", data)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"Canteen-Backend/pkg/customErr"
+	"crypto/subtle"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// oidcStateCookie binds a single sign-on to the browser that started it, so that a callback URL
+// from someone else's sign-in cannot be used to sign a victim in to the wrong account.
+const (
+	oidcStateCookie     = "oidc_state"
+	oidcStateCookiePath = "/api/auth/oidc"
+)
+
+// StartOIDCSignIn godoc
+// @Summary Sign in with the university account
+// @Description Redirect to the OpenID Connect provider to sign in. The provider redirects back to the callback
+// @Tags auth
+// @Success 302 {string} string "Redirect to the provider"
+// @Failure 404 {string} string "Single sign-on not configured"
+// @Failure 502 {string} string "Provider unavailable"
+// @Router /api/auth/oidc/login [get]
+func (h *UserHandler) StartOIDCSignIn(c *gin.Context) {
+	authURL, state, customErr := h.userUseCase.StartOIDCSignIn()
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, 600, oidcStateCookiePath, "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// FinishOIDCSignIn godoc
+// @Summary Finish signing in with the university account
+// @Description Called by the OpenID Connect provider after the user signed in. Users are linked by their verified
+// @Description email address or created on their first sign-in, and get the role their provider groups map to.
+// @Description If the user has two-factor authentication enabled or the role requires it, the response carries
+// @Description a challenge token for the local second factor instead of the token pair
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} models.SignInResult "Successful response"
+// @Failure 400 {string} string "Invalid or expired state"
+// @Failure 401 {string} string "Single sign-on failed"
+// @Failure 403 {string} string "User inactive or not in a mapped group"
+// @Failure 404 {string} string "Single sign-on not configured"
+// @Router /api/auth/oidc/callback [get]
+func (h *UserHandler) FinishOIDCSignIn(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		NewErrorResponse(c, http.StatusUnauthorized, customErr.SSOSignInFailed.Error(),
+			errors.New(providerErr+": "+c.Query("error_description")), nil)
+		return
+	}
+
+	state := c.Query("state")
+	cookieState, err := c.Cookie(oidcStateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookieState)) != 1 {
+		NewErrorResponse(c, http.StatusBadRequest, customErr.SSOStateInvalid.Error(), err, nil)
+		return
+	}
+	c.SetCookie(oidcStateCookie, "", -1, oidcStateCookiePath, "", c.Request.TLS != nil, true)
+
+	result, customError := h.userUseCase.FinishOIDCSignIn(c.Query("code"), state, sessionMetadata(c))
+	if customError != nil {
+		NewErrorResponse(c, customError.StatusCode, customError.Message, customError.Error, nil)
+		return
+	}
+
+	if result.Token == nil {
+		NewSensitiveSuccessResponse(c, http.StatusOK, "user sign in challenged", result)
+		return
+	}
+
+	NewSensitiveSuccessResponse(c, http.StatusOK, "user signed in", result)
+}
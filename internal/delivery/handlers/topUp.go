@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/delivery/dto/request"
+	"Canteen-Backend/internal/delivery/dto/response"
+	"Canteen-Backend/internal/usecase"
+	"Canteen-Backend/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// maxWebhookSize bounds the body of the payment provider's webhook requests.
+const maxWebhookSize = 1 << 20
+
+func (h *Handler) initTopUpRoutes(api *gin.RouterGroup) {
+
+	// the payment provider signs its webhook requests instead of authenticating
+	api.POST("/payments/webhook", h.topUpHandler.HandlePaymentWebhook)
+
+	topUps := api.Group("/top-ups")
+	{
+		topUps.Use(h.authenticateUser)
+		{
+			topUps.POST("/", h.requirePermissions(constants.PermissionTopUpsWrite), h.topUpHandler.CreateTopUp)
+			topUps.GET("/:id", h.requirePermissions(constants.PermissionClientsRead), h.topUpHandler.GetTopUpByID)
+		}
+	}
+}
+
+type TopUpHandler struct {
+	topUpUseCase usecase.TopUp
+}
+
+func NewTopUpHandler(topUpUseCase usecase.TopUp) *TopUpHandler {
+	return &TopUpHandler{topUpUseCase: topUpUseCase}
+}
+
+// CreateTopUp godoc
+// @Summary Start an online top-up
+// @Description Create a payment with the payment provider for topping up the client's balance, to be paid on the provider's
+// @Description checkout page. The balance is credited once the provider reports that the payment succeeded
+// @Tags top-ups
+// @Accept json
+// @Produce json
+// @Param input body request.CreateTopUp true "Top-up"
+// @Success 201 {object} response.GetTopUp "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string "Client not found, or online payments are not configured"
+// @Failure 409 {string} string "Client is deactivated"
+// @Failure 502 {string} string "Payment provider unavailable"
+// @Failure 500 {string} string
+// @Router /api/top-ups [post]
+func (h *TopUpHandler) CreateTopUp(c *gin.Context) {
+	var input *request.CreateTopUp
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), err, nil)
+		return
+	}
+
+	topUp := request.MapCreateTopUpToTopUp(input)
+	if customErr := h.topUpUseCase.CreateTopUp(topUp, operator(c)); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"client_id": input.ClientID})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusCreated, "top-up created", response.MapTopUpToGetTopUp(topUp))
+}
+
+// GetTopUpByID godoc
+// @Summary Get an online top-up
+// @Description Get an online top-up and the status of its payment: pending, succeeded or failed
+// @Tags top-ups
+// @Produce json
+// @Param id path int true "Top-up ID" Format(int64)
+// @Success 200 {object} response.GetTopUp "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/top-ups/{id} [get]
+func (h *TopUpHandler) GetTopUpByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	topUp, customErr := h.topUpUseCase.GetTopUpByID(uint(id))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "top-up received", response.MapTopUpToGetTopUp(topUp))
+}
+
+// GetClientTopUps godoc
+// @Summary Get the online top-ups of a client
+// @Description Get the client's online top-ups, most recent first
+// @Tags top-ups
+// @Produce json
+// @Param id path int true "Client ID" Format(int64)
+// @Success 200 {array} response.GetTopUp "Successful response"
+// @Failure 400 {string} string
+// @Failure 404 {string} string
+// @Failure 500 {string} string
+// @Router /api/clients/{id}/top-ups [get]
+func (h *TopUpHandler) GetClientTopUps(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	topUps, customErr := h.topUpUseCase.GetClientTopUps(uint(id))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	data := make([]*response.GetTopUp, len(*topUps))
+	for i := range *topUps {
+		data[i] = response.MapTopUpToGetTopUp(&(*topUps)[i])
+	}
+	NewSuccessResponse(c, http.StatusOK, "client top-ups received", data)
+}
+
+// HandlePaymentWebhook godoc
+// @Summary Receive a payment provider's webhook
+// @Description Receive the payment provider's report of a payment's outcome, signed in the Payment-Signature header.
+// @Description A successful payment credits the client's balance once, however often the provider delivers it
+// @Tags top-ups
+// @Accept json
+// @Produce json
+// @Param Payment-Signature header string true "Signature of the request, e.g. t=1700000000,v1=5257a8..."
+// @Success 200 {object} response.GetTopUp "Successful response"
+// @Failure 400 {string} string
+// @Failure 401 {string} string "Invalid or expired signature"
+// @Failure 404 {string} string "Top-up not found, or online payments are not configured"
+// @Failure 409 {string} string "Amount paid does not match the top-up, or the top-up was paid by another transaction"
+// @Failure 500 {string} string
+// @Router /api/payments/webhook [post]
+func (h *TopUpHandler) HandlePaymentWebhook(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookSize))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid webhook body", err, nil)
+		return
+	}
+
+	topUp, customErr := h.topUpUseCase.HandlePaymentWebhook(c.Request.Header, body)
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "payment webhook processed", response.MapTopUpToGetTopUp(topUp))
+}
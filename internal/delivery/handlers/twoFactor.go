@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"Canteen-Backend/internal/delivery/dto/request"
+	"Canteen-Backend/internal/delivery/dto/response"
+	"Canteen-Backend/pkg/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// VerifyTwoFactorSignIn godoc
+// @Summary Finish a sign-in with a two-factor code
+// @Description Finish a sign-in challenged for two-factor authentication with a TOTP code or a recovery code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body request.TwoFactorSignIn true "Challenge token and code"
+// @Success 200 {object} models.SignInResult "Successful response"
+// @Failure 400 {string} string "Invalid input JSON"
+// @Failure 401 {string} string "Invalid challenge or code"
+// @Failure 429 {string} string "Too many sign-in attempts"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/sign-in/2fa [post]
+func (h *UserHandler) VerifyTwoFactorSignIn(c *gin.Context) {
+	var input *request.TwoFactorSignIn
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	result, customErr := h.userUseCase.VerifyTwoFactorSignIn(input.ChallengeToken, input.Code, sessionMetadata(c))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSensitiveSuccessResponse(c, http.StatusOK, "user signed in", result)
+}
+
+// EnrollTwoFactorOnSignIn godoc
+// @Summary Set up two-factor authentication during sign-in
+// @Description Generate a TOTP secret for a user whose role requires two-factor authentication before signing in
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body request.TwoFactorChallenge true "Challenge token"
+// @Success 200 {object} models.TwoFactorEnrollment "Successful response"
+// @Failure 400 {string} string "Invalid input JSON"
+// @Failure 401 {string} string "Invalid challenge"
+// @Failure 409 {string} string "Two-factor authentication already enabled"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/sign-in/2fa/enroll [post]
+func (h *UserHandler) EnrollTwoFactorOnSignIn(c *gin.Context) {
+	var input *request.TwoFactorChallenge
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	enrollment, customErr := h.userUseCase.EnrollTwoFactorOnSignIn(input.ChallengeToken, sessionMetadata(c))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSensitiveSuccessResponse(c, http.StatusOK, "two-factor authentication enrolled", enrollment)
+}
+
+// ConfirmTwoFactorOnSignIn godoc
+// @Summary Confirm two-factor authentication during sign-in
+// @Description Enable the TOTP secret enrolled during sign-in with a code from the authenticator app and finish the sign-in.
+// @Description The response carries the recovery codes, which are shown only once
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body request.TwoFactorSignIn true "Challenge token and TOTP code"
+// @Success 200 {object} models.SignInResult "Successful response"
+// @Failure 400 {string} string "Invalid input JSON"
+// @Failure 401 {string} string "Invalid challenge or code"
+// @Failure 409 {string} string "Two-factor enrollment not started"
+// @Failure 429 {string} string "Too many sign-in attempts"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/sign-in/2fa/confirm [post]
+func (h *UserHandler) ConfirmTwoFactorOnSignIn(c *gin.Context) {
+	var input *request.TwoFactorSignIn
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	result, customErr := h.userUseCase.ConfirmTwoFactorOnSignIn(input.ChallengeToken, input.Code, sessionMetadata(c))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSensitiveSuccessResponse(c, http.StatusOK, "user signed in", result)
+}
+
+// EnrollTwoFactor godoc
+// @Summary Start two-factor authentication enrollment
+// @Description Generate a TOTP secret for the signed in user. It has to be confirmed before it is used
+// @Tags auth
+// @Produce json
+// @Success 200 {object} models.TwoFactorEnrollment "Successful response"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Two-factor authentication already enabled"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/2fa/enroll [post]
+func (h *UserHandler) EnrollTwoFactor(c *gin.Context) {
+	enrollment, customErr := h.userUseCase.EnrollTwoFactor(c.GetUint("user_id"))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSensitiveSuccessResponse(c, http.StatusOK, "two-factor authentication enrolled", enrollment)
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Confirm two-factor authentication
+// @Description Enable the enrolled TOTP secret of the signed in user with a code from the authenticator app.
+// @Description The response carries the recovery codes, which are shown only once
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body request.TwoFactorCode true "TOTP code"
+// @Success 200 {object} response.RecoveryCodes "Successful response"
+// @Failure 400 {string} string "Invalid input JSON or code"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Two-factor enrollment not started"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/2fa/confirm [post]
+func (h *UserHandler) ConfirmTwoFactor(c *gin.Context) {
+	var input *request.TwoFactorCode
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	recoveryCodes, customErr := h.userUseCase.ConfirmTwoFactor(c.GetUint("user_id"), input.Code)
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSensitiveSuccessResponse(c, http.StatusOK, "two-factor authentication enabled", &response.RecoveryCodes{RecoveryCodes: recoveryCodes})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Disable two-factor authentication of the signed in user with a TOTP code or a recovery code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body request.TwoFactorCode true "TOTP code or recovery code"
+// @Success 200 {string} string "Two-factor authentication disabled"
+// @Failure 400 {string} string "Invalid input JSON or code"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Two-factor authentication is required for the role"
+// @Failure 409 {string} string "Two-factor authentication not enabled"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/2fa/disable [post]
+func (h *UserHandler) DisableTwoFactor(c *gin.Context) {
+	var input *request.TwoFactorCode
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	if customErr := h.userUseCase.DisableTwoFactor(c.GetUint("user_id"), input.Code); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace the recovery codes of the signed in user, which requires a TOTP code or a recovery code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body request.TwoFactorCode true "TOTP code or recovery code"
+// @Success 200 {object} response.RecoveryCodes "Successful response"
+// @Failure 400 {string} string "Invalid input JSON or code"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Two-factor authentication not enabled"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/2fa/recovery-codes [post]
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var input *request.TwoFactorCode
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	recoveryCodes, customErr := h.userUseCase.RegenerateRecoveryCodes(c.GetUint("user_id"), input.Code)
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSensitiveSuccessResponse(c, http.StatusOK, "recovery codes regenerated", &response.RecoveryCodes{RecoveryCodes: recoveryCodes})
+}
+
+// ResetUserTwoFactor godoc
+// @Summary Reset a user's two-factor authentication
+// @Description Remove the two-factor authentication of a user who lost the authenticator and the recovery codes,
+// @Description and sign the user out everywhere
+// @ID reset-user-two-factor
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID" Format(int64)
+// @Success 200 {string} string "Two-factor authentication reset"
+// @Failure 400 {string} string "Invalid user id"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/users/{id}/2fa [delete]
+func (h *UserHandler) ResetUserTwoFactor(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if customErr := h.userUseCase.ResetTwoFactor(uint(id)); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "two-factor authentication reset", nil)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	_ "Canteen-Backend/docs"
+	"Canteen-Backend/internal/constants"
 	"Canteen-Backend/internal/delivery/dto/request"
 	"Canteen-Backend/internal/delivery/dto/response"
 	"Canteen-Backend/internal/models"
@@ -17,19 +18,55 @@ func (h *Handler) initUserRoutes(api *gin.RouterGroup) {
 	auth := api.Group("/auth")
 	{
 		auth.POST("/sign-in", h.userHandler.SignIn)
+		auth.POST("/sign-in/2fa", h.userHandler.VerifyTwoFactorSignIn)
+		auth.POST("/sign-in/2fa/enroll", h.userHandler.EnrollTwoFactorOnSignIn)
+		auth.POST("/sign-in/2fa/confirm", h.userHandler.ConfirmTwoFactorOnSignIn)
+		auth.POST("/sign-in/password", h.userHandler.ChangePasswordOnSignIn)
+		auth.POST("/forgot-password", h.userHandler.ForgotPassword)
+		auth.POST("/reset-password", h.userHandler.ResetPassword)
+		auth.GET("/oidc/login", h.userHandler.StartOIDCSignIn)
+		auth.GET("/oidc/callback", h.userHandler.FinishOIDCSignIn)
 		auth.POST("/sign-out", h.userHandler.SignOut)
 		auth.POST("/refresh-token", h.userHandler.RefreshToken)
+
+		me := auth.Group("/me", h.authenticateUser, h.requireUserSession)
+		{
+			me.GET("/", h.userHandler.GetProfile)
+			me.PUT("/", h.userHandler.UpdateProfile)
+			me.PUT("/password", h.userHandler.ChangePassword)
+		}
+
+		sessions := auth.Group("/sessions", h.authenticateUser, h.requireUserSession)
+		{
+			sessions.GET("/", h.userHandler.GetMySessions)
+			sessions.DELETE("/", h.userHandler.RevokeMyOtherSessions)
+			sessions.DELETE("/:id", h.userHandler.RevokeMySession)
+		}
+
+		twoFactor := auth.Group("/2fa", h.authenticateUser, h.requireUserSession)
+		{
+			twoFactor.POST("/enroll", h.userHandler.EnrollTwoFactor)
+			twoFactor.POST("/confirm", h.userHandler.ConfirmTwoFactor)
+			twoFactor.POST("/disable", h.userHandler.DisableTwoFactor)
+			twoFactor.POST("/recovery-codes", h.userHandler.RegenerateRecoveryCodes)
+		}
 	}
 
 	users := api.Group("/users")
 	{
 		users.Use(h.authenticateUser)
 		{
-			users.POST("/", h.userHandler.CreateUser)
-			users.GET("/", h.userHandler.GetAllUsers)
-			users.GET("/:id", h.userHandler.GetUserByID)
-			users.PUT("/:id", h.userHandler.UpdateUser)
-			users.DELETE("/:id", h.userHandler.DeleteUser)
+			users.POST("/", h.requirePermissions(constants.PermissionUsersWrite), h.userHandler.CreateUser)
+			users.GET("/", h.requirePermissions(constants.PermissionUsersRead), h.userHandler.GetAllUsers)
+			users.GET("/:id", h.requirePermissions(constants.PermissionUsersRead), h.userHandler.GetUserByID)
+			users.PUT("/:id", h.requirePermissions(constants.PermissionUsersWrite), h.userHandler.UpdateUser)
+			users.DELETE("/:id", h.requirePermissions(constants.PermissionUsersWrite), h.userHandler.DeleteUser)
+			users.POST("/:id/unlock", h.requirePermissions(constants.PermissionUsersWrite), h.userHandler.UnlockUser)
+			users.DELETE("/:id/2fa", h.requirePermissions(constants.PermissionUsersWrite), h.userHandler.ResetUserTwoFactor)
+
+			users.GET("/:id/sessions", h.requirePermissions(constants.PermissionUsersRead), h.userHandler.GetUserSessions)
+			users.DELETE("/:id/sessions", h.requirePermissions(constants.PermissionUsersWrite), h.userHandler.RevokeUserSessions)
+			users.DELETE("/:id/sessions/:session_id", h.requirePermissions(constants.PermissionUsersWrite), h.userHandler.RevokeUserSession)
 		}
 	}
 }
@@ -44,15 +81,18 @@ func NewUserHandler(userUseCase usecase.User) *UserHandler {
 
 // SignIn godoc
 // @Summary Sign in a user
-// @Description Sign in a user with the provided JSON input
+// @Description Sign in a user with the provided JSON input. If the user has two-factor authentication enabled,
+// @Description or the user's role requires it, or the user has to change the password,
+// @Description the response carries a challenge token instead of the token pair
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param input body request.SignIn true "User sign in object"
-// @Success 200 {object} models.Token "Successful response"
+// @Success 200 {object} models.SignInResult "Successful response"
 // @Failure 400 {string} string "Invalid input JSON"
-// @Failure 401 {string} string "Unauthorized"
-// @Failure 404 {string} string "User not found"
+// @Failure 401 {string} string "Invalid username or password"
+// @Failure 403 {string} string "User inactive"
+// @Failure 429 {string} string "Too many sign-in attempts"
 // @Router /api/auth/sign-in [post]
 func (h *UserHandler) SignIn(c *gin.Context) {
 	var input *request.SignIn
@@ -66,13 +106,18 @@ func (h *UserHandler) SignIn(c *gin.Context) {
 		return
 	}
 
-	tokens, customErr := h.userUseCase.SignIn(request.MapSignInToUser(input))
+	result, customErr := h.userUseCase.SignIn(request.MapSignInToUser(input), sessionMetadata(c))
 	if customErr != nil {
 		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
 		return
 	}
 
-	NewSuccessResponse(c, http.StatusOK, "user signed in", tokens)
+	if result.Token == nil {
+		NewSensitiveSuccessResponse(c, http.StatusOK, "user sign in challenged", result)
+		return
+	}
+
+	NewSensitiveSuccessResponse(c, http.StatusOK, "user signed in", result)
 }
 
 // SignOut godoc
@@ -133,13 +178,13 @@ func (h *UserHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	accessToken, customErr := h.userUseCase.RefreshTokens(input.RefreshToken)
+	accessToken, customErr := h.userUseCase.RefreshTokens(input.RefreshToken, sessionMetadata(c))
 	if customErr != nil {
 		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
 		return
 	}
 
-	NewSuccessResponse(c, http.StatusOK, "token refreshed", accessToken)
+	NewSensitiveSuccessResponse(c, http.StatusOK, "token refreshed", accessToken)
 }
 
 // CreateUser godoc
@@ -270,6 +315,20 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	if input.IsActive != nil {
+		if customErr := h.userUseCase.SetUserActive(user.ID, *input.IsActive); customErr != nil {
+			NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+			return
+		}
+	}
+
+	if input.MustChangePassword != nil {
+		if customErr := h.userUseCase.SetMustChangePassword(user.ID, *input.MustChangePassword); customErr != nil {
+			NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+			return
+		}
+	}
+
 	NewSuccessResponse(c, http.StatusOK, "user updated", nil)
 }
 
@@ -301,3 +360,266 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 
 	NewSuccessResponse(c, http.StatusOK, "user deleted", nil)
 }
+
+// UnlockUser godoc
+// @Summary Unlock a user
+// @Description Lift a sign-in lockout of a user and reset the failed sign-in attempts
+// @ID unlock-user
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID" Format(int64)
+// @Success 200 {string} string "User unlocked"
+// @Failure 400 {string} string "Invalid user id"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/users/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	customErr := h.userUseCase.UnlockUser(uint(id))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "user unlocked", nil)
+}
+
+// GetProfile godoc
+// @Summary Get the current user
+// @Description Get the profile of the signed in user with the role and its permissions
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.GetProfile "Successful response"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/me [get]
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	user, role, customErr := h.userUseCase.GetProfile(c.GetUint("user_id"))
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "profile retrieved", response.MapUserToGetProfile(user, role))
+}
+
+// UpdateProfile godoc
+// @Summary Update the current user
+// @Description Update the name and email of the signed in user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body request.UpdateProfile true "Profile fields to be updated"
+// @Success 200 {string} string "Profile updated"
+// @Failure 400 {string} string "Invalid input JSON"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 409 {string} string "Email already exists"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/me [put]
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	var input *request.UpdateProfile
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	user := request.MapUpdateProfileToUser(input)
+	user.ID = c.GetUint("user_id")
+
+	if customErr := h.userUseCase.UpdateUser(user); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "profile updated", nil)
+}
+
+// ChangePassword godoc
+// @Summary Change the current user's password
+// @Description Change the password of the signed in user, which requires the current password.
+// @Description All other sessions of the user are revoked
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body request.ChangePassword true "Current and new password"
+// @Success 200 {string} string "Password changed"
+// @Failure 400 {string} string "Invalid input JSON"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Current password is incorrect"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/me/password [put]
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	var input *request.ChangePassword
+	if err := c.BindJSON(&input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid input JSON", err, nil)
+		return
+	}
+
+	if err := validator.ValidatePayload(input); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error(), nil, nil)
+		return
+	}
+
+	customErr := h.userUseCase.ChangePassword(c.GetUint("user_id"), c.GetString("session_id"), input.CurrentPassword, input.NewPassword)
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "password changed", nil)
+}
+
+// GetMySessions godoc
+// @Summary Get the current user's sessions
+// @Description Get all active sessions of the signed in user
+// @Tags auth
+// @Produce json
+// @Success 200 {array} response.GetSession "Successful response"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/sessions [get]
+func (h *UserHandler) GetMySessions(c *gin.Context) {
+	h.respondWithSessions(c, c.GetUint("user_id"))
+}
+
+// RevokeMySession godoc
+// @Summary Revoke one of the current user's sessions
+// @Description Revoke a session of the signed in user by its ID
+// @Tags auth
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {string} string "Session revoked"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Session not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/sessions/{id} [delete]
+func (h *UserHandler) RevokeMySession(c *gin.Context) {
+	if customErr := h.userUseCase.RevokeSession(c.GetUint("user_id"), c.Param("id")); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"session_id": c.Param("id")})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "session revoked", nil)
+}
+
+// RevokeMyOtherSessions godoc
+// @Summary Revoke the current user's other sessions
+// @Description Revoke all sessions of the signed in user except the current one
+// @Tags auth
+// @Produce json
+// @Success 200 {string} string "Sessions revoked"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/auth/sessions [delete]
+func (h *UserHandler) RevokeMyOtherSessions(c *gin.Context) {
+	if customErr := h.userUseCase.RevokeAllSessions(c.GetUint("user_id"), c.GetString("session_id")); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, nil)
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "other sessions revoked", nil)
+}
+
+// GetUserSessions godoc
+// @Summary Get a user's sessions
+// @Description Get all active sessions of a user based on ID
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID" Format(int64)
+// @Success 200 {array} response.GetSession "Successful response"
+// @Failure 400 {string} string "Invalid user id"
+// @Failure 404 {string} string "User not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/users/{id}/sessions [get]
+func (h *UserHandler) GetUserSessions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	h.respondWithSessions(c, uint(id))
+}
+
+// RevokeUserSession godoc
+// @Summary Revoke a user's session
+// @Description Revoke one session of a user based on user ID and session ID
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID" Format(int64)
+// @Param session_id path string true "Session ID"
+// @Success 200 {string} string "Session revoked"
+// @Failure 400 {string} string "Invalid user id"
+// @Failure 404 {string} string "Session not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/users/{id}/sessions/{session_id} [delete]
+func (h *UserHandler) RevokeUserSession(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if customErr := h.userUseCase.RevokeSession(uint(id), c.Param("session_id")); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id, "session_id": c.Param("session_id")})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "session revoked", nil)
+}
+
+// RevokeUserSessions godoc
+// @Summary Revoke all sessions of a user
+// @Description Revoke every session of a user based on ID
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID" Format(int64)
+// @Success 200 {string} string "Sessions revoked"
+// @Failure 400 {string} string "Invalid user id"
+// @Failure 500 {string} string "Internal server error"
+// @Router /api/users/{id}/sessions [delete]
+func (h *UserHandler) RevokeUserSessions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid id", err, nil)
+		return
+	}
+
+	if customErr := h.userUseCase.RevokeAllSessions(uint(id), ""); customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"id": id})
+		return
+	}
+
+	NewSuccessResponse(c, http.StatusOK, "user sessions revoked", nil)
+}
+
+func (h *UserHandler) respondWithSessions(c *gin.Context, userID uint) {
+	sessions, customErr := h.userUseCase.GetActiveSessions(userID)
+	if customErr != nil {
+		NewErrorResponse(c, customErr.StatusCode, customErr.Message, customErr.Error, gin.H{"user_id": userID})
+		return
+	}
+
+	data := make([]*response.GetSession, len(*sessions))
+	for i, session := range *sessions {
+		data[i] = response.MapSessionToGetSession(&session, c.GetString("session_id"))
+	}
+	NewSuccessResponse(c, http.StatusOK, "sessions retrieved", data)
+}
+
+func sessionMetadata(c *gin.Context) *models.SessionMetadata {
+	return &models.SessionMetadata{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
@@ -1,22 +1,196 @@
 package models
 
 import (
+	"Canteen-Backend/pkg/money"
 	"time"
 )
 
 type Client struct {
-	ID               uint      `gorm:"column:client_id;primaryKey"`
-	CreatedAt        time.Time `gorm:"column:created_at"`
-	UpdatedAt        time.Time `gorm:"column:updated_at"`
-	DeletedAt        time.Time `gorm:"column:deleted_at"`
-	FirstName        string    `gorm:"column:first_name"`
-	LastName         string    `gorm:"column:last_name"`
-	Age              uint      `gorm:"column:age"`
-	Gender           string    `gorm:"column:gender"`
-	Email            string    `gorm:"column:email"`
+	ID               uint        `gorm:"column:client_id;primaryKey"`
+	CreatedAt        time.Time   `gorm:"column:created_at"`
+	UpdatedAt        time.Time   `gorm:"column:updated_at"`
+	DeletedAt        time.Time   `gorm:"column:deleted_at"`
+	FirstName        string      `gorm:"column:first_name"`
+	LastName         string      `gorm:"column:last_name"`
+	Age              uint        `gorm:"column:age"`
+	Gender           string      `gorm:"column:gender"`
+	Email            string      `gorm:"column:email"`
+	ClientCategoryID uint        `gorm:"column:client_category_id"`
+	Balance          money.Money `gorm:"column:balance"`
+	IsActive         bool        `gorm:"column:is_active"`
+	// CreditLimit overrides the credit limit of the client's category, nil if the client has none of their own
+	CreditLimit    *money.Money   `gorm:"column:credit_limit"`
+	SpendingLimits SpendingLimits `gorm:"embedded"`
+}
+
+// SpendingLimits cap how much a client spends on purchases, net of refunds, in a day or a week of the canteen's
+// time zone, or in a single purchase. A nil limit does not cap spending.
+type SpendingLimits struct {
+	Daily       *money.Money `gorm:"column:daily_spending_limit"`
+	Weekly      *money.Money `gorm:"column:weekly_spending_limit"`
+	Transaction *money.Money `gorm:"column:transaction_spending_limit"`
+}
+
+// ClientImportRow is a client read from a row of an import file. Line is the row's line number in the file,
+// and Error says why the client cannot be imported.
+type ClientImportRow struct {
+	Line   int
+	Client *Client
+	Error  string
+}
+
+// ClientFilter narrows down, orders and pages the client list. Zero fields do not filter.
+type ClientFilter struct {
+	// Name matches the first name, last name or full name, ignoring case
+	Name               string
+	Email              string
+	ClientCategoryID   uint
+	ClientCategoryName string
+	IsActive           *bool
+	MinBalance         *money.Money
+	MaxBalance         *money.Money
+	Sort               []SortField
+	Limit              int
+	Offset             int
+}
+
+// SortField is a field to order a list by, e.g. "last_name".
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// BalanceTransaction is an entry of a client's balance ledger. Amount is signed, and BalanceAfter
+// is the client's balance once the transaction was applied.
+type BalanceTransaction struct {
+	ID           uint        `gorm:"column:balance_transaction_id;primaryKey"`
+	ClientID     uint        `gorm:"column:client_id"`
+	Type         string      `gorm:"column:type"`
+	Amount       money.Money `gorm:"column:amount"`
+	BalanceAfter money.Money `gorm:"column:balance_after"`
+	UserID       *uint       `gorm:"column:user_id"`
+	APIKeyID     *uint       `gorm:"column:api_key_id"`
+	Reason       string      `gorm:"column:reason"`
+	// IdempotencyKey is chosen by the caller, so that a retried request is not applied twice
+	IdempotencyKey *string `gorm:"column:idempotency_key"`
+	// GrossAmount is what a purchase cost before the client category's discount and subsidy,
+	// which were taken off it under the pricing rules with ClientCategoryPricingID. It is nil for other transactions.
+	GrossAmount             *money.Money             `gorm:"column:gross_amount"`
+	Discount                money.Money              `gorm:"column:discount"`
+	Subsidy                 money.Money              `gorm:"column:subsidy"`
+	ClientCategoryPricingID *uint                    `gorm:"column:client_category_pricing_id"`
+	Items                   []BalanceTransactionItem `gorm:"-"`
+	// DietaryOverrideReason is why the cashier sells dishes the client is allergic to, the sale is refused without it
+	DietaryOverrideReason string            `gorm:"-"`
+	DietaryConflicts      []DietaryConflict `gorm:"-"`
+	DietaryOverrides      []DietaryOverride `gorm:"-"`
+	CreatedAt             time.Time         `gorm:"column:created_at"`
+}
+
+// DietaryRestrictions are what a client cannot or will not eat.
+type DietaryRestrictions struct {
+	AllergenIDs []uint
+	Vegetarian  bool
+	Halal       bool
+}
+
+// DietaryConflict is a dish of a purchase that the client should not eat. Restriction is the allergen's name,
+// or the diet, "vegetarian" or "halal". Allergies block the purchase, diets only warn.
+type DietaryConflict struct {
+	DishID      uint
+	DishName    string
+	Restriction string
+	Blocking    bool
+}
+
+// DietaryOverride records that a cashier sold a dish despite a client's allergy, and why.
+type DietaryOverride struct {
+	ID                   uint      `gorm:"column:dietary_override_id;primaryKey"`
+	BalanceTransactionID uint      `gorm:"column:balance_transaction_id"`
+	DishID               uint      `gorm:"column:dish_id"`
+	Restriction          string    `gorm:"column:restriction"`
+	Reason               string    `gorm:"column:reason"`
+	UserID               *uint     `gorm:"column:user_id"`
+	APIKeyID             *uint     `gorm:"column:api_key_id"`
+	CreatedAt            time.Time `gorm:"column:created_at"`
+}
+
+// BalanceTransactionItem is a dish bought in a purchase. UnitPrice is the price the client's category paid for it,
+// before the discount and subsidy.
+type BalanceTransactionItem struct {
+	BalanceTransactionID uint        `gorm:"column:balance_transaction_id;primaryKey"`
+	DishID               uint        `gorm:"column:dish_id;primaryKey"`
+	Quantity             int         `gorm:"column:quantity"`
+	UnitPrice            money.Money `gorm:"column:unit_price"`
+}
+
+// BalanceTransactionFilter narrows down a client's ledger. Zero fields do not filter.
+type BalanceTransactionFilter struct {
+	From time.Time
+	To   time.Time
+	Type string
+}
+
+// Operator is who makes a change: a signed in user or, for machine clients, an API key.
+type Operator struct {
+	UserID   *uint
+	APIKeyID *uint
+}
+
+// ClientIdentifier is a card, QR code or RFID tag that identifies a client at the till.
+// A value identifies at most one client, for good: identifiers are blocked or replaced, never reused.
+type ClientIdentifier struct {
+	ID       uint   `gorm:"column:client_identifier_id;primaryKey"`
+	ClientID uint   `gorm:"column:client_id"`
+	Type     string `gorm:"column:type"`
+	Value    string `gorm:"column:value"`
+	Status   string `gorm:"column:status"`
+	// ReplacedByID is the identifier that was issued in place of this one
+	ReplacedByID *uint     `gorm:"column:replaced_by_id"`
+	CreatedAt    time.Time `gorm:"column:created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at"`
+}
+
+// ClientIdentifierEvent is an entry of a client identifier's history. Status is the identifier's status after the event.
+type ClientIdentifierEvent struct {
+	ID                 uint      `gorm:"column:client_identifier_event_id;primaryKey"`
+	ClientIdentifierID uint      `gorm:"column:client_identifier_id"`
+	Event              string    `gorm:"column:event"`
+	Status             string    `gorm:"column:status"`
+	UserID             *uint     `gorm:"column:user_id"`
+	APIKeyID           *uint     `gorm:"column:api_key_id"`
+	Reason             string    `gorm:"column:reason"`
+	CreatedAt          time.Time `gorm:"column:created_at"`
+}
+
+// ClientLookup is the client an identifier presented at the till belongs to.
+type ClientLookup struct {
+	Client     *Client
+	Category   *ClientCategory
+	Identifier *ClientIdentifier
+}
+
+// ClientCategoryPricing is a version of a client category's pricing rules, in effect from EffectiveFrom until
+// the next version takes over. Purchases are priced from the category's price list, or the dishes' own prices,
+// then the discount is taken off, and then the subsidy, granted per meal or per day, covers as much of the rest as it can.
+type ClientCategoryPricing struct {
+	ID               uint      `gorm:"column:client_category_pricing_id;primaryKey"`
 	ClientCategoryID uint      `gorm:"column:client_category_id"`
-	Balance          float32   `gorm:"column:balance"`
-	IsActive         bool      `gorm:"column:is_active"`
+	EffectiveFrom    time.Time `gorm:"column:effective_from"`
+	// DiscountPercent is between 0 and 100
+	DiscountPercent float64               `gorm:"column:discount_percent"`
+	Subsidy         money.Money           `gorm:"column:subsidy"`
+	SubsidyPeriod   string                `gorm:"column:subsidy_period"`
+	UserID          *uint                 `gorm:"column:user_id"`
+	CreatedAt       time.Time             `gorm:"column:created_at"`
+	Prices          []ClientCategoryPrice `gorm:"-"`
+}
+
+// ClientCategoryPrice is the price of a dish on a client category's price list.
+type ClientCategoryPrice struct {
+	ClientCategoryPricingID uint        `gorm:"column:client_category_pricing_id;primaryKey"`
+	DishID                  uint        `gorm:"column:dish_id;primaryKey"`
+	Price                   money.Money `gorm:"column:price"`
 }
 
 type ClientCategory struct {
@@ -26,4 +200,7 @@ type ClientCategory struct {
 	UpdatedAt time.Time `gorm:"column:updated_at"`
 	DeletedAt time.Time `gorm:"column:deleted_at"`
 	IsActive  bool      `gorm:"column:is_active"`
+	// CreditLimit is how far below zero purchases may take a client's balance, zero allows no overdraft.
+	// It is a pointer so that an update can set it to zero.
+	CreditLimit *money.Money `gorm:"column:credit_limit"`
 }
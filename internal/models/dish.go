@@ -0,0 +1,28 @@
+package models
+
+import (
+	"Canteen-Backend/pkg/money"
+	"time"
+)
+
+// Dish is an item on the canteen's menu. Price is what clients pay unless their category has a price of its own.
+// Dishes are deactivated rather than deleted, so that past purchases keep pointing at them.
+type Dish struct {
+	ID        uint        `gorm:"column:dish_id;primaryKey"`
+	Name      string      `gorm:"column:name"`
+	Price     money.Money `gorm:"column:price"`
+	IsActive  bool        `gorm:"column:is_active"`
+	CreatedAt time.Time   `gorm:"column:created_at"`
+	UpdatedAt time.Time   `gorm:"column:updated_at"`
+}
+
+// DishDietaryInfo is what a dish's ingredients make of it: the allergens it contains and whether
+// vegetarians and clients who eat halal can eat it. A dish without ingredients contains no allergens.
+type DishDietaryInfo struct {
+	DishID        uint
+	DishName      string
+	IngredientIDs []uint
+	Allergens     []Allergen
+	IsVegetarian  bool
+	IsHalal       bool
+}
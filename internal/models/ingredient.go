@@ -1,19 +1,22 @@
 package models
 
-import "time"
+import (
+	"Canteen-Backend/pkg/money"
+	"time"
+)
 
 type Ingredient struct {
-	ID                   uint      `gorm:"column:ingredient_id"`
-	Name                 string    `gorm:"column:name"`
-	IngredientCategoryID uint      `gorm:"column:ingredient_category_id"`
-	Unit                 string    `gorm:"column:unit"`
-	Quantity             float64   `gorm:"column:quantity"`
-	UnitPrice            float64   `gorm:"column:unit_price"`
-	LackLimit            float64   `gorm:"column:lack_limit"`
-	PurchaseDate         time.Time `gorm:"column:purchase_date"`
-	ExpirationDate       time.Time `gorm:"column:expiration_date"`
-	CreatedAt            time.Time `gorm:"column:created_at"`
-	UpdatedAt            time.Time `gorm:"column:updated_at"`
+	ID                   uint        `gorm:"column:ingredient_id"`
+	Name                 string      `gorm:"column:name"`
+	IngredientCategoryID uint        `gorm:"column:ingredient_category_id"`
+	Unit                 string      `gorm:"column:unit"`
+	Quantity             float64     `gorm:"column:quantity"`
+	UnitPrice            money.Money `gorm:"column:unit_price"`
+	LackLimit            float64     `gorm:"column:lack_limit"`
+	PurchaseDate         time.Time   `gorm:"column:purchase_date"`
+	ExpirationDate       time.Time   `gorm:"column:expiration_date"`
+	CreatedAt            time.Time   `gorm:"column:created_at"`
+	UpdatedAt            time.Time   `gorm:"column:updated_at"`
 }
 
 type IngredientCategory struct {
@@ -22,3 +25,18 @@ type IngredientCategory struct {
 	CreatedAt time.Time `gorm:"column:created_at"`
 	UpdatedAt time.Time `gorm:"column:updated_at"`
 }
+
+// Allergen is an entry of the allergen catalogue, e.g. peanuts or gluten, that ingredients are tagged with.
+type Allergen struct {
+	ID        uint      `gorm:"column:allergen_id;primaryKey"`
+	Name      string    `gorm:"column:name"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// IngredientDietaryInfo says which allergens an ingredient contains and whether vegetarians
+// and clients who eat halal can eat it.
+type IngredientDietaryInfo struct {
+	AllergenIDs  []uint
+	IsVegetarian bool
+	IsHalal      bool
+}
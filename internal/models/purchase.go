@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"Canteen-Backend/pkg/money"
+	"time"
+)
 
 type Supplier struct {
 	ID   uint   `gorm:"column:supplier_id"`
@@ -11,7 +14,7 @@ type Purchase struct {
 	ID                   uint                   `gorm:"column:purchase_id"`
 	PurchaseDate         time.Time              `gorm:"column:purchase_date"`
 	SupplierID           uint                   `gorm:"column:supplier_id"`
-	TotalSum             float64                `gorm:"column:total_sum"`
+	TotalSum             money.Money            `gorm:"column:total_sum"`
 	PurchasedIngredients []PurchasedIngredients `gorm:"-"`
 }
 
@@ -19,15 +22,15 @@ type PurchasedIngredients struct {
 	ID             uint
 	Name           string
 	Amount         float64
-	Cost           float64
+	Cost           money.Money
 	ExpirationDate time.Time
 }
 
 // todo add here amount, cost, current_unit_price
 type PurchasesIngredients struct {
-	PurchaseID       uint    `gorm:"column:purchase_id"`
-	IngredientID     uint    `gorm:"column:ingredient_id"`
-	Amount           float64 `gorm:"column:amount"`
-	Cost             float64 `gorm:"column:cost"`
-	CurrentUnitPrice float64 `gorm:"column:current_unit_price"`
+	PurchaseID       uint        `gorm:"column:purchase_id"`
+	IngredientID     uint        `gorm:"column:ingredient_id"`
+	Amount           float64     `gorm:"column:amount"`
+	Cost             money.Money `gorm:"column:cost"`
+	CurrentUnitPrice money.Money `gorm:"column:current_unit_price"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"Canteen-Backend/pkg/money"
+	"time"
+)
+
+// Statement is a client's account over a period, from From up to, not including, To: the balance the period opened
+// with, every balance transaction in it, oldest first, and the balance it closed with.
+type Statement struct {
+	Client             Client
+	ClientCategoryName string
+	From               time.Time
+	To                 time.Time
+	OpeningBalance     money.Money
+	Transactions       []BalanceTransaction
+	ClosingBalance     money.Money
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"Canteen-Backend/pkg/money"
+	"time"
+)
+
+// TopUp is a top-up of a client's balance paid online through the payment provider. The client pays on the
+// provider's checkout page, and the provider's webhook reports the payment's outcome. The balance is credited
+// by the top-up transaction BalanceTransactionID, made once per ProviderTransactionID.
+type TopUp struct {
+	ID                    uint        `gorm:"column:top_up_id;primaryKey"`
+	ClientID              uint        `gorm:"column:client_id"`
+	Amount                money.Money `gorm:"column:amount"`
+	Status                string      `gorm:"column:status"`
+	Provider              string      `gorm:"column:provider"`
+	ProviderPaymentID     *string     `gorm:"column:provider_payment_id"`
+	ProviderTransactionID *string     `gorm:"column:provider_transaction_id"`
+	CheckoutURL           string      `gorm:"column:checkout_url"`
+	BalanceTransactionID  *uint       `gorm:"column:balance_transaction_id"`
+	UserID                *uint       `gorm:"column:user_id"`
+	APIKeyID              *uint       `gorm:"column:api_key_id"`
+	CreatedAt             time.Time   `gorm:"column:created_at"`
+	UpdatedAt             time.Time   `gorm:"column:updated_at"`
+}
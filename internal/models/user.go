@@ -16,11 +16,92 @@ type User struct {
 	FirstName  string    `gorm:"column:first_name"`
 	LastName   string    `gorm:"column:last_name"`
 	IsActive   bool      `gorm:"column:is_active"`
+
+	FailedSignInAttempts int        `gorm:"column:failed_sign_in_attempts"`
+	LastFailedSignInAt   *time.Time `gorm:"column:last_failed_sign_in_at"`
+	LockedUntil          *time.Time `gorm:"column:locked_until"`
+
+	// TOTPSecret is set while two-factor authentication is being enrolled or enabled
+	TOTPSecret       string `gorm:"column:totp_secret"`
+	TOTPEnabled      bool   `gorm:"column:totp_enabled"`
+	TOTPLastUsedStep *int64 `gorm:"column:totp_last_used_step"`
+
+	// MustChangePassword makes the user choose a new password before the next sign-in completes
+	MustChangePassword bool `gorm:"column:must_change_password"`
+}
+
+// RecoveryCode is a single-use code that replaces a TOTP code when the authenticator is lost.
+type RecoveryCode struct {
+	ID        uint       `gorm:"column:user_recovery_code_id;primaryKey"`
+	UserID    uint       `gorm:"column:user_id"`
+	CodeHash  string     `gorm:"column:code_hash"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+}
+
+// PasswordResetToken is a single-use token emailed to a user who forgot the password.
+type PasswordResetToken struct {
+	ID        uint       `gorm:"column:password_reset_token_id;primaryKey"`
+	UserID    uint       `gorm:"column:user_id"`
+	TokenHash string     `gorm:"column:token_hash"`
+	ExpiresAt time.Time  `gorm:"column:expires_at"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+}
+
+// OIDCState is a pending OpenID Connect sign-in, from the redirect to the provider until its callback.
+type OIDCState struct {
+	ID           uint       `gorm:"column:oidc_state_id;primaryKey"`
+	StateHash    string     `gorm:"column:state_hash"`
+	Nonce        string     `gorm:"column:nonce"`
+	CodeVerifier string     `gorm:"column:code_verifier"`
+	ExpiresAt    time.Time  `gorm:"column:expires_at"`
+	CreatedAt    time.Time  `gorm:"column:created_at"`
+	UsedAt       *time.Time `gorm:"column:used_at"`
+}
+
+// UserIdentity links a user to the subject of an OpenID Connect provider the user signs in with.
+type UserIdentity struct {
+	ID           uint       `gorm:"column:user_identity_id;primaryKey"`
+	UserID       uint       `gorm:"column:user_id"`
+	Issuer       string     `gorm:"column:issuer"`
+	Subject      string     `gorm:"column:subject"`
+	CreatedAt    time.Time  `gorm:"column:created_at"`
+	LastSignInAt *time.Time `gorm:"column:last_sign_in_at"`
+}
+
+// APIKey authenticates a machine client, such as a kiosk or a POS terminal, with the permissions of a role.
+// Only the hash of the key is stored, Prefix is kept to tell keys apart.
+type APIKey struct {
+	ID         uint   `gorm:"column:api_key_id;primaryKey"`
+	Name       string `gorm:"column:name"`
+	Prefix     string `gorm:"column:prefix"`
+	KeyHash    string `gorm:"column:key_hash"`
+	UserRoleID uint   `gorm:"column:user_role_id"`
+	// AllowedIPs is a comma separated list of IP addresses and CIDR ranges the key can be used from, empty allows any
+	AllowedIPs string     `gorm:"column:allowed_ips"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	LastUsedIP *string    `gorm:"column:last_used_ip"`
+	CreatedBy  *uint      `gorm:"column:created_by"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
 }
 
 type UserRole struct {
-	ID   uint   `gorm:"column:user_role_id;primaryKey" json:"id"`
-	Name string `gorm:"column:name;unique" json:"name"`
+	ID          uint      `gorm:"column:user_role_id;primaryKey" json:"id"`
+	Name        string    `gorm:"column:name;unique" json:"name"`
+	Description string    `gorm:"column:description" json:"description"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updated_at"`
+	// Permissions are the names of the permissions granted to the role, stored in the role_permission table
+	Permissions []string `gorm:"-" json:"permissions"`
+}
+
+type Permission struct {
+	ID          uint   `gorm:"column:permission_id;primaryKey" json:"id"`
+	Name        string `gorm:"column:name;unique" json:"name"`
+	Description string `gorm:"column:description" json:"description"`
 }
 
 type Token struct {
@@ -28,9 +109,52 @@ type Token struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// SignInResult is the outcome of a sign-in step: either a token pair or, when a second factor
+// or a new password is still missing, a short-lived challenge token to continue the sign-in with.
+type SignInResult struct {
+	*Token
+	TwoFactorRequired      bool     `json:"two_factor_required,omitempty"`
+	TwoFactorSetupRequired bool     `json:"two_factor_setup_required,omitempty"`
+	PasswordChangeRequired bool     `json:"password_change_required,omitempty"`
+	ChallengeToken         string   `json:"challenge_token,omitempty"`
+	RecoveryCodes          []string `json:"recovery_codes,omitempty"`
+}
+
+// TwoFactorEnrollment is what an authenticator app needs to be set up with a new TOTP secret.
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	QRCode          string `json:"qr_code"`
+}
+
+// Session is one refresh token. Tokens rotated from the same sign-in share a FamilyID,
+// which is what the API exposes as the session id; rotated tokens are kept so that
+// presenting one again can be detected as reuse.
 type Session struct {
-	ID           uint      `gorm:"column:session_id;primaryKey"`
-	UserID       uint      `gorm:"column:user_id"`
-	RefreshToken string    `gorm:"column:refresh_token"`
-	ExpiresAt    time.Time `gorm:"column:expires_at"`
+	ID               uint       `gorm:"column:session_id;primaryKey"`
+	UserID           uint       `gorm:"column:user_id"`
+	FamilyID         string     `gorm:"column:family_id"`
+	RefreshTokenHash string     `gorm:"column:refresh_token_hash"`
+	IPAddress        string     `gorm:"column:ip_address"`
+	UserAgent        string     `gorm:"column:user_agent"`
+	SignedInAt       time.Time  `gorm:"column:signed_in_at"`
+	LastUsedAt       time.Time  `gorm:"column:last_used_at"`
+	ExpiresAt        time.Time  `gorm:"column:expires_at"`
+	CreatedAt        time.Time  `gorm:"column:created_at"`
+	RotatedAt        *time.Time `gorm:"column:rotated_at"`
+	RevokedAt        *time.Time `gorm:"column:revoked_at"`
+}
+
+type SignInAttempt struct {
+	ID           uint      `gorm:"column:sign_in_attempt_id;primaryKey"`
+	Username     string    `gorm:"column:username"`
+	IPAddress    string    `gorm:"column:ip_address"`
+	IsSuccessful bool      `gorm:"column:is_successful"`
+	CreatedAt    time.Time `gorm:"column:created_at"`
+}
+
+// SessionMetadata describes the device a session is used from.
+type SessionMetadata struct {
+	IPAddress string
+	UserAgent string
 }
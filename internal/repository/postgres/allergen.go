@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+func (r *IngredientPostgres) CreateAllergen(allergen *models.Allergen) (uint, error) {
+	result := r.db.Table(constants.AllergenTableName).Create(allergen)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return allergen.ID, nil
+}
+
+func (r *IngredientPostgres) GetAllAllergens() (*[]models.Allergen, error) {
+	var allergens []models.Allergen
+	result := r.db.Table(constants.AllergenTableName).Order("name").Find(&allergens)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &allergens, nil
+}
+
+// GetAllergensByIDs returns the allergens with any of the IDs. IDs of allergens that do not exist are skipped.
+func (r *IngredientPostgres) GetAllergensByIDs(ids []uint) (*[]models.Allergen, error) {
+	var allergens []models.Allergen
+	result := r.db.Table(constants.AllergenTableName).Where("allergen_id IN ?", ids).Find(&allergens)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &allergens, nil
+}
+
+func (r *IngredientPostgres) UpdateAllergen(allergen *models.Allergen) error {
+	result := r.db.Table(constants.AllergenTableName).Where("allergen_id = ?", allergen.ID).Update("name", allergen.Name)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteAllergen deletes the allergen, and with it, the ingredients' and clients' tags of it.
+func (r *IngredientPostgres) DeleteAllergen(id uint) error {
+	result := r.db.Table(constants.AllergenTableName).Delete(&models.Allergen{}, "allergen_id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
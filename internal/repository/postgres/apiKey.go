@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type APIKeyPostgres struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyPostgres(db *gorm.DB) *APIKeyPostgres {
+	return &APIKeyPostgres{db: db}
+}
+
+func (r *APIKeyPostgres) CreateAPIKey(apiKey *models.APIKey) (uint, error) {
+	result := r.db.Table(constants.APIKeyTableName).Create(apiKey)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return apiKey.ID, nil
+}
+
+func (r *APIKeyPostgres) GetAllAPIKeys() (*[]models.APIKey, error) {
+	var apiKeys []models.APIKey
+	result := r.db.Table(constants.APIKeyTableName).Order("api_key_id").Find(&apiKeys)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &apiKeys, nil
+}
+
+func (r *APIKeyPostgres) GetAPIKeyByID(id uint) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	result := r.db.Table(constants.APIKeyTableName).First(&apiKey, "api_key_id = ?", id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &apiKey, nil
+}
+
+func (r *APIKeyPostgres) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	result := r.db.Table(constants.APIKeyTableName).First(&apiKey, "key_hash = ?", keyHash)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &apiKey, nil
+}
+
+// RevokeAPIKey revokes the key. It returns gorm.ErrRecordNotFound if there is no such key
+// or it has already been revoked.
+func (r *APIKeyPostgres) RevokeAPIKey(id uint) error {
+	result := r.db.Table(constants.APIKeyTableName).
+		Where("api_key_id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *APIKeyPostgres) UpdateAPIKeyLastUsed(id uint, ipAddress string) error {
+	result := r.db.Table(constants.APIKeyTableName).Where("api_key_id = ?", id).Updates(map[string]interface{}{
+		"last_used_at": time.Now(),
+		"last_used_ip": ipAddress,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
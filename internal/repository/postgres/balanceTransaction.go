@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/money"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// CreateBalanceTransaction applies the transaction to the client's balance and records it in the ledger,
+// in one database transaction. With checkCreditLimit, a debit past the credit limit is refused with
+// customErr.CreditLimitExceeded. A reused idempotency key returns the earlier transaction instead.
+func (r *ClientPostgres) CreateBalanceTransaction(transaction *models.BalanceTransaction, checkCreditLimit bool) (*models.BalanceTransaction, error) {
+	if transaction.IdempotencyKey != nil {
+		existing, err := r.GetBalanceTransactionByIdempotencyKey(transaction.ClientID, *transaction.IdempotencyKey)
+		if err == nil {
+			return existing, nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return createBalanceTransaction(tx, transaction, checkCreditLimit)
+	})
+	if err != nil {
+		// a concurrent request with the same idempotency key won, and this one was rolled back. If the
+		// winner cannot be read, e.g. it has not committed yet, the duplicate key is reported as it is
+		if ok, _ := customErr.IsDuplicateKeyError(err); ok && transaction.IdempotencyKey != nil {
+			if existing, readErr := r.GetBalanceTransactionByIdempotencyKey(transaction.ClientID, *transaction.IdempotencyKey); readErr == nil {
+				return existing, nil
+			}
+		}
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// createBalanceTransaction applies the transaction to the client's balance and records it in the ledger,
+// within the database transaction tx.
+func createBalanceTransaction(tx *gorm.DB, transaction *models.BalanceTransaction, checkCreditLimit bool) error {
+	query := `UPDATE client SET balance = balance + ?, updated_at = ? WHERE client_id = ?`
+	args := []interface{}{transaction.Amount, time.Now(), transaction.ClientID}
+	if checkCreditLimit && transaction.Amount < 0 {
+		query += ` AND balance + ? >= -COALESCE(credit_limit,
+			(SELECT credit_limit FROM client_category WHERE client_category.client_category_id = client.client_category_id), 0)`
+		args = append(args, transaction.Amount)
+	}
+
+	var clients []models.Client
+	result := tx.Raw(query+` RETURNING balance`, args...).Scan(&clients)
+	if result.Error != nil {
+		return result.Error
+	} else if len(clients) == 0 {
+		if err := tx.Table(constants.ClientTableName).First(&models.Client{}, "client_id = ?", transaction.ClientID).Error; err != nil {
+			return err
+		}
+		return customErr.CreditLimitExceeded
+	}
+
+	transaction.BalanceAfter = clients[0].Balance
+	if err := tx.Table(constants.BalanceTransactionTableName).Create(transaction).Error; err != nil {
+		return err
+	}
+
+	if len(transaction.Items) > 0 {
+		for i := range transaction.Items {
+			transaction.Items[i].BalanceTransactionID = transaction.ID
+		}
+		if err := tx.Table(constants.BalanceTransactionItemTableName).Create(&transaction.Items).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(transaction.DietaryOverrides) == 0 {
+		return nil
+	}
+	for i := range transaction.DietaryOverrides {
+		transaction.DietaryOverrides[i].BalanceTransactionID = transaction.ID
+	}
+	return tx.Table(constants.DietaryOverrideTableName).Create(&transaction.DietaryOverrides).Error
+}
+
+func (r *ClientPostgres) GetBalanceTransactionByIdempotencyKey(clientID uint, idempotencyKey string) (*models.BalanceTransaction, error) {
+	var transaction models.BalanceTransaction
+	result := r.db.Table(constants.BalanceTransactionTableName).First(&transaction, "client_id = ? AND idempotency_key = ?", clientID, idempotencyKey)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &transaction, nil
+}
+
+// GetBalanceTransactionsByClientID returns the client's ledger, most recent transactions first.
+func (r *ClientPostgres) GetBalanceTransactionsByClientID(clientID uint, filter *models.BalanceTransactionFilter) (*[]models.BalanceTransaction, error) {
+	query := r.db.Table(constants.BalanceTransactionTableName).Where("client_id = ?", clientID)
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	var transactions []models.BalanceTransaction
+	result := query.Order("created_at DESC, balance_transaction_id DESC").Find(&transactions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &transactions, nil
+}
+
+// GetSubsidySince returns how much subsidy the client's purchases since the given time were granted.
+func (r *ClientPostgres) GetSubsidySince(clientID uint, since time.Time) (money.Money, error) {
+	var subsidy money.Money
+	err := r.db.Table(constants.BalanceTransactionTableName).Select("COALESCE(SUM(subsidy), 0)").
+		Where("client_id = ? AND created_at >= ?", clientID, since).Row().Scan(&subsidy)
+	if err != nil {
+		return 0, err
+	}
+
+	return subsidy, nil
+}
+
+// GetAvailableBalance returns how much the client can spend: the balance plus the client's credit limit, or their category's.
+func (r *ClientPostgres) GetAvailableBalance(clientID uint) (money.Money, error) {
+	var available money.Money
+	err := r.db.Raw(`SELECT client.balance + COALESCE(client.credit_limit, client_category.credit_limit, 0) FROM client
+		LEFT JOIN client_category ON client_category.client_category_id = client.client_category_id WHERE client.client_id = ?`, clientID).
+		Row().Scan(&available)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, gorm.ErrRecordNotFound
+	} else if err != nil {
+		return 0, err
+	}
+
+	return available, nil
+}
+
+// GetSpendingSince returns how much the client spent on purchases since the given time, net of refunds.
+func (r *ClientPostgres) GetSpendingSince(clientID uint, since time.Time) (money.Money, error) {
+	var spending money.Money
+	err := r.db.Table(constants.BalanceTransactionTableName).Select("COALESCE(-SUM(amount), 0)").
+		Where("client_id = ? AND created_at >= ? AND type IN ?", clientID, since,
+			[]string{constants.BalanceTransactionPurchase, constants.BalanceTransactionRefund}).Row().Scan(&spending)
+	if err != nil {
+		return 0, err
+	}
+
+	return spending, nil
+}
+
+// GetBalanceBefore returns the client's balance just before the given time, zero if the client had no transactions by then.
+func (r *ClientPostgres) GetBalanceBefore(clientID uint, before time.Time) (money.Money, error) {
+	var balance money.Money
+	err := r.db.Table(constants.BalanceTransactionTableName).Select("balance_after").
+		Where("client_id = ? AND created_at < ?", clientID, before).
+		Order("created_at DESC, balance_transaction_id DESC").Limit(1).Row().Scan(&balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
@@ -3,7 +3,11 @@ package postgres
 import (
 	"Canteen-Backend/internal/constants"
 	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/money"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
 )
 
 type ClientPostgres struct {
@@ -14,28 +18,142 @@ func NewClientPostgres(db *gorm.DB) *ClientPostgres {
 	return &ClientPostgres{db: db}
 }
 
-func (r *ClientPostgres) CreateClient(client *models.Client) (uint, error) {
-	result := r.db.Table(constants.ClientTableName).Create(client)
-	if result.Error != nil {
-		return 0, result.Error
+// WithClientLock runs fn in a database transaction that holds the client's lock, with a ClientPostgres
+// bound to the transaction, see lockClient. The transaction is rolled back if fn returns an error.
+func (r *ClientPostgres) WithClientLock(clientID uint, fn func(tx *ClientPostgres) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockClient(tx, clientID); err != nil {
+			return err
+		}
+
+		return fn(&ClientPostgres{db: tx})
+	})
+}
+
+// CreateClient creates the client and, unless openingBalance is nil, records the client's initial balance in the ledger.
+func (r *ClientPostgres) CreateClient(client *models.Client, openingBalance *models.BalanceTransaction) (uint, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return createClient(tx, client, openingBalance)
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return client.ID, nil
 }
 
-func (r *ClientPostgres) GetAllClients() (*[]models.Client, error) {
+// clientBatchSize is how many rows CreateClients inserts and GetClientsByEmails looks up per statement.
+const clientBatchSize = 500
+
+// CreateClients creates all clients in one transaction, so that either all or none of them are created.
+// openingBalances[i] is the opening balance of clients[i], or nil.
+func (r *ClientPostgres) CreateClients(clients []*models.Client, openingBalances []*models.BalanceTransaction) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(constants.ClientTableName).CreateInBatches(clients, clientBatchSize).Error; err != nil {
+			return err
+		}
+
+		var balances []*models.BalanceTransaction
+		for i, client := range clients {
+			if openingBalances[i] != nil {
+				setOpeningBalance(client, openingBalances[i])
+				balances = append(balances, openingBalances[i])
+			}
+		}
+		if len(balances) == 0 {
+			return nil
+		}
+		return tx.Table(constants.BalanceTransactionTableName).CreateInBatches(balances, clientBatchSize).Error
+	})
+}
+
+func createClient(tx *gorm.DB, client *models.Client, openingBalance *models.BalanceTransaction) error {
+	if err := tx.Table(constants.ClientTableName).Create(client).Error; err != nil {
+		return err
+	}
+
+	if openingBalance == nil {
+		return nil
+	}
+	setOpeningBalance(client, openingBalance)
+	return tx.Table(constants.BalanceTransactionTableName).Create(openingBalance).Error
+}
+
+// setOpeningBalance makes the transaction record the created client's initial balance.
+func setOpeningBalance(client *models.Client, openingBalance *models.BalanceTransaction) {
+	openingBalance.ClientID = client.ID
+	openingBalance.Amount = client.Balance
+	openingBalance.BalanceAfter = client.Balance
+}
+
+// GetClients returns a page of the clients that match the filter and the number of all matching clients.
+// The client ID breaks ties in the sort order, so that pages neither overlap nor skip clients.
+func (r *ClientPostgres) GetClients(filter *models.ClientFilter) (*[]models.Client, int64, error) {
+	query := r.db.Table(constants.ClientTableName)
+	if filter.Name != "" {
+		pattern := "%" + escapeLike(filter.Name) + "%"
+		query = query.Where("first_name ILIKE ? OR last_name ILIKE ? OR first_name || ' ' || last_name ILIKE ?", pattern, pattern, pattern)
+	}
+	if filter.Email != "" {
+		query = query.Where("email ILIKE ?", "%"+escapeLike(filter.Email)+"%")
+	}
+	if filter.ClientCategoryID != 0 {
+		query = query.Where("client_category_id = ?", filter.ClientCategoryID)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+	if filter.MinBalance != nil {
+		query = query.Where("balance >= ?", *filter.MinBalance)
+	}
+	if filter.MaxBalance != nil {
+		query = query.Where("balance <= ?", *filter.MaxBalance)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	for _, sort := range filter.Sort {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: constants.ClientSortColumns[sort.Field]}, Desc: sort.Descending})
+	}
+
 	var clients []models.Client
-	result := r.db.Table(constants.ClientTableName).Find(&clients)
+	result := query.Order("client_id").Limit(filter.Limit).Offset(filter.Offset).Find(&clients)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, 0, result.Error
+	}
+
+	return &clients, total, nil
+}
+
+// GetClientsByEmails returns the clients with any of the email addresses, ignoring case.
+func (r *ClientPostgres) GetClientsByEmails(emails []string) (*[]models.Client, error) {
+	lowerEmails := make([]string, len(emails))
+	for i, email := range emails {
+		lowerEmails[i] = strings.ToLower(email)
+	}
+
+	var clients []models.Client
+	for start := 0; start < len(lowerEmails); start += clientBatchSize {
+		var batch []models.Client
+		result := r.db.Table(constants.ClientTableName).Where("lower(email) IN ?", lowerEmails[start:min(start+clientBatchSize, len(lowerEmails))]).Find(&batch)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		clients = append(clients, batch...)
 	}
 
 	return &clients, nil
 }
 
-func (r *ClientPostgres) GetAllClientsByCategoryID(clientCategoryID uint) (*[]models.Client, error) {
+// GetClientsByCategoryID returns the clients of the category, active or not, ordered by name.
+func (r *ClientPostgres) GetClientsByCategoryID(clientCategoryID uint) (*[]models.Client, error) {
 	var clients []models.Client
-	result := r.db.Table(constants.ClientTableName).Find(&clients, "client_category_id = ?", clientCategoryID)
+	result := r.db.Table(constants.ClientTableName).Where("client_category_id = ?", clientCategoryID).
+		Order("last_name, first_name, client_id").Find(&clients)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -43,6 +161,11 @@ func (r *ClientPostgres) GetAllClientsByCategoryID(clientCategoryID uint) (*[]mo
 	return &clients, nil
 }
 
+// escapeLike escapes the wildcards of a LIKE pattern, so that the value is matched literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
 func (r *ClientPostgres) GetClientByID(id uint) (*models.Client, error) {
 	var client models.Client
 	result := r.db.Table(constants.ClientTableName).First(&client, "client_id = ?", id)
@@ -63,6 +186,36 @@ func (r *ClientPostgres) UpdateClient(client *models.Client) error {
 	return nil
 }
 
+// SetClientCreditLimit sets the client's own credit limit, or with nil, makes the client's category's limit apply.
+func (r *ClientPostgres) SetClientCreditLimit(clientID uint, creditLimit *money.Money) error {
+	result := r.db.Table(constants.ClientTableName).Where("client_id = ?", clientID).
+		Updates(map[string]interface{}{"credit_limit": creditLimit, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// SetClientSpendingLimits replaces the client's spending limits, nil limits are removed.
+func (r *ClientPostgres) SetClientSpendingLimits(clientID uint, limits *models.SpendingLimits) error {
+	result := r.db.Table(constants.ClientTableName).Where("client_id = ?", clientID).Updates(map[string]interface{}{
+		"daily_spending_limit":       limits.Daily,
+		"weekly_spending_limit":      limits.Weekly,
+		"transaction_spending_limit": limits.Transaction,
+		"updated_at":                 time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
 func (r *ClientPostgres) DeleteClient(id uint) error {
 	result := r.db.Table(constants.ClientTableName).Delete(&models.Client{}, "client_id = ?", id)
 	if result.Error != nil {
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// CreateClientCategoryPricing creates the pricing rules together with their price list.
+func (r *ClientPostgres) CreateClientCategoryPricing(pricing *models.ClientCategoryPricing) (uint, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(constants.ClientCategoryPricingTableName).Create(pricing).Error; err != nil {
+			return err
+		}
+
+		if len(pricing.Prices) == 0 {
+			return nil
+		}
+		for i := range pricing.Prices {
+			pricing.Prices[i].ClientCategoryPricingID = pricing.ID
+		}
+		return tx.Table(constants.ClientCategoryPriceTableName).Create(&pricing.Prices).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return pricing.ID, nil
+}
+
+// GetClientCategoryPricings returns every version of the category's pricing rules, the latest first, with their price lists.
+func (r *ClientPostgres) GetClientCategoryPricings(clientCategoryID uint) (*[]models.ClientCategoryPricing, error) {
+	var pricings []models.ClientCategoryPricing
+	result := r.db.Table(constants.ClientCategoryPricingTableName).Where("client_category_id = ?", clientCategoryID).
+		Order("effective_from DESC").Find(&pricings)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for i := range pricings {
+		if err := r.loadClientCategoryPrices(&pricings[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return &pricings, nil
+}
+
+func (r *ClientPostgres) GetClientCategoryPricingByID(id uint) (*models.ClientCategoryPricing, error) {
+	var pricing models.ClientCategoryPricing
+	result := r.db.Table(constants.ClientCategoryPricingTableName).First(&pricing, "client_category_pricing_id = ?", id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if err := r.loadClientCategoryPrices(&pricing); err != nil {
+		return nil, err
+	}
+
+	return &pricing, nil
+}
+
+// GetClientCategoryPricingAt returns the version of the category's pricing rules that is in effect at the given time.
+func (r *ClientPostgres) GetClientCategoryPricingAt(clientCategoryID uint, at time.Time) (*models.ClientCategoryPricing, error) {
+	var pricing models.ClientCategoryPricing
+	result := r.db.Table(constants.ClientCategoryPricingTableName).
+		Where("client_category_id = ? AND effective_from <= ?", clientCategoryID, at).
+		Order("effective_from DESC").First(&pricing)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if err := r.loadClientCategoryPrices(&pricing); err != nil {
+		return nil, err
+	}
+
+	return &pricing, nil
+}
+
+func (r *ClientPostgres) loadClientCategoryPrices(pricing *models.ClientCategoryPricing) error {
+	return r.db.Table(constants.ClientCategoryPriceTableName).Where("client_category_pricing_id = ?", pricing.ID).
+		Order("dish_id").Find(&pricing.Prices).Error
+}
+
+// DeleteClientCategoryPricing deletes a version of pricing rules that has not come into effect yet.
+// Versions that are or were in effect explain past charges and are kept.
+func (r *ClientPostgres) DeleteClientCategoryPricing(id uint, now time.Time) error {
+	result := r.db.Table(constants.ClientCategoryPricingTableName).
+		Delete(&models.ClientCategoryPricing{}, "client_category_pricing_id = ? AND effective_from > ?", id, now)
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
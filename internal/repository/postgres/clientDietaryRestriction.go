@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+func (r *ClientPostgres) GetClientDietaryRestrictions(clientID uint) (*models.DietaryRestrictions, error) {
+	var client struct {
+		Vegetarian bool `gorm:"column:vegetarian"`
+		Halal      bool `gorm:"column:halal"`
+	}
+	result := r.db.Table(constants.ClientTableName).Select("vegetarian", "halal").First(&client, "client_id = ?", clientID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	restrictions := &models.DietaryRestrictions{Vegetarian: client.Vegetarian, Halal: client.Halal, AllergenIDs: []uint{}}
+	result = r.db.Table(constants.ClientAllergenTableName).Where("client_id = ?", clientID).
+		Order("allergen_id").Pluck("allergen_id", &restrictions.AllergenIDs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return restrictions, nil
+}
+
+// SetClientDietaryRestrictions replaces the client's allergies and diets.
+func (r *ClientPostgres) SetClientDietaryRestrictions(clientID uint, restrictions *models.DietaryRestrictions) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(constants.ClientTableName).Where("client_id = ?", clientID).
+			Updates(map[string]interface{}{"vegetarian": restrictions.Vegetarian, "halal": restrictions.Halal, "updated_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Exec("DELETE FROM client_allergen WHERE client_id = ?", clientID).Error; err != nil {
+			return err
+		}
+		for _, allergenID := range restrictions.AllergenIDs {
+			if err := tx.Exec("INSERT INTO client_allergen (client_id, allergen_id) VALUES (?, ?)", clientID, allergenID).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetDietaryOverridesByClientID returns the allergies cashiers overrode in the client's purchases, most recent first.
+func (r *ClientPostgres) GetDietaryOverridesByClientID(clientID uint) (*[]models.DietaryOverride, error) {
+	var overrides []models.DietaryOverride
+	result := r.db.Table(constants.DietaryOverrideTableName).
+		Joins("JOIN balance_transaction ON balance_transaction.balance_transaction_id = dietary_override.balance_transaction_id").
+		Where("balance_transaction.client_id = ?", clientID).
+		Order("dietary_override.created_at DESC, dietary_override.dietary_override_id DESC").
+		Select("dietary_override.*").Find(&overrides)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &overrides, nil
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// CreateClientIdentifier issues the identifier and records the event in its history.
+func (r *ClientPostgres) CreateClientIdentifier(identifier *models.ClientIdentifier, event *models.ClientIdentifierEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(constants.ClientIdentifierTableName).Create(identifier).Error; err != nil {
+			return err
+		}
+
+		event.ClientIdentifierID = identifier.ID
+		return tx.Table(constants.ClientIdentifierEventTableName).Create(event).Error
+	})
+}
+
+func (r *ClientPostgres) GetClientIdentifierByID(id uint) (*models.ClientIdentifier, error) {
+	var identifier models.ClientIdentifier
+	result := r.db.Table(constants.ClientIdentifierTableName).First(&identifier, "client_identifier_id = ?", id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &identifier, nil
+}
+
+func (r *ClientPostgres) GetClientIdentifierByValue(identifierType, value string) (*models.ClientIdentifier, error) {
+	var identifier models.ClientIdentifier
+	result := r.db.Table(constants.ClientIdentifierTableName).First(&identifier, "type = ? AND value = ?", identifierType, value)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &identifier, nil
+}
+
+// GetClientIdentifiersByClientID returns the client's identifiers, most recently issued first.
+func (r *ClientPostgres) GetClientIdentifiersByClientID(clientID uint) (*[]models.ClientIdentifier, error) {
+	var identifiers []models.ClientIdentifier
+	result := r.db.Table(constants.ClientIdentifierTableName).Where("client_id = ?", clientID).
+		Order("created_at DESC, client_identifier_id DESC").Find(&identifiers)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &identifiers, nil
+}
+
+// UpdateClientIdentifierStatus sets the identifier's status and records the event in its history.
+func (r *ClientPostgres) UpdateClientIdentifierStatus(identifier *models.ClientIdentifier, event *models.ClientIdentifierEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(constants.ClientIdentifierTableName).Where("client_identifier_id = ?", identifier.ID).
+			Updates(map[string]interface{}{"status": identifier.Status, "updated_at": identifier.UpdatedAt})
+		if result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		event.ClientIdentifierID = identifier.ID
+		return tx.Table(constants.ClientIdentifierEventTableName).Create(event).Error
+	})
+}
+
+// ReplaceClientIdentifier issues the replacement and retires the identifier it replaces with the identifier's
+// new status, recording both events. It returns gorm.ErrRecordNotFound if the identifier was replaced already.
+func (r *ClientPostgres) ReplaceClientIdentifier(identifier, replacement *models.ClientIdentifier, event, replacementEvent *models.ClientIdentifierEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(constants.ClientIdentifierTableName).Create(replacement).Error; err != nil {
+			return err
+		}
+
+		result := tx.Table(constants.ClientIdentifierTableName).Where("client_identifier_id = ? AND replaced_by_id IS NULL", identifier.ID).
+			Updates(map[string]interface{}{"status": identifier.Status, "replaced_by_id": replacement.ID, "updated_at": identifier.UpdatedAt})
+		if result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		identifier.ReplacedByID = &replacement.ID
+
+		event.ClientIdentifierID = identifier.ID
+		replacementEvent.ClientIdentifierID = replacement.ID
+		return tx.Table(constants.ClientIdentifierEventTableName).Create([]*models.ClientIdentifierEvent{event, replacementEvent}).Error
+	})
+}
+
+// GetClientIdentifierEvents returns the identifier's history, most recent events first.
+func (r *ClientPostgres) GetClientIdentifierEvents(identifierID uint) (*[]models.ClientIdentifierEvent, error) {
+	var events []models.ClientIdentifierEvent
+	result := r.db.Table(constants.ClientIdentifierEventTableName).Where("client_identifier_id = ?", identifierID).
+		Order("created_at DESC, client_identifier_event_id DESC").Find(&events)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &events, nil
+}
@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type DishPostgres struct {
+	db *gorm.DB
+}
+
+func NewDishPostgres(db *gorm.DB) *DishPostgres {
+	return &DishPostgres{db: db}
+}
+
+func (r *DishPostgres) CreateDish(dish *models.Dish) (uint, error) {
+	result := r.db.Table(constants.DishTableName).Create(dish)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return dish.ID, nil
+}
+
+func (r *DishPostgres) GetAllDishes() (*[]models.Dish, error) {
+	var dishes []models.Dish
+	result := r.db.Table(constants.DishTableName).Order("name").Find(&dishes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &dishes, nil
+}
+
+func (r *DishPostgres) GetDishByID(id uint) (*models.Dish, error) {
+	var dish models.Dish
+	result := r.db.Table(constants.DishTableName).First(&dish, "dish_id = ?", id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &dish, nil
+}
+
+// GetDishesByIDs returns the dishes with any of the IDs. IDs of dishes that do not exist are skipped.
+func (r *DishPostgres) GetDishesByIDs(ids []uint) (*[]models.Dish, error) {
+	var dishes []models.Dish
+	result := r.db.Table(constants.DishTableName).Where("dish_id IN ?", ids).Find(&dishes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &dishes, nil
+}
+
+// UpdateDish updates the dish's name, price and active flag. Zero values are written too, so that a dish can be deactivated.
+func (r *DishPostgres) UpdateDish(dish *models.Dish) error {
+	result := r.db.Table(constants.DishTableName).Where("dish_id = ?", dish.ID).
+		Select("name", "price", "is_active", "updated_at").Updates(dish)
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// SetDishIngredients replaces the ingredients the dish is made of.
+func (r *DishPostgres) SetDishIngredients(dishID uint, ingredientIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM dish_ingredient WHERE dish_id = ?", dishID).Error; err != nil {
+			return err
+		}
+		for _, ingredientID := range ingredientIDs {
+			if err := tx.Exec("INSERT INTO dish_ingredient (dish_id, ingredient_id) VALUES (?, ?)", dishID, ingredientID).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetDishDietaryInfo returns the dietary info of the dishes with any of the IDs, worked out from their ingredients.
+// IDs of dishes that do not exist are skipped.
+func (r *DishPostgres) GetDishDietaryInfo(dishIDs []uint) (*[]models.DishDietaryInfo, error) {
+	dishes, err := r.GetDishesByIDs(dishIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var ingredients []struct {
+		DishID       uint `gorm:"column:dish_id"`
+		IngredientID uint `gorm:"column:ingredient_id"`
+		IsVegetarian bool `gorm:"column:is_vegetarian"`
+		IsHalal      bool `gorm:"column:is_halal"`
+	}
+	result := r.db.Raw(`SELECT dish_ingredient.dish_id, ingredient.ingredient_id, ingredient.is_vegetarian, ingredient.is_halal
+		FROM dish_ingredient JOIN ingredient ON ingredient.ingredient_id = dish_ingredient.ingredient_id
+		WHERE dish_ingredient.dish_id IN ? ORDER BY ingredient.ingredient_id`, dishIDs).Scan(&ingredients)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var allergens []struct {
+		DishID     uint   `gorm:"column:dish_id"`
+		AllergenID uint   `gorm:"column:allergen_id"`
+		Name       string `gorm:"column:name"`
+	}
+	result = r.db.Raw(`SELECT DISTINCT dish_ingredient.dish_id, allergen.allergen_id, allergen.name
+		FROM dish_ingredient JOIN ingredient_allergen ON ingredient_allergen.ingredient_id = dish_ingredient.ingredient_id
+		JOIN allergen ON allergen.allergen_id = ingredient_allergen.allergen_id
+		WHERE dish_ingredient.dish_id IN ? ORDER BY allergen.name`, dishIDs).Scan(&allergens)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	infos := make([]models.DishDietaryInfo, len(*dishes))
+	infoByDishID := make(map[uint]*models.DishDietaryInfo, len(*dishes))
+	for i, dish := range *dishes {
+		infos[i] = models.DishDietaryInfo{DishID: dish.ID, DishName: dish.Name, IngredientIDs: []uint{}, Allergens: []models.Allergen{},
+			IsVegetarian: true, IsHalal: true}
+		infoByDishID[dish.ID] = &infos[i]
+	}
+	for _, ingredient := range ingredients {
+		info := infoByDishID[ingredient.DishID]
+		info.IngredientIDs = append(info.IngredientIDs, ingredient.IngredientID)
+		info.IsVegetarian = info.IsVegetarian && ingredient.IsVegetarian
+		info.IsHalal = info.IsHalal && ingredient.IsHalal
+	}
+	for _, allergen := range allergens {
+		info := infoByDishID[allergen.DishID]
+		info.Allergens = append(info.Allergens, models.Allergen{ID: allergen.AllergenID, Name: allergen.Name})
+	}
+
+	return &infos, nil
+}
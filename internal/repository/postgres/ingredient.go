@@ -123,3 +123,60 @@ func (r *IngredientPostgres) DeleteIngredient(id uint) error {
 
 	return nil
 }
+
+// GetIngredientsByIDs returns the ingredients with any of the IDs. IDs of ingredients that do not exist are skipped.
+func (r *IngredientPostgres) GetIngredientsByIDs(ids []uint) (*[]models.Ingredient, error) {
+	var ingredients []models.Ingredient
+	result := r.db.Table(constants.IngredientTableName).Where("ingredient_id IN ?", ids).Find(&ingredients)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &ingredients, nil
+}
+
+func (r *IngredientPostgres) GetIngredientDietaryInfo(id uint) (*models.IngredientDietaryInfo, error) {
+	var ingredient struct {
+		IsVegetarian bool `gorm:"column:is_vegetarian"`
+		IsHalal      bool `gorm:"column:is_halal"`
+	}
+	result := r.db.Table(constants.IngredientTableName).Select("is_vegetarian", "is_halal").First(&ingredient, "ingredient_id = ?", id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	info := &models.IngredientDietaryInfo{IsVegetarian: ingredient.IsVegetarian, IsHalal: ingredient.IsHalal, AllergenIDs: []uint{}}
+	result = r.db.Table(constants.IngredientAllergenTableName).Where("ingredient_id = ?", id).
+		Order("allergen_id").Pluck("allergen_id", &info.AllergenIDs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return info, nil
+}
+
+// SetIngredientDietaryInfo replaces the ingredient's allergens and diet flags.
+func (r *IngredientPostgres) SetIngredientDietaryInfo(id uint, info *models.IngredientDietaryInfo) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(constants.IngredientTableName).Where("ingredient_id = ?", id).
+			Updates(map[string]interface{}{"is_vegetarian": info.IsVegetarian, "is_halal": info.IsHalal})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Exec("DELETE FROM ingredient_allergen WHERE ingredient_id = ?", id).Error; err != nil {
+			return err
+		}
+		for _, allergenID := range info.AllergenIDs {
+			if err := tx.Exec("INSERT INTO ingredient_allergen (ingredient_id, allergen_id) VALUES (?, ?)", id, allergenID).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
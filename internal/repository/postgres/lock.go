@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/customErr"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LockPostgres struct {
+	db *gorm.DB
+}
+
+func NewLockPostgres(db *gorm.DB) *LockPostgres {
+	return &LockPostgres{db: db}
+}
+
+// WithAdvisoryLock runs fn while holding the Postgres advisory lock with the given key and reports
+// whether the lock was acquired. If another session holds the lock, fn is not run. The lock is taken
+// on a dedicated connection, since advisory locks belong to the connection that acquired them.
+func (r *LockPostgres) WithAdvisoryLock(key int64, fn func() error) (bool, error) {
+	var acquired bool
+	err := r.db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Row().Scan(&acquired); err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", key)
+
+		return fn()
+	})
+
+	return acquired, err
+}
+
+// clientLockTimeout is how long a change to a client's balance waits for a concurrent one to commit.
+const clientLockTimeout = "5s"
+
+// lockClient locks the client's row until the database transaction tx ends, so that changes to the
+// client's balance are applied one at a time. It returns customErr.ClientBusy if the lock is not
+// acquired within clientLockTimeout, and gorm.ErrRecordNotFound if the client does not exist.
+func lockClient(tx *gorm.DB, clientID uint) error {
+	if err := tx.Exec("SELECT set_config('lock_timeout', ?, true)", clientLockTimeout).Error; err != nil {
+		return err
+	}
+
+	err := tx.Table(constants.ClientTableName).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("client_id").First(&models.Client{}, "client_id = ?", clientID).Error
+	if err != nil && customErr.IsLockTimeoutError(err) {
+		return customErr.ClientBusy
+	}
+
+	return err
+}
@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type OIDCStatePostgres struct {
+	db *gorm.DB
+}
+
+func NewOIDCStatePostgres(db *gorm.DB) *OIDCStatePostgres {
+	return &OIDCStatePostgres{db: db}
+}
+
+func (r *OIDCStatePostgres) CreateOIDCState(state *models.OIDCState) error {
+	return r.db.Table(constants.OIDCStateTableName).Create(state).Error
+}
+
+// UseOIDCState marks the state as used and returns it, so that each sign-in can be finished only once.
+// It returns gorm.ErrRecordNotFound if there is no such state or it has expired or already been used.
+func (r *OIDCStatePostgres) UseOIDCState(stateHash string) (*models.OIDCState, error) {
+	var states []models.OIDCState
+	result := r.db.Raw(`UPDATE oidc_state SET used_at = ?
+		WHERE state_hash = ? AND used_at IS NULL AND expires_at > ?
+		RETURNING *`, time.Now(), stateHash, time.Now()).Scan(&states)
+	if result.Error != nil {
+		return nil, result.Error
+	} else if len(states) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &states[0], nil
+}
+
+// DeleteExpiredOIDCStates deletes the states that expired before the given time, whether or not they were used.
+func (r *OIDCStatePostgres) DeleteExpiredOIDCStates(before time.Time) (int64, error) {
+	result := r.db.Table(constants.OIDCStateTableName).Delete(&models.OIDCState{}, "expires_at < ?", before)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type PasswordResetTokenPostgres struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetTokenPostgres(db *gorm.DB) *PasswordResetTokenPostgres {
+	return &PasswordResetTokenPostgres{db: db}
+}
+
+// CreatePasswordResetToken stores the token and invalidates all earlier unused tokens of the user,
+// so that only the most recently emailed link works.
+func (r *PasswordResetTokenPostgres) CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(constants.PasswordResetTokenTableName).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Table(constants.PasswordResetTokenTableName).Create(token).Error
+	})
+}
+
+// UsePasswordResetToken marks the token as used and returns the user it was issued for.
+// It returns gorm.ErrRecordNotFound if there is no such token or it has expired or already been used.
+func (r *PasswordResetTokenPostgres) UsePasswordResetToken(tokenHash string) (uint, error) {
+	var userIDs []uint
+	result := r.db.Raw(`UPDATE password_reset_token SET used_at = ?
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?
+		RETURNING user_id`, time.Now(), tokenHash, time.Now()).Scan(&userIDs)
+	if result.Error != nil {
+		return 0, result.Error
+	} else if len(userIDs) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+
+	return userIDs[0], nil
+}
+
+// DeleteExpiredPasswordResetTokens deletes the tokens that expired before the given time, whether or not they were used.
+func (r *PasswordResetTokenPostgres) DeleteExpiredPasswordResetTokens(before time.Time) (int64, error) {
+	result := r.db.Table(constants.PasswordResetTokenTableName).Delete(&models.PasswordResetToken{}, "expires_at < ?", before)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type RecoveryCodePostgres struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodePostgres(db *gorm.DB) *RecoveryCodePostgres {
+	return &RecoveryCodePostgres{db: db}
+}
+
+// ReplaceRecoveryCodes deletes all recovery codes of the user and stores the new code hashes instead.
+func (r *RecoveryCodePostgres) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(constants.RecoveryCodeTableName).Delete(&models.RecoveryCode{}, "user_id = ?", userID).Error; err != nil {
+			return err
+		}
+
+		recoveryCodes := make([]models.RecoveryCode, len(codeHashes))
+		for i, codeHash := range codeHashes {
+			recoveryCodes[i] = models.RecoveryCode{UserID: userID, CodeHash: codeHash, CreatedAt: time.Now()}
+		}
+
+		return tx.Table(constants.RecoveryCodeTableName).Create(&recoveryCodes).Error
+	})
+}
+
+// UseRecoveryCode marks the recovery code as used. It returns gorm.ErrRecordNotFound
+// if the user has no such code or it has already been used.
+func (r *RecoveryCodePostgres) UseRecoveryCode(userID uint, codeHash string) error {
+	result := r.db.Table(constants.RecoveryCodeTableName).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *RecoveryCodePostgres) DeleteRecoveryCodesByUserID(userID uint) error {
+	result := r.db.Table(constants.RecoveryCodeTableName).Delete(&models.RecoveryCode{}, "user_id = ?", userID)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
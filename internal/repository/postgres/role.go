@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type RolePostgres struct {
+	db *gorm.DB
+}
+
+func NewRolePostgres(db *gorm.DB) *RolePostgres {
+	return &RolePostgres{db: db}
+}
+
+// CreateRole creates the role together with its permission grants.
+func (r *RolePostgres) CreateRole(role *models.UserRole) (uint, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(constants.RoleTableName).Create(role).Error; err != nil {
+			return err
+		}
+
+		return setRolePermissions(tx, role.ID, role.Permissions)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return role.ID, nil
+}
+
+func (r *RolePostgres) GetAllRoles() (*[]models.UserRole, error) {
+	var roles []models.UserRole
+	result := r.db.Table(constants.RoleTableName).Order("user_role_id").Find(&roles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var grants []struct {
+		UserRoleID uint
+		Name       string
+	}
+	result = r.db.Table(constants.RolePermissionTableName).
+		Select("role_permission.user_role_id, permission.name").
+		Joins("JOIN permission USING (permission_id)").
+		Order("permission.name").
+		Scan(&grants)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	permissions := make(map[uint][]string, len(roles))
+	for _, grant := range grants {
+		permissions[grant.UserRoleID] = append(permissions[grant.UserRoleID], grant.Name)
+	}
+	for i := range roles {
+		roles[i].Permissions = permissions[roles[i].ID]
+	}
+
+	return &roles, nil
+}
+
+func (r *RolePostgres) GetRoleByID(id uint) (*models.UserRole, error) {
+	var role models.UserRole
+	result := r.db.Table(constants.RoleTableName).First(&role, "user_role_id = ?", id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	permissions, err := r.GetPermissionsByRoleID(id)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = permissions
+
+	return &role, nil
+}
+
+func (r *RolePostgres) GetRoleByName(name string) (*models.UserRole, error) {
+	var role models.UserRole
+	result := r.db.Table(constants.RoleTableName).First(&role, "name = ?", name)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &role, nil
+}
+
+// UpdateRole updates the role's non-zero fields. Its permission grants are replaced
+// unless role.Permissions is nil.
+func (r *RolePostgres) UpdateRole(role *models.UserRole) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(constants.RoleTableName).Model(&models.UserRole{}).Where("user_role_id = ?", role.ID).Updates(role)
+		if result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if role.Permissions == nil {
+			return nil
+		}
+
+		if err := tx.Exec("DELETE FROM role_permission WHERE user_role_id = ?", role.ID).Error; err != nil {
+			return err
+		}
+
+		return setRolePermissions(tx, role.ID, role.Permissions)
+	})
+}
+
+func (r *RolePostgres) DeleteRole(id uint) error {
+	result := r.db.Table(constants.RoleTableName).Delete(&models.UserRole{}, "user_role_id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *RolePostgres) CountUsersByRoleID(id uint) (int64, error) {
+	var count int64
+	result := r.db.Table(constants.UserTableName).Where("user_role_id = ?", id).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return count, nil
+}
+
+func (r *RolePostgres) CountAPIKeysByRoleID(id uint) (int64, error) {
+	var count int64
+	result := r.db.Table(constants.APIKeyTableName).Where("user_role_id = ?", id).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return count, nil
+}
+
+func (r *RolePostgres) GetAllPermissions() (*[]models.Permission, error) {
+	var permissions []models.Permission
+	result := r.db.Table(constants.PermissionTableName).Order("name").Find(&permissions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &permissions, nil
+}
+
+// GetPermissionsByRoleID returns the names of the permissions granted to the role,
+// which is empty if the role does not exist.
+func (r *RolePostgres) GetPermissionsByRoleID(roleID uint) ([]string, error) {
+	permissions := []string{}
+	result := r.db.Table(constants.RolePermissionTableName).
+		Joins("JOIN permission USING (permission_id)").
+		Where("role_permission.user_role_id = ?", roleID).
+		Order("permission.name").
+		Pluck("permission.name", &permissions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return permissions, nil
+}
+
+func setRolePermissions(tx *gorm.DB, roleID uint, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	insertStatement := `INSERT INTO role_permission (user_role_id, permission_id)
+		SELECT ?, permission_id FROM permission WHERE name IN ?
+		ON CONFLICT DO NOTHING;`
+
+	return tx.Exec(insertStatement, roleID, permissions).Error
+}
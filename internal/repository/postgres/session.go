@@ -4,6 +4,7 @@ import (
 	"Canteen-Backend/internal/constants"
 	"Canteen-Backend/internal/models"
 	"gorm.io/gorm"
+	"time"
 )
 
 type SessionPostgres struct {
@@ -23,25 +24,92 @@ func (r *SessionPostgres) CreateSession(session *models.Session) error {
 	return nil
 }
 
-func (r *SessionPostgres) DeleteSession(session *models.Session) error {
-	result := r.db.Table(constants.SessionTableName).Delete(&models.Session{}, "session_id = ?", session.ID)
+func (r *SessionPostgres) GetSessionByRefreshTokenHash(refreshTokenHash string) (*models.Session, error) {
+	var session models.Session
+	result := r.db.Table(constants.SessionTableName).First(&session, "refresh_token_hash = ?", refreshTokenHash)
 	if result.Error != nil {
-		return result.Error
+		return nil, result.Error
 	}
 
-	if result.RowsAffected == 0 {
+	return &session, nil
+}
+
+// RotateSession marks the session as rotated. It returns gorm.ErrRecordNotFound if the session
+// has already been rotated or revoked, so that concurrent refreshes with one token cannot both succeed.
+func (r *SessionPostgres) RotateSession(id uint) error {
+	result := r.db.Table(constants.SessionTableName).
+		Where("session_id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Update("rotated_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
 		return gorm.ErrRecordNotFound
 	}
 
 	return nil
 }
 
-func (r *SessionPostgres) GetSessionByRefreshToken(refreshToken string) (*models.Session, error) {
-	var session models.Session
-	result := r.db.Table(constants.SessionTableName).First(&session, "refresh_token = ?", refreshToken)
+// GetActiveSessionsByUserID returns the current refresh token of every token family of the user
+// that has neither been revoked nor expired.
+func (r *SessionPostgres) GetActiveSessionsByUserID(userID uint) (*[]models.Session, error) {
+	var sessions []models.Session
+	result := r.db.Table(constants.SessionTableName).
+		Where("user_id = ? AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 
-	return &session, nil
+	return &sessions, nil
+}
+
+func (r *SessionPostgres) IsSessionFamilyActive(userID uint, familyID string) (bool, error) {
+	var count int64
+	result := r.db.Table(constants.SessionTableName).
+		Where("user_id = ? AND family_id = ? AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > ?", userID, familyID, time.Now()).
+		Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// RevokeSessionFamily revokes every refresh token of the user's token family.
+// It returns gorm.ErrRecordNotFound if there was nothing left to revoke.
+func (r *SessionPostgres) RevokeSessionFamily(userID uint, familyID string) error {
+	result := r.db.Table(constants.SessionTableName).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// RevokeSessionsByUserID revokes all sessions of the user except the token family exceptFamilyID,
+// which may be empty to revoke everything.
+func (r *SessionPostgres) RevokeSessionsByUserID(userID uint, exceptFamilyID string) error {
+	result := r.db.Table(constants.SessionTableName).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, exceptFamilyID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// DeleteExpiredSessions deletes the sessions that expired before the given time, whether or not they were revoked.
+func (r *SessionPostgres) DeleteExpiredSessions(before time.Time) (int64, error) {
+	result := r.db.Table(constants.SessionTableName).Delete(&models.Session{}, "expires_at < ?", before)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
 }
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type SignInAttemptPostgres struct {
+	db *gorm.DB
+}
+
+func NewSignInAttemptPostgres(db *gorm.DB) *SignInAttemptPostgres {
+	return &SignInAttemptPostgres{db: db}
+}
+
+func (r *SignInAttemptPostgres) CreateSignInAttempt(attempt *models.SignInAttempt) error {
+	result := r.db.Table(constants.SignInAttemptTableName).Create(attempt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (r *SignInAttemptPostgres) CountFailedSignInAttemptsByIP(ipAddress string, since time.Time) (int64, error) {
+	var count int64
+	result := r.db.Table(constants.SignInAttemptTableName).
+		Where("ip_address = ? AND is_successful = FALSE AND created_at > ?", ipAddress, since).
+		Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return count, nil
+}
+
+// GetFailedSignInAttemptTimes returns when the consecutive failed attempts for the username were made since the
+// given time, oldest first. Failures before the last successful attempt are not consecutive and left out.
+func (r *SignInAttemptPostgres) GetFailedSignInAttemptTimes(username string, since time.Time) ([]time.Time, error) {
+	var times []time.Time
+	result := r.db.Table(constants.SignInAttemptTableName).
+		Where("username = ? AND is_successful = FALSE AND created_at > ?", username, since).
+		Where("NOT EXISTS (SELECT 1 FROM sign_in_attempt AS success WHERE success.username = sign_in_attempt.username "+
+			"AND success.is_successful = TRUE AND success.created_at >= sign_in_attempt.created_at)").
+		Order("created_at, sign_in_attempt_id").
+		Pluck("created_at", &times)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return times, nil
+}
+
+// DeleteFailedSignInAttemptsByUsername forgets the failed attempts for the username, which lifts its throttling.
+func (r *SignInAttemptPostgres) DeleteFailedSignInAttemptsByUsername(username string) error {
+	result := r.db.Table(constants.SignInAttemptTableName).Delete(&models.SignInAttempt{}, "username = ? AND is_successful = FALSE", username)
+	return result.Error
+}
+
+func (r *SignInAttemptPostgres) DeleteSignInAttemptsBefore(before time.Time) (int64, error) {
+	result := r.db.Table(constants.SignInAttemptTableName).Delete(&models.SignInAttempt{}, "created_at < ?", before)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
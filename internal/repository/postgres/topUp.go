@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/customErr"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type TopUpPostgres struct {
+	db *gorm.DB
+}
+
+func NewTopUpPostgres(db *gorm.DB) *TopUpPostgres {
+	return &TopUpPostgres{db: db}
+}
+
+func (r *TopUpPostgres) CreateTopUp(topUp *models.TopUp) (uint, error) {
+	result := r.db.Table(constants.TopUpTableName).Create(topUp)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return topUp.ID, nil
+}
+
+// SetTopUpPayment records the payment created with the provider for the top-up.
+func (r *TopUpPostgres) SetTopUpPayment(id uint, providerPaymentID, checkoutURL string) error {
+	result := r.db.Table(constants.TopUpTableName).Where("top_up_id = ?", id).
+		Updates(map[string]interface{}{"provider_payment_id": providerPaymentID, "checkout_url": checkoutURL, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *TopUpPostgres) GetTopUpByID(id uint) (*models.TopUp, error) {
+	var topUp models.TopUp
+	result := r.db.Table(constants.TopUpTableName).First(&topUp, "top_up_id = ?", id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &topUp, nil
+}
+
+func (r *TopUpPostgres) GetTopUpByProviderPaymentID(provider, providerPaymentID string) (*models.TopUp, error) {
+	var topUp models.TopUp
+	result := r.db.Table(constants.TopUpTableName).First(&topUp, "provider = ? AND provider_payment_id = ?", provider, providerPaymentID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &topUp, nil
+}
+
+// GetTopUpsByClientID returns the client's online top-ups, most recent first.
+func (r *TopUpPostgres) GetTopUpsByClientID(clientID uint) (*[]models.TopUp, error) {
+	var topUps []models.TopUp
+	result := r.db.Table(constants.TopUpTableName).Where("client_id = ?", clientID).
+		Order("created_at DESC, top_up_id DESC").Find(&topUps)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &topUps, nil
+}
+
+// FailTopUp marks a pending top-up as failed. A top-up that already succeeded is left as it is,
+// the provider may report a failed attempt after a successful one.
+func (r *TopUpPostgres) FailTopUp(id uint) error {
+	result := r.db.Table(constants.TopUpTableName).Where("top_up_id = ? AND status = ?", id, constants.TopUpPending).
+		Updates(map[string]interface{}{"status": constants.TopUpFailed, "updated_at": time.Now()})
+	return result.Error
+}
+
+// CompleteTopUp credits the client's balance with the top-up transaction and marks the top-up as paid by the
+// provider's transaction, all in one database transaction. The top-up's row is locked meanwhile, so that
+// a webhook delivered twice at the same time credits the balance once, and so is the client's, like for any other
+// change to the client's balance, see lockClient. If the top-up was already paid by the
+// same provider transaction, nothing is applied and the top-up is returned as it is. If it was paid by another
+// transaction, customErr.TopUpAlreadyPaid is returned.
+func (r *TopUpPostgres) CompleteTopUp(id uint, providerTransactionID string, transaction *models.BalanceTransaction) (*models.TopUp, error) {
+	var topUp models.TopUp
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(constants.TopUpTableName).Clauses(clause.Locking{Strength: "UPDATE"}).First(&topUp, "top_up_id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if topUp.Status == constants.TopUpSucceeded {
+			if topUp.ProviderTransactionID != nil && *topUp.ProviderTransactionID == providerTransactionID {
+				return nil
+			}
+			return customErr.TopUpAlreadyPaid
+		}
+
+		if err := lockClient(tx, transaction.ClientID); err != nil {
+			return err
+		}
+		if err := createBalanceTransaction(tx, transaction, true); err != nil {
+			return err
+		}
+
+		topUp.Status = constants.TopUpSucceeded
+		topUp.ProviderTransactionID = &providerTransactionID
+		topUp.BalanceTransactionID = &transaction.ID
+		topUp.UpdatedAt = time.Now()
+		return tx.Table(constants.TopUpTableName).Where("top_up_id = ?", id).Updates(map[string]interface{}{
+			"status":                  topUp.Status,
+			"provider_transaction_id": providerTransactionID,
+			"balance_transaction_id":  transaction.ID,
+			"updated_at":              topUp.UpdatedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &topUp, nil
+}
@@ -4,6 +4,7 @@ import (
 	"Canteen-Backend/internal/constants"
 	"Canteen-Backend/internal/models"
 	"gorm.io/gorm"
+	"time"
 )
 
 type UserPostgres struct {
@@ -55,8 +56,40 @@ func (r *UserPostgres) UpdateUser(user *models.User) error {
 	return nil
 }
 
-func (r *UserPostgres) DeleteUser(id uint) error {
-	result := r.db.Table(constants.UserTableName).Delete(&models.User{}, "user_id = ?", id)
+func (r *UserPostgres) SetUserActive(id uint, isActive bool) error {
+	result := r.db.Table(constants.UserTableName).Where("user_id = ?", id).Updates(map[string]interface{}{"is_active": isActive, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// SetFailedSignInAttempts records the user's consecutive failed sign-ins and lockout, as shown to admins.
+func (r *UserPostgres) SetFailedSignInAttempts(id uint, failedAttempts int, lastFailedAt time.Time, lockedUntil *time.Time) error {
+	result := r.db.Table(constants.UserTableName).Where("user_id = ?", id).Updates(map[string]interface{}{
+		"failed_sign_in_attempts": failedAttempts,
+		"last_failed_sign_in_at":  lastFailedAt,
+		"locked_until":            lockedUntil,
+	})
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ResetFailedSignInAttempts clears the failed sign-in counter and lifts any lockout.
+func (r *UserPostgres) ResetFailedSignInAttempts(id uint) error {
+	result := r.db.Table(constants.UserTableName).Where("user_id = ?", id).Updates(map[string]interface{}{
+		"failed_sign_in_attempts": 0,
+		"last_failed_sign_in_at":  nil,
+		"locked_until":            nil,
+	})
 	if result.Error != nil {
 		return result.Error
 	} else if result.RowsAffected == 0 {
@@ -66,14 +99,85 @@ func (r *UserPostgres) DeleteUser(id uint) error {
 	return nil
 }
 
-func (r *UserPostgres) GetRoleByID(id uint) (string, error) {
-	var role models.UserRole
-	result := r.db.Table(constants.RoleTableName).Select("name").First(&role, "user_role_id = ?", id)
+// SetTOTPSecret stores a new, not yet confirmed TOTP secret and disables two-factor authentication
+// until it is confirmed. An empty secret removes two-factor authentication altogether.
+func (r *UserPostgres) SetTOTPSecret(id uint, secret string) error {
+	result := r.db.Table(constants.UserTableName).Where("user_id = ?", id).Updates(map[string]interface{}{
+		"totp_secret":         secret,
+		"totp_enabled":        false,
+		"totp_last_used_step": nil,
+	})
 	if result.Error != nil {
-		return "", result.Error
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *UserPostgres) EnableTOTP(id uint) error {
+	result := r.db.Table(constants.UserTableName).Where("user_id = ? AND totp_secret <> ''", id).Update("totp_enabled", true)
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
 	}
 
-	return role.Name, nil
+	return nil
+}
+
+// UseTOTPStep records the time step of an accepted TOTP code. It returns gorm.ErrRecordNotFound
+// if a code of the same or a later step has already been used, so that a code cannot be replayed.
+func (r *UserPostgres) UseTOTPStep(id uint, step int64) error {
+	result := r.db.Table(constants.UserTableName).
+		Where("user_id = ? AND (totp_last_used_step IS NULL OR totp_last_used_step < ?)", id, step).
+		Update("totp_last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// SetPassword stores a new password hash and clears the must change password flag.
+func (r *UserPostgres) SetPassword(id uint, hashedPassword string) error {
+	result := r.db.Table(constants.UserTableName).Where("user_id = ?", id).Updates(map[string]interface{}{
+		"password":             hashedPassword,
+		"must_change_password": false,
+		"updated_at":           time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *UserPostgres) SetMustChangePassword(id uint, mustChangePassword bool) error {
+	result := r.db.Table(constants.UserTableName).Where("user_id = ?", id).Updates(map[string]interface{}{"must_change_password": mustChangePassword, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *UserPostgres) DeleteUser(id uint) error {
+	result := r.db.Table(constants.UserTableName).Delete(&models.User{}, "user_id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
 }
 
 func (r *UserPostgres) GetUserByUsername(username string) (*models.User, error) {
@@ -85,3 +189,13 @@ func (r *UserPostgres) GetUserByUsername(username string) (*models.User, error)
 
 	return &user, nil
 }
+
+func (r *UserPostgres) GetUserByEmail(email string) (*models.User, error) {
+	var user models.User
+	result := r.db.Table(constants.UserTableName).First(&user, "lower(email) = lower(?)", email)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &user, nil
+}
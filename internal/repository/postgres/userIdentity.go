@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type UserIdentityPostgres struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityPostgres(db *gorm.DB) *UserIdentityPostgres {
+	return &UserIdentityPostgres{db: db}
+}
+
+func (r *UserIdentityPostgres) GetUserIdentity(issuer, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	result := r.db.Table(constants.UserIdentityTableName).First(&identity, "issuer = ? AND subject = ?", issuer, subject)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &identity, nil
+}
+
+func (r *UserIdentityPostgres) CreateUserIdentity(identity *models.UserIdentity) error {
+	return r.db.Table(constants.UserIdentityTableName).Create(identity).Error
+}
+
+// CreateUserWithIdentity creates a user who signed in through a provider for the first time,
+// together with the identity linking the user to the provider.
+func (r *UserIdentityPostgres) CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) (uint, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(constants.UserTableName).Create(user).Error; err != nil {
+			return err
+		}
+
+		identity.UserID = user.ID
+		return tx.Table(constants.UserIdentityTableName).Create(identity).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return user.ID, nil
+}
+
+func (r *UserIdentityPostgres) UpdateUserIdentityLastSignIn(id uint) error {
+	result := r.db.Table(constants.UserIdentityTableName).Where("user_identity_id = ?", id).Update("last_sign_in_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
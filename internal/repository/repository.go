@@ -3,7 +3,9 @@ package repository
 import (
 	"Canteen-Backend/internal/models"
 	"Canteen-Backend/internal/repository/postgres"
+	"Canteen-Backend/pkg/money"
 	"gorm.io/gorm"
+	"time"
 )
 
 type User interface {
@@ -11,25 +13,130 @@ type User interface {
 	CreateUser(user *models.User) (uint, error)
 	GetUserByID(id uint) (*models.User, error)
 	UpdateUser(user *models.User) error
+	SetUserActive(id uint, isActive bool) error
+	SetFailedSignInAttempts(id uint, failedAttempts int, lastFailedAt time.Time, lockedUntil *time.Time) error
+	ResetFailedSignInAttempts(id uint) error
+	SetTOTPSecret(id uint, secret string) error
+	EnableTOTP(id uint) error
+	UseTOTPStep(id uint, step int64) error
+	SetPassword(id uint, hashedPassword string) error
+	SetMustChangePassword(id uint, mustChangePassword bool) error
 	DeleteUser(ud uint) error
-	GetRoleByID(id uint) (string, error)
 	GetUserByUsername(username string) (*models.User, error)
+	GetUserByEmail(email string) (*models.User, error)
+}
+
+type Role interface {
+	CreateRole(role *models.UserRole) (uint, error)
+	GetAllRoles() (*[]models.UserRole, error)
+	GetRoleByID(id uint) (*models.UserRole, error)
+	GetRoleByName(name string) (*models.UserRole, error)
+	UpdateRole(role *models.UserRole) error
+	DeleteRole(id uint) error
+	CountUsersByRoleID(id uint) (int64, error)
+	CountAPIKeysByRoleID(id uint) (int64, error)
+	GetAllPermissions() (*[]models.Permission, error)
+	GetPermissionsByRoleID(roleID uint) ([]string, error)
 }
 
 type Session interface {
 	CreateSession(session *models.Session) error
-	DeleteSession(session *models.Session) error
-	GetSessionByRefreshToken(refreshToken string) (*models.Session, error)
+	GetSessionByRefreshTokenHash(refreshTokenHash string) (*models.Session, error)
+	RotateSession(id uint) error
+	GetActiveSessionsByUserID(userID uint) (*[]models.Session, error)
+	IsSessionFamilyActive(userID uint, familyID string) (bool, error)
+	RevokeSessionFamily(userID uint, familyID string) error
+	RevokeSessionsByUserID(userID uint, exceptFamilyID string) error
+	DeleteExpiredSessions(before time.Time) (int64, error)
+}
+
+type SignInAttempt interface {
+	CreateSignInAttempt(attempt *models.SignInAttempt) error
+	CountFailedSignInAttemptsByIP(ipAddress string, since time.Time) (int64, error)
+	GetFailedSignInAttemptTimes(username string, since time.Time) ([]time.Time, error)
+	DeleteFailedSignInAttemptsByUsername(username string) error
+	DeleteSignInAttemptsBefore(before time.Time) (int64, error)
+}
+
+type RecoveryCode interface {
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	UseRecoveryCode(userID uint, codeHash string) error
+	DeleteRecoveryCodesByUserID(userID uint) error
+}
+
+type PasswordResetToken interface {
+	CreatePasswordResetToken(token *models.PasswordResetToken) error
+	UsePasswordResetToken(tokenHash string) (uint, error)
+	DeleteExpiredPasswordResetTokens(before time.Time) (int64, error)
+}
+
+type OIDCState interface {
+	CreateOIDCState(state *models.OIDCState) error
+	UseOIDCState(stateHash string) (*models.OIDCState, error)
+	DeleteExpiredOIDCStates(before time.Time) (int64, error)
+}
+
+type UserIdentity interface {
+	GetUserIdentity(issuer, subject string) (*models.UserIdentity, error)
+	CreateUserIdentity(identity *models.UserIdentity) error
+	CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) (uint, error)
+	UpdateUserIdentityLastSignIn(id uint) error
+}
+
+type APIKey interface {
+	CreateAPIKey(apiKey *models.APIKey) (uint, error)
+	GetAllAPIKeys() (*[]models.APIKey, error)
+	GetAPIKeyByID(id uint) (*models.APIKey, error)
+	GetAPIKeyByHash(keyHash string) (*models.APIKey, error)
+	RevokeAPIKey(id uint) error
+	UpdateAPIKeyLastUsed(id uint, ipAddress string) error
+}
+
+// Locker runs work that only one app replica sharing the database may do at a time.
+type Locker interface {
+	WithAdvisoryLock(key int64, fn func() error) (bool, error)
 }
 
 type Client interface {
-	CreateClient(client *models.Client) (uint, error)
-	GetAllClients() (*[]models.Client, error)
-	GetAllClientsByCategoryID(clientCategoryID uint) (*[]models.Client, error)
+	WithClientLock(clientID uint, fn func(repoClient Client) error) error
+
+	CreateClient(client *models.Client, openingBalance *models.BalanceTransaction) (uint, error)
+	CreateClients(clients []*models.Client, openingBalances []*models.BalanceTransaction) error
+	GetClients(filter *models.ClientFilter) (*[]models.Client, int64, error)
+	GetClientsByEmails(emails []string) (*[]models.Client, error)
+	GetClientsByCategoryID(clientCategoryID uint) (*[]models.Client, error)
 	GetClientByID(id uint) (*models.Client, error)
 	UpdateClient(client *models.Client) error
 	DeleteClient(id uint) error
 
+	CreateBalanceTransaction(transaction *models.BalanceTransaction, checkCreditLimit bool) (*models.BalanceTransaction, error)
+	GetBalanceTransactionByIdempotencyKey(clientID uint, idempotencyKey string) (*models.BalanceTransaction, error)
+	GetBalanceTransactionsByClientID(clientID uint, filter *models.BalanceTransactionFilter) (*[]models.BalanceTransaction, error)
+	GetSubsidySince(clientID uint, since time.Time) (money.Money, error)
+	GetAvailableBalance(clientID uint) (money.Money, error)
+	SetClientCreditLimit(clientID uint, creditLimit *money.Money) error
+	GetSpendingSince(clientID uint, since time.Time) (money.Money, error)
+	GetBalanceBefore(clientID uint, before time.Time) (money.Money, error)
+	SetClientSpendingLimits(clientID uint, limits *models.SpendingLimits) error
+
+	GetClientDietaryRestrictions(clientID uint) (*models.DietaryRestrictions, error)
+	SetClientDietaryRestrictions(clientID uint, restrictions *models.DietaryRestrictions) error
+	GetDietaryOverridesByClientID(clientID uint) (*[]models.DietaryOverride, error)
+
+	CreateClientIdentifier(identifier *models.ClientIdentifier, event *models.ClientIdentifierEvent) error
+	GetClientIdentifierByID(id uint) (*models.ClientIdentifier, error)
+	GetClientIdentifierByValue(identifierType, value string) (*models.ClientIdentifier, error)
+	GetClientIdentifiersByClientID(clientID uint) (*[]models.ClientIdentifier, error)
+	UpdateClientIdentifierStatus(identifier *models.ClientIdentifier, event *models.ClientIdentifierEvent) error
+	ReplaceClientIdentifier(identifier, replacement *models.ClientIdentifier, event, replacementEvent *models.ClientIdentifierEvent) error
+	GetClientIdentifierEvents(identifierID uint) (*[]models.ClientIdentifierEvent, error)
+
+	CreateClientCategoryPricing(pricing *models.ClientCategoryPricing) (uint, error)
+	GetClientCategoryPricings(clientCategoryID uint) (*[]models.ClientCategoryPricing, error)
+	GetClientCategoryPricingByID(id uint) (*models.ClientCategoryPricing, error)
+	GetClientCategoryPricingAt(clientCategoryID uint, at time.Time) (*models.ClientCategoryPricing, error)
+	DeleteClientCategoryPricing(id uint, now time.Time) error
+
 	CreateClientCategory(clientCategory *models.ClientCategory) (uint, error)
 	GetAllClientCategories() (*[]models.ClientCategory, error)
 	GetClientCategoryByID(id uint) (*models.ClientCategory, error)
@@ -38,6 +145,16 @@ type Client interface {
 	DeleteClientCategory(id uint) error
 }
 
+type TopUp interface {
+	CreateTopUp(topUp *models.TopUp) (uint, error)
+	SetTopUpPayment(id uint, providerPaymentID, checkoutURL string) error
+	GetTopUpByID(id uint) (*models.TopUp, error)
+	GetTopUpByProviderPaymentID(provider, providerPaymentID string) (*models.TopUp, error)
+	GetTopUpsByClientID(clientID uint) (*[]models.TopUp, error)
+	FailTopUp(id uint) error
+	CompleteTopUp(id uint, providerTransactionID string, transaction *models.BalanceTransaction) (*models.TopUp, error)
+}
+
 type Ingredient interface {
 	CreateIngredientCategory(ingredientCategory *models.IngredientCategory) (uint, error)
 	GetAllIngredientCategories() (*[]models.IngredientCategory, error)
@@ -50,6 +167,25 @@ type Ingredient interface {
 	GetIngredientByID(id uint) (*models.Ingredient, error)
 	UpdateIngredient(ingredient *models.Ingredient) error
 	DeleteIngredient(id uint) error
+	GetIngredientsByIDs(ids []uint) (*[]models.Ingredient, error)
+	GetIngredientDietaryInfo(id uint) (*models.IngredientDietaryInfo, error)
+	SetIngredientDietaryInfo(id uint, info *models.IngredientDietaryInfo) error
+
+	CreateAllergen(allergen *models.Allergen) (uint, error)
+	GetAllAllergens() (*[]models.Allergen, error)
+	GetAllergensByIDs(ids []uint) (*[]models.Allergen, error)
+	UpdateAllergen(allergen *models.Allergen) error
+	DeleteAllergen(id uint) error
+}
+
+type Dish interface {
+	CreateDish(dish *models.Dish) (uint, error)
+	GetAllDishes() (*[]models.Dish, error)
+	GetDishByID(id uint) (*models.Dish, error)
+	GetDishesByIDs(ids []uint) (*[]models.Dish, error)
+	UpdateDish(dish *models.Dish) error
+	SetDishIngredients(dishID uint, ingredientIDs []uint) error
+	GetDishDietaryInfo(dishIDs []uint) (*[]models.DishDietaryInfo, error)
 }
 
 type Purchase interface {
@@ -65,18 +201,49 @@ type Purchase interface {
 
 type Repository struct {
 	User
+	Role
 	Client
+	TopUp
 	Session
+	SignInAttempt
+	RecoveryCode
+	PasswordResetToken
+	OIDCState
+	UserIdentity
+	APIKey
+	Locker
 	Ingredient
+	Dish
 	Purchase
 }
 
 func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{
-		User:       postgres.NewUserPostgres(db),
-		Client:     postgres.NewClientPostgres(db),
-		Session:    postgres.NewSessionPostgres(db),
-		Ingredient: postgres.NewIngredientPostgres(db),
-		Purchase:   postgres.NewPurchasePostgres(db),
+		User:               postgres.NewUserPostgres(db),
+		Role:               postgres.NewRolePostgres(db),
+		Client:             &clientPostgres{postgres.NewClientPostgres(db)},
+		TopUp:              postgres.NewTopUpPostgres(db),
+		Session:            postgres.NewSessionPostgres(db),
+		SignInAttempt:      postgres.NewSignInAttemptPostgres(db),
+		RecoveryCode:       postgres.NewRecoveryCodePostgres(db),
+		PasswordResetToken: postgres.NewPasswordResetTokenPostgres(db),
+		OIDCState:          postgres.NewOIDCStatePostgres(db),
+		UserIdentity:       postgres.NewUserIdentityPostgres(db),
+		APIKey:             postgres.NewAPIKeyPostgres(db),
+		Locker:             postgres.NewLockPostgres(db),
+		Ingredient:         postgres.NewIngredientPostgres(db),
+		Dish:               postgres.NewDishPostgres(db),
+		Purchase:           postgres.NewPurchasePostgres(db),
 	}
 }
+
+// clientPostgres hands WithClientLock's callback the transaction-bound repository as a Client.
+type clientPostgres struct {
+	*postgres.ClientPostgres
+}
+
+func (r *clientPostgres) WithClientLock(clientID uint, fn func(repoClient Client) error) error {
+	return r.ClientPostgres.WithClientLock(clientID, func(tx *postgres.ClientPostgres) error {
+		return fn(&clientPostgres{tx})
+	})
+}
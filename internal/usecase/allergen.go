@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/internal/repository"
+	"Canteen-Backend/pkg/customErr"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+func (u *IngredientUseCase) CreateAllergen(allergen *models.Allergen) (uint, *customErr.CustomError) {
+	allergen.CreatedAt = time.Now()
+
+	id, err := u.repoIngredient.CreateAllergen(allergen)
+	if err != nil {
+		if ok, _ := customErr.IsDuplicateKeyError(err); ok {
+			return 0, customErr.NewCustomError(err, customErr.AllergenAlreadyExists.Error(), http.StatusConflict)
+		} else {
+			return 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return id, nil
+}
+
+func (u *IngredientUseCase) GetAllAllergens() (*[]models.Allergen, *customErr.CustomError) {
+	allergens, err := u.repoIngredient.GetAllAllergens()
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return allergens, nil
+}
+
+func (u *IngredientUseCase) UpdateAllergen(allergen *models.Allergen) *customErr.CustomError {
+	if err := u.repoIngredient.UpdateAllergen(allergen); err != nil {
+		if ok, _ := customErr.IsDuplicateKeyError(err); ok {
+			return customErr.NewCustomError(err, customErr.AllergenAlreadyExists.Error(), http.StatusConflict)
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.AllergenNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// DeleteAllergen deletes the allergen. Ingredients are no longer tagged with it, and clients no longer count as allergic to it.
+func (u *IngredientUseCase) DeleteAllergen(id uint) *customErr.CustomError {
+	if err := u.repoIngredient.DeleteAllergen(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.AllergenNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+func (u *IngredientUseCase) GetIngredientDietaryInfo(id uint) (*models.IngredientDietaryInfo, *customErr.CustomError) {
+	info, err := u.repoIngredient.GetIngredientDietaryInfo(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.IngredientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return info, nil
+}
+
+// SetIngredientDietaryInfo replaces the allergens the ingredient contains and whether it is vegetarian and halal.
+func (u *IngredientUseCase) SetIngredientDietaryInfo(id uint, info *models.IngredientDietaryInfo) *customErr.CustomError {
+	if customError := checkAllergensExist(u.repoIngredient, info.AllergenIDs); customError != nil {
+		return customError
+	}
+
+	if err := u.repoIngredient.SetIngredientDietaryInfo(id, info); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.IngredientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// checkAllergensExist returns an error unless every allergen of the IDs, which are distinct, is in the catalogue.
+func checkAllergensExist(repoIngredient repository.Ingredient, ids []uint) *customErr.CustomError {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	allergens, err := repoIngredient.GetAllergensByIDs(ids)
+	if err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	if len(*allergens) != len(ids) {
+		return customErr.NewCustomError(customErr.AllergenNotFound, customErr.AllergenNotFound.Error(), http.StatusNotFound)
+	}
+
+	return nil
+}
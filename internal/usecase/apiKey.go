@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/internal/repository"
+	"Canteen-Backend/pkg/auth"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/logger"
+	"errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiKeyLastUsedInterval limits how often the last use of an API key is written,
+// so that a busy kiosk does not cause a write on every request.
+const apiKeyLastUsedInterval = time.Minute
+
+type APIKeyUseCase struct {
+	apiKeyRepo repository.APIKey
+	roleRepo   repository.Role
+}
+
+func NewAPIKeyUseCase(apiKeyRepo repository.APIKey, roleRepo repository.Role) *APIKeyUseCase {
+	return &APIKeyUseCase{apiKeyRepo: apiKeyRepo, roleRepo: roleRepo}
+}
+
+// CreateAPIKey creates a key with the permissions of the given role and returns its ID and the key itself,
+// which cannot be retrieved later since only its hash is stored.
+func (u *APIKeyUseCase) CreateAPIKey(apiKey *models.APIKey) (uint, string, *customErr.CustomError) {
+	if _, err := u.roleRepo.GetRoleByID(apiKey.UserRoleID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, "", customErr.NewCustomError(err, customErr.RoleNotFound.Error(), http.StatusNotFound)
+		} else {
+			return 0, "", customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	for _, allowedIP := range splitAllowedIPs(apiKey.AllowedIPs) {
+		if !isValidIPOrCIDR(allowedIP) {
+			return 0, "", customErr.NewCustomError(customErr.APIKeyAllowedIPInvalid, customErr.APIKeyAllowedIPInvalid.Error()+": "+allowedIP, http.StatusBadRequest)
+		}
+	}
+
+	if apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(time.Now()) {
+		return 0, "", customErr.NewCustomError(customErr.APIKeyExpired, customErr.APIKeyExpired.Error(), http.StatusBadRequest)
+	}
+
+	key, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		return 0, "", customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+	apiKey.Prefix = prefix
+	apiKey.KeyHash = auth.HashAPIKey(key)
+	apiKey.CreatedAt = time.Now()
+
+	id, err := u.apiKeyRepo.CreateAPIKey(apiKey)
+	if err != nil {
+		return 0, "", customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return id, key, nil
+}
+
+func (u *APIKeyUseCase) GetAllAPIKeys() (*[]models.APIKey, *customErr.CustomError) {
+	apiKeys, err := u.apiKeyRepo.GetAllAPIKeys()
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return apiKeys, nil
+}
+
+func (u *APIKeyUseCase) GetAPIKeyByID(id uint) (*models.APIKey, *customErr.CustomError) {
+	apiKey, err := u.apiKeyRepo.GetAPIKeyByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.APIKeyNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return apiKey, nil
+}
+
+// RevokeAPIKey revokes the key immediately. Revoked keys are kept to show when they were last used.
+func (u *APIKeyUseCase) RevokeAPIKey(id uint) *customErr.CustomError {
+	if err := u.apiKeyRepo.RevokeAPIKey(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.APIKeyNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey returns the API key if it exists, has neither expired nor been revoked,
+// and may be used from the IP address. Its last use is recorded.
+func (u *APIKeyUseCase) AuthenticateAPIKey(key, ipAddress string) (*models.APIKey, *customErr.CustomError) {
+	apiKey, err := u.apiKeyRepo.GetAPIKeyByHash(auth.HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.APIKeyInvalid.Error(), http.StatusUnauthorized)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if apiKey.RevokedAt != nil {
+		return nil, customErr.NewCustomError(customErr.APIKeyRevoked, customErr.APIKeyRevoked.Error(), http.StatusUnauthorized)
+	}
+
+	if apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(time.Now()) {
+		return nil, customErr.NewCustomError(customErr.APIKeyExpired, customErr.APIKeyExpired.Error(), http.StatusUnauthorized)
+	}
+
+	if !isIPAllowed(apiKey.AllowedIPs, ipAddress) {
+		logger.GetLogger().Warn("security event: API key used from a disallowed IP address",
+			zap.Uint("api_key_id", apiKey.ID), zap.String("ip_address", ipAddress))
+		return nil, customErr.NewCustomError(customErr.APIKeyIPNotAllowed, customErr.APIKeyIPNotAllowed.Error(), http.StatusForbidden)
+	}
+
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > apiKeyLastUsedInterval {
+		if err := u.apiKeyRepo.UpdateAPIKeyLastUsed(apiKey.ID, ipAddress); err != nil {
+			logger.GetLogger().Error("error recording API key use", zap.Uint("api_key_id", apiKey.ID), zap.Error(err))
+		}
+	}
+
+	return apiKey, nil
+}
+
+func splitAllowedIPs(allowedIPs string) []string {
+	if allowedIPs == "" {
+		return nil
+	}
+
+	return strings.Split(allowedIPs, ",")
+}
+
+func isValidIPOrCIDR(value string) bool {
+	if _, _, err := net.ParseCIDR(value); err == nil {
+		return true
+	}
+
+	return net.ParseIP(value) != nil
+}
+
+// isIPAllowed reports whether ipAddress is one of the comma separated IP addresses
+// or within one of the CIDR ranges of allowedIPs. An empty allowedIPs allows any address.
+func isIPAllowed(allowedIPs, ipAddress string) bool {
+	entries := splitAllowedIPs(allowedIPs)
+	if len(entries) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range entries {
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		} else if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
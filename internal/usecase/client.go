@@ -1,23 +1,31 @@
 package usecase
 
 import (
+	"Canteen-Backend/internal/constants"
 	"Canteen-Backend/internal/models"
 	"Canteen-Backend/internal/repository"
 	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/money"
 	"errors"
+	"fmt"
 	"gorm.io/gorm"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type ClientUseCase struct {
-	repoClient repository.Client
+	repoClient     repository.Client
+	repoDish       repository.Dish
+	repoIngredient repository.Ingredient
 }
 
-func NewClientUseCase(repoClient repository.Client) *ClientUseCase {
-	return &ClientUseCase{repoClient: repoClient}
+func NewClientUseCase(repoClient repository.Client, repoDish repository.Dish, repoIngredient repository.Ingredient) *ClientUseCase {
+	return &ClientUseCase{repoClient: repoClient, repoDish: repoDish, repoIngredient: repoIngredient}
 }
 
-func (u *ClientUseCase) CreateClient(client *models.Client) (uint, *customErr.CustomError) {
+// CreateClient creates the client. A non-zero initial balance is recorded in the ledger as a top-up by the operator.
+func (u *ClientUseCase) CreateClient(client *models.Client, operator *models.Operator) (uint, *customErr.CustomError) {
 	if _, err := u.repoClient.GetClientCategoryByID(client.ClientCategoryID); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return 0, customErr.NewCustomError(err, customErr.ClientCategoryNotFound.Error(), http.StatusNotFound)
@@ -25,7 +33,8 @@ func (u *ClientUseCase) CreateClient(client *models.Client) (uint, *customErr.Cu
 			return 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
 		}
 	}
-	id, err := u.repoClient.CreateClient(client)
+
+	id, err := u.repoClient.CreateClient(client, openingBalanceTransaction(client, operator))
 	if err != nil {
 		if ok, _ := customErr.IsDuplicateKeyError(err); ok {
 			return 0, customErr.NewCustomError(err, customErr.EmailAlreadyExists.Error(), http.StatusConflict)
@@ -37,32 +46,57 @@ func (u *ClientUseCase) CreateClient(client *models.Client) (uint, *customErr.Cu
 	return id, nil
 }
 
-func (u *ClientUseCase) GetAllClients(clientCategoryName string) (*[]models.Client, *customErr.CustomError) {
-	if clientCategoryName != "" {
-		clientCategory, err := u.repoClient.GetClientCategoryByName(clientCategoryName)
+// openingBalanceTransaction returns the ledger entry of a new client's initial balance, or nil if the balance is zero.
+func openingBalanceTransaction(client *models.Client, operator *models.Operator) *models.BalanceTransaction {
+	if client.Balance == 0 {
+		return nil
+	}
+
+	openingBalance := &models.BalanceTransaction{
+		Type:      constants.BalanceTransactionTopUp,
+		UserID:    operator.UserID,
+		APIKeyID:  operator.APIKeyID,
+		Reason:    "opening balance",
+		CreatedAt: time.Now(),
+	}
+	if client.Balance < 0 {
+		openingBalance.Type = constants.BalanceTransactionCorrection
+	}
+
+	return openingBalance
+}
+
+// GetClients returns a page of the clients that match the filter and the number of all matching clients.
+// A category given by name has to exist.
+func (u *ClientUseCase) GetClients(filter *models.ClientFilter) (*[]models.Client, int64, *customErr.CustomError) {
+	for _, sort := range filter.Sort {
+		if _, ok := constants.ClientSortColumns[sort.Field]; !ok {
+			return nil, 0, customErr.NewCustomError(customErr.SortFieldInvalid, customErr.SortFieldInvalid.Error()+": "+sort.Field, http.StatusBadRequest)
+		}
+	}
+
+	if filter.ClientCategoryName != "" {
+		clientCategory, err := u.repoClient.GetClientCategoryByName(filter.ClientCategoryName)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return nil, customErr.NewCustomError(err, customErr.ClientCategoryNotFound.Error(), http.StatusNotFound)
+				return nil, 0, customErr.NewCustomError(err, customErr.ClientCategoryNotFound.Error(), http.StatusNotFound)
 			} else {
-				return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+				return nil, 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
 			}
 		}
 
-		clients, err := u.repoClient.GetAllClientsByCategoryID(clientCategory.ID)
-		if err != nil {
-			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		if filter.ClientCategoryID != 0 && filter.ClientCategoryID != clientCategory.ID {
+			return &[]models.Client{}, 0, nil
 		}
-
-		return clients, nil
-
+		filter.ClientCategoryID = clientCategory.ID
 	}
 
-	clients, err := u.repoClient.GetAllClients()
+	clients, total, err := u.repoClient.GetClients(filter)
 	if err != nil {
-		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		return nil, 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
 	}
 
-	return clients, nil
+	return clients, total, nil
 }
 
 func (u *ClientUseCase) GetClientByID(id uint) (*models.Client, *customErr.CustomError) {
@@ -113,25 +147,153 @@ func (u *ClientUseCase) DeleteClient(id uint) *customErr.CustomError {
 	return nil
 }
 
-func (u *ClientUseCase) ModifyBalanceByClientID(id uint, difference float32) *customErr.CustomError {
-	user, err := u.repoClient.GetClientByID(id)
+// CreateBalanceTransaction applies the transaction to the client's balance and records it in the ledger.
+// The sign of the amount has to match the type: top-ups and refunds are positive, purchases negative,
+// and corrections, which can go either way, need a reason. Purchases, given as an amount or as the dishes bought,
+// are priced under the rules of the client's category and checked against the client's dietary restrictions and spending limits, and no debit
+// but a correction may take the balance below the client's credit limit. A transaction with an idempotency key that was already used for the client is not
+// applied again, the original transaction is returned instead.
+func (u *ClientUseCase) CreateBalanceTransaction(transaction *models.BalanceTransaction, operator *models.Operator) (*models.BalanceTransaction, *customErr.CustomError) {
+	switch {
+	case len(transaction.Items) > 0 && transaction.Type != constants.BalanceTransactionPurchase:
+		return nil, customErr.NewCustomError(customErr.BalanceTransactionItemsInvalid, customErr.BalanceTransactionItemsInvalid.Error(), http.StatusBadRequest)
+	case transaction.Amount == 0 && len(transaction.Items) == 0,
+		transaction.Amount < 0 && (transaction.Type == constants.BalanceTransactionTopUp || transaction.Type == constants.BalanceTransactionRefund),
+		transaction.Amount > 0 && transaction.Type == constants.BalanceTransactionPurchase:
+		return nil, customErr.NewCustomError(customErr.BalanceTransactionAmountInvalid, customErr.BalanceTransactionAmountInvalid.Error(), http.StatusBadRequest)
+	case transaction.Type == constants.BalanceTransactionCorrection && strings.TrimSpace(transaction.Reason) == "":
+		return nil, customErr.NewCustomError(customErr.BalanceTransactionReasonRequired, customErr.BalanceTransactionReasonRequired.Error(), http.StatusBadRequest)
+	}
+
+	transaction.UserID = operator.UserID
+	transaction.APIKeyID = operator.APIKeyID
+	transaction.CreatedAt = time.Now()
+
+	// a charge depends on the client's earlier charges, e.g. on the subsidy left for the day,
+	// so the client's transactions are applied one at a time, each checked and applied in one database transaction
+	var stored *models.BalanceTransaction
+	var customError *customErr.CustomError
+	err := u.repoClient.WithClientLock(transaction.ClientID, func(repoClient repository.Client) error {
+		locked := *u
+		locked.repoClient = repoClient
+		stored, customError = locked.applyBalanceTransaction(transaction)
+		return nil
+	})
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+			return nil, customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else if errors.Is(err, customErr.ClientBusy) {
+			return nil, customErr.NewCustomError(err, customErr.ClientBusy.Error(), http.StatusServiceUnavailable)
 		} else {
-			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	} else if customError != nil {
+		return nil, customError
+	}
+
+	// a retry has to repeat the original request, otherwise the key was reused by mistake
+	if stored != transaction && (stored.Type != transaction.Type || requestedAmount(stored) != requestedAmount(transaction) || stored.Reason != transaction.Reason) {
+		return nil, customErr.NewCustomError(customErr.IdempotencyKeyReused, customErr.IdempotencyKeyReused.Error(), http.StatusConflict)
+	}
+
+	return stored, nil
+}
+
+func (u *ClientUseCase) applyBalanceTransaction(transaction *models.BalanceTransaction) (*models.BalanceTransaction, *customErr.CustomError) {
+	if transaction.Type == constants.BalanceTransactionPurchase {
+		if customError := u.priceBalanceTransaction(transaction); customError != nil {
+			return nil, customError
 		}
 	}
 
-	user.Balance += difference
+	// a retry is answered before the limits are checked, the original transaction already counts towards them
+	if transaction.IdempotencyKey != nil {
+		stored, err := u.repoClient.GetBalanceTransactionByIdempotencyKey(transaction.ClientID, *transaction.IdempotencyKey)
+		if err == nil {
+			return stored, nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
 
-	if err := u.repoClient.UpdateClient(user); err != nil {
+	if transaction.Type == constants.BalanceTransactionPurchase {
+		if customError := u.checkDietaryRestrictions(transaction); customError != nil {
+			return nil, customError
+		}
+		if customError := u.checkSpendingLimits(transaction); customError != nil {
+			return nil, customError
+		}
+	}
+
+	// corrections fix mistakes, e.g. take back a top-up made to the wrong client, even if that overdraws the client
+	stored, err := u.repoClient.CreateBalanceTransaction(transaction, transaction.Type != constants.BalanceTransactionCorrection)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else if errors.Is(err, customErr.CreditLimitExceeded) {
+			return nil, u.creditLimitExceededError(transaction.ClientID)
+		} else if ok, _ := customErr.IsDuplicateKeyError(err); ok && transaction.IdempotencyKey != nil {
+			return nil, customErr.NewCustomError(err, customErr.IdempotencyKeyInProgress.Error(), http.StatusConflict)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return stored, nil
+}
+
+// creditLimitExceededError returns the error of a charge refused because of the client's credit limit,
+// telling how much the client can still spend.
+func (u *ClientUseCase) creditLimitExceededError(clientID uint) *customErr.CustomError {
+	available, err := u.repoClient.GetAvailableBalance(clientID)
+	if err != nil {
 		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
 	}
 
+	return customErr.NewCustomError(customErr.CreditLimitExceeded,
+		fmt.Sprintf("%s, %s available", customErr.CreditLimitExceeded.Error(), max(available, 0)), http.StatusPaymentRequired)
+}
+
+// SetClientCreditLimit sets how far below zero the client's balance may go, overriding the client's category.
+// With nil, the category's credit limit applies to the client again.
+func (u *ClientUseCase) SetClientCreditLimit(clientID uint, creditLimit *money.Money) *customErr.CustomError {
+	if err := u.repoClient.SetClientCreditLimit(clientID, creditLimit); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
 	return nil
 }
 
+// requestedAmount returns the amount a transaction was requested for, before a purchase's discount and subsidy.
+func requestedAmount(transaction *models.BalanceTransaction) money.Money {
+	if transaction.GrossAmount != nil {
+		return -*transaction.GrossAmount
+	}
+
+	return transaction.Amount
+}
+
+func (u *ClientUseCase) GetBalanceTransactions(clientID uint, filter *models.BalanceTransactionFilter) (*[]models.BalanceTransaction, *customErr.CustomError) {
+	if _, err := u.repoClient.GetClientByID(clientID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	transactions, err := u.repoClient.GetBalanceTransactionsByClientID(clientID, filter)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return transactions, nil
+}
+
 func (u *ClientUseCase) CreateClientCategory(clientCategory *models.ClientCategory) (uint, *customErr.CustomError) {
 	id, err := u.repoClient.CreateClientCategory(clientCategory)
 	if err != nil {
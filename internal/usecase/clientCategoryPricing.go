@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/money"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+// CreateClientCategoryPricing adds a version of the category's pricing rules. It cannot take effect in the past,
+// and one taking effect today is in effect at once. Every dish on its price list has to exist.
+func (u *ClientUseCase) CreateClientCategoryPricing(pricing *models.ClientCategoryPricing, operator *models.Operator) (uint, *customErr.CustomError) {
+	if _, err := u.repoClient.GetClientCategoryByID(pricing.ClientCategoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, customErr.NewCustomError(err, customErr.ClientCategoryNotFound.Error(), http.StatusNotFound)
+		} else {
+			return 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	now := time.Now()
+	if pricing.EffectiveFrom.Before(helpers.StartOfDay(now)) {
+		return 0, customErr.NewCustomError(customErr.EffectiveDateInPast, customErr.EffectiveDateInPast.Error(), http.StatusBadRequest)
+	} else if pricing.EffectiveFrom.Before(now) {
+		pricing.EffectiveFrom = now
+	}
+
+	if len(pricing.Prices) > 0 {
+		dishIDs := make([]uint, len(pricing.Prices))
+		for i, price := range pricing.Prices {
+			dishIDs[i] = price.DishID
+		}
+		dishes, err := u.repoDish.GetDishesByIDs(dishIDs)
+		if err != nil {
+			return 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		} else if len(*dishes) != len(dishIDs) {
+			return 0, customErr.NewCustomError(customErr.DishNotFound, customErr.DishNotFound.Error(), http.StatusNotFound)
+		}
+	}
+
+	pricing.UserID = operator.UserID
+	pricing.CreatedAt = now
+
+	id, err := u.repoClient.CreateClientCategoryPricing(pricing)
+	if err != nil {
+		if ok, _ := customErr.IsDuplicateKeyError(err); ok {
+			return 0, customErr.NewCustomError(err, customErr.ClientCategoryPricingAlreadyExists.Error(), http.StatusConflict)
+		} else {
+			return 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return id, nil
+}
+
+func (u *ClientUseCase) GetClientCategoryPricings(clientCategoryID uint) (*[]models.ClientCategoryPricing, *customErr.CustomError) {
+	if _, err := u.repoClient.GetClientCategoryByID(clientCategoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.ClientCategoryNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	pricings, err := u.repoClient.GetClientCategoryPricings(clientCategoryID)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return pricings, nil
+}
+
+// DeleteClientCategoryPricing deletes a version of the category's pricing rules that has not come into effect yet.
+func (u *ClientUseCase) DeleteClientCategoryPricing(clientCategoryID, pricingID uint) *customErr.CustomError {
+	pricing, err := u.repoClient.GetClientCategoryPricingByID(pricingID)
+	if err != nil || pricing.ClientCategoryID != clientCategoryID {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.ClientCategoryPricingNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	// the version may have come into effect since it was read
+	if err := u.repoClient.DeleteClientCategoryPricing(pricingID, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.ClientCategoryPricingInEffect.Error(), http.StatusConflict)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// priceBalanceTransaction prices a purchase under the pricing rules of the client's category in effect at the time
+// of the purchase, setting its gross amount, discount, subsidy and, finally, the amount charged. A purchase of dishes
+// costs what the category's price list or, for dishes not on it, the menu says; otherwise the amount given is the gross amount.
+func (u *ClientUseCase) priceBalanceTransaction(transaction *models.BalanceTransaction) *customErr.CustomError {
+	client, err := u.repoClient.GetClientByID(transaction.ClientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	pricing, err := u.repoClient.GetClientCategoryPricingAt(client.ClientCategoryID, transaction.CreatedAt)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		pricing = nil
+	} else if err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	gross := -transaction.Amount
+	if len(transaction.Items) > 0 {
+		var customError *customErr.CustomError
+		if gross, customError = u.priceBalanceTransactionItems(transaction.Items, pricing); customError != nil {
+			return customError
+		}
+	}
+	transaction.GrossAmount = &gross
+
+	if pricing == nil {
+		transaction.Amount = -gross
+		return nil
+	}
+	transaction.ClientCategoryPricingID = &pricing.ID
+	transaction.Discount = gross.Mul(pricing.DiscountPercent / 100)
+
+	net := gross - transaction.Discount
+	subsidy := pricing.Subsidy
+	if pricing.SubsidyPeriod == constants.SubsidyPerDay {
+		used, err := u.repoClient.GetSubsidySince(client.ID, helpers.StartOfDay(transaction.CreatedAt))
+		if err != nil {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+		subsidy = max(subsidy-used, 0)
+	}
+	transaction.Subsidy = min(subsidy, net)
+	transaction.Amount = -(net - transaction.Subsidy)
+
+	return nil
+}
+
+// priceBalanceTransactionItems sets the unit prices of the dishes bought and returns their total.
+func (u *ClientUseCase) priceBalanceTransactionItems(items []models.BalanceTransactionItem, pricing *models.ClientCategoryPricing) (money.Money, *customErr.CustomError) {
+	dishIDs := make([]uint, len(items))
+	for i, item := range items {
+		dishIDs[i] = item.DishID
+	}
+	dishes, err := u.repoDish.GetDishesByIDs(dishIDs)
+	if err != nil {
+		return 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	dishesByID := make(map[uint]models.Dish, len(*dishes))
+	for _, dish := range *dishes {
+		dishesByID[dish.ID] = dish
+	}
+	categoryPrices := make(map[uint]money.Money)
+	if pricing != nil {
+		for _, price := range pricing.Prices {
+			categoryPrices[price.DishID] = price.Price
+		}
+	}
+
+	var total money.Money
+	for i, item := range items {
+		dish, ok := dishesByID[item.DishID]
+		if !ok {
+			return 0, customErr.NewCustomError(customErr.DishNotFound, customErr.DishNotFound.Error(), http.StatusNotFound)
+		} else if !dish.IsActive {
+			return 0, customErr.NewCustomError(customErr.DishInactive, customErr.DishInactive.Error(), http.StatusBadRequest)
+		}
+
+		price := dish.Price
+		if categoryPrice, ok := categoryPrices[item.DishID]; ok {
+			price = categoryPrice
+		}
+		items[i].UnitPrice = price
+		total += price.Mul(float64(item.Quantity))
+	}
+
+	return total, nil
+}
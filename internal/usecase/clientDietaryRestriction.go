@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/customErr"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+)
+
+func (u *ClientUseCase) GetClientDietaryRestrictions(clientID uint) (*models.DietaryRestrictions, *customErr.CustomError) {
+	restrictions, err := u.repoClient.GetClientDietaryRestrictions(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return restrictions, nil
+}
+
+// SetClientDietaryRestrictions replaces the allergens the client is allergic to and the client's diets.
+func (u *ClientUseCase) SetClientDietaryRestrictions(clientID uint, restrictions *models.DietaryRestrictions) *customErr.CustomError {
+	if customError := checkAllergensExist(u.repoIngredient, restrictions.AllergenIDs); customError != nil {
+		return customError
+	}
+
+	if err := u.repoClient.SetClientDietaryRestrictions(clientID, restrictions); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// CheckDietaryRestrictions returns the conflicts between the dishes and the client's dietary restrictions,
+// so that the cashier is warned before taking the payment.
+func (u *ClientUseCase) CheckDietaryRestrictions(clientID uint, items []models.BalanceTransactionItem) (*[]models.DietaryConflict, *customErr.CustomError) {
+	dishIDs := make([]uint, 0, len(items))
+	seen := make(map[uint]bool, len(items))
+	for _, item := range items {
+		if !seen[item.DishID] {
+			seen[item.DishID] = true
+			dishIDs = append(dishIDs, item.DishID)
+		}
+	}
+
+	conflicts, customError := u.findDietaryConflicts(clientID, dishIDs)
+	if customError != nil {
+		return nil, customError
+	}
+
+	return &conflicts, nil
+}
+
+// checkDietaryRestrictions refuses a purchase of dishes the client is allergic to, unless the cashier gave a reason
+// to sell them anyway, in which case every allergy overridden is recorded with the purchase. Dishes that do not fit
+// the client's diets do not stop the purchase, the conflicts are returned with it as warnings.
+func (u *ClientUseCase) checkDietaryRestrictions(transaction *models.BalanceTransaction) *customErr.CustomError {
+	if len(transaction.Items) == 0 {
+		return nil
+	}
+
+	dishIDs := make([]uint, len(transaction.Items))
+	for i, item := range transaction.Items {
+		dishIDs[i] = item.DishID
+	}
+
+	conflicts, customError := u.findDietaryConflicts(transaction.ClientID, dishIDs)
+	if customError != nil {
+		return customError
+	}
+
+	var blocking []string
+	for _, conflict := range conflicts {
+		if conflict.Blocking {
+			blocking = append(blocking, fmt.Sprintf("%s contains %s", conflict.DishName, conflict.Restriction))
+		}
+	}
+
+	reason := strings.TrimSpace(transaction.DietaryOverrideReason)
+	if len(blocking) > 0 && reason == "" {
+		return customErr.NewCustomError(customErr.DietaryConflict,
+			fmt.Sprintf("%s: %s", customErr.DietaryConflict.Error(), strings.Join(blocking, ", ")), http.StatusConflict)
+	}
+
+	transaction.DietaryConflicts = conflicts
+	for _, conflict := range conflicts {
+		if conflict.Blocking {
+			transaction.DietaryOverrides = append(transaction.DietaryOverrides, models.DietaryOverride{
+				DishID:      conflict.DishID,
+				Restriction: conflict.Restriction,
+				Reason:      reason,
+				UserID:      transaction.UserID,
+				APIKeyID:    transaction.APIKeyID,
+				CreatedAt:   transaction.CreatedAt,
+			})
+		}
+	}
+
+	return nil
+}
+
+// findDietaryConflicts matches the dishes, which are distinct, against the client's dietary restrictions.
+func (u *ClientUseCase) findDietaryConflicts(clientID uint, dishIDs []uint) ([]models.DietaryConflict, *customErr.CustomError) {
+	restrictions, customError := u.GetClientDietaryRestrictions(clientID)
+	if customError != nil {
+		return nil, customError
+	}
+
+	infos, err := u.repoDish.GetDishDietaryInfo(dishIDs)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	if len(*infos) != len(dishIDs) {
+		return nil, customErr.NewCustomError(customErr.DishNotFound, customErr.DishNotFound.Error(), http.StatusNotFound)
+	}
+
+	allergic := make(map[uint]bool, len(restrictions.AllergenIDs))
+	for _, id := range restrictions.AllergenIDs {
+		allergic[id] = true
+	}
+
+	conflicts := []models.DietaryConflict{}
+	for _, info := range *infos {
+		for _, allergen := range info.Allergens {
+			if allergic[allergen.ID] {
+				conflicts = append(conflicts, models.DietaryConflict{DishID: info.DishID, DishName: info.DishName, Restriction: allergen.Name, Blocking: true})
+			}
+		}
+		if restrictions.Vegetarian && !info.IsVegetarian {
+			conflicts = append(conflicts, models.DietaryConflict{DishID: info.DishID, DishName: info.DishName, Restriction: constants.DietVegetarian})
+		}
+		if restrictions.Halal && !info.IsHalal {
+			conflicts = append(conflicts, models.DietaryConflict{DishID: info.DishID, DishName: info.DishName, Restriction: constants.DietHalal})
+		}
+	}
+
+	return conflicts, nil
+}
+
+// GetDietaryOverrides returns the allergies cashiers overrode to sell dishes to the client, most recent first.
+func (u *ClientUseCase) GetDietaryOverrides(clientID uint) (*[]models.DietaryOverride, *customErr.CustomError) {
+	if _, customError := u.GetClientByID(clientID); customError != nil {
+		return nil, customError
+	}
+
+	overrides, err := u.repoClient.GetDietaryOverridesByClientID(clientID)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return overrides, nil
+}
@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/customErr"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// IssueClientIdentifier issues a card, QR code or RFID tag to the client.
+func (u *ClientUseCase) IssueClientIdentifier(identifier *models.ClientIdentifier, reason string, operator *models.Operator) (uint, *customErr.CustomError) {
+	if _, err := u.repoClient.GetClientByID(identifier.ClientID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	identifier.Value = normalizeIdentifierValue(identifier.Type, identifier.Value)
+	identifier.Status = constants.ClientIdentifierActive
+	identifier.CreatedAt = time.Now()
+	identifier.UpdatedAt = time.Now()
+
+	err := u.repoClient.CreateClientIdentifier(identifier, newClientIdentifierEvent(constants.ClientIdentifierEventIssued, identifier.Status, reason, operator))
+	if err != nil {
+		if ok, _ := customErr.IsDuplicateKeyError(err); ok {
+			return 0, customErr.NewCustomError(err, customErr.ClientIdentifierAlreadyExists.Error(), http.StatusConflict)
+		} else {
+			return 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return identifier.ID, nil
+}
+
+// LookUpClient finds the client an identifier presented at the till belongs to, with the client's category.
+// Lost and blocked identifiers are refused, so that they cannot be used to pay.
+func (u *ClientUseCase) LookUpClient(identifierType, value string) (*models.ClientLookup, *customErr.CustomError) {
+	identifier, err := u.repoClient.GetClientIdentifierByValue(identifierType, normalizeIdentifierValue(identifierType, value))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.ClientIdentifierNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	switch identifier.Status {
+	case constants.ClientIdentifierLost:
+		return nil, customErr.NewCustomError(customErr.ClientIdentifierLost, customErr.ClientIdentifierLost.Error(), http.StatusForbidden)
+	case constants.ClientIdentifierBlocked:
+		return nil, customErr.NewCustomError(customErr.ClientIdentifierBlocked, customErr.ClientIdentifierBlocked.Error(), http.StatusForbidden)
+	}
+
+	client, err := u.repoClient.GetClientByID(identifier.ClientID)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	category, err := u.repoClient.GetClientCategoryByID(client.ClientCategoryID)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return &models.ClientLookup{Client: client, Category: category, Identifier: identifier}, nil
+}
+
+func (u *ClientUseCase) GetClientIdentifiers(clientID uint) (*[]models.ClientIdentifier, *customErr.CustomError) {
+	if _, err := u.repoClient.GetClientByID(clientID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	identifiers, err := u.repoClient.GetClientIdentifiersByClientID(clientID)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return identifiers, nil
+}
+
+// SetClientIdentifierStatus reports the client's identifier lost, blocks it or activates it again.
+// An identifier that was replaced cannot be activated again.
+func (u *ClientUseCase) SetClientIdentifierStatus(clientID, identifierID uint, status, reason string, operator *models.Operator) *customErr.CustomError {
+	identifier, customError := u.getClientIdentifier(clientID, identifierID)
+	if customError != nil {
+		return customError
+	}
+
+	if identifier.Status == status {
+		return nil
+	} else if status == constants.ClientIdentifierActive && identifier.ReplacedByID != nil {
+		return customErr.NewCustomError(customErr.ClientIdentifierReplaced, customErr.ClientIdentifierReplaced.Error(), http.StatusConflict)
+	}
+
+	identifier.Status = status
+	identifier.UpdatedAt = time.Now()
+
+	err := u.repoClient.UpdateClientIdentifierStatus(identifier, newClientIdentifierEvent(constants.ClientIdentifierEventStatusChanged, status, reason, operator))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.ClientIdentifierNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// ReplaceClientIdentifier issues a new identifier of the same type in place of the client's identifier,
+// which is reported lost or blocked. Both identifiers' histories record the replacement.
+func (u *ClientUseCase) ReplaceClientIdentifier(clientID, identifierID uint, status, value, reason string, operator *models.Operator) (*models.ClientIdentifier, *customErr.CustomError) {
+	identifier, customError := u.getClientIdentifier(clientID, identifierID)
+	if customError != nil {
+		return nil, customError
+	}
+
+	if identifier.ReplacedByID != nil {
+		return nil, customErr.NewCustomError(customErr.ClientIdentifierReplaced, customErr.ClientIdentifierReplaced.Error(), http.StatusConflict)
+	}
+
+	replacement := &models.ClientIdentifier{
+		ClientID:  clientID,
+		Type:      identifier.Type,
+		Value:     normalizeIdentifierValue(identifier.Type, value),
+		Status:    constants.ClientIdentifierActive,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	identifier.Status = status
+	identifier.UpdatedAt = time.Now()
+
+	err := u.repoClient.ReplaceClientIdentifier(identifier, replacement,
+		newClientIdentifierEvent(constants.ClientIdentifierEventReplaced, status, reason, operator),
+		newClientIdentifierEvent(constants.ClientIdentifierEventIssued, replacement.Status, reason, operator))
+	if err != nil {
+		if ok, _ := customErr.IsDuplicateKeyError(err); ok {
+			return nil, customErr.NewCustomError(err, customErr.ClientIdentifierAlreadyExists.Error(), http.StatusConflict)
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			// replaced concurrently
+			return nil, customErr.NewCustomError(err, customErr.ClientIdentifierReplaced.Error(), http.StatusConflict)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return replacement, nil
+}
+
+func (u *ClientUseCase) GetClientIdentifierHistory(clientID, identifierID uint) (*[]models.ClientIdentifierEvent, *customErr.CustomError) {
+	if _, customError := u.getClientIdentifier(clientID, identifierID); customError != nil {
+		return nil, customError
+	}
+
+	events, err := u.repoClient.GetClientIdentifierEvents(identifierID)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return events, nil
+}
+
+// getClientIdentifier returns the identifier, provided it belongs to the client.
+func (u *ClientUseCase) getClientIdentifier(clientID, identifierID uint) (*models.ClientIdentifier, *customErr.CustomError) {
+	identifier, err := u.repoClient.GetClientIdentifierByID(identifierID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.ClientIdentifierNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if identifier.ClientID != clientID {
+		return nil, customErr.NewCustomError(customErr.ClientIdentifierNotFound, customErr.ClientIdentifierNotFound.Error(), http.StatusNotFound)
+	}
+
+	return identifier, nil
+}
+
+func newClientIdentifierEvent(event, status, reason string, operator *models.Operator) *models.ClientIdentifierEvent {
+	return &models.ClientIdentifierEvent{
+		Event:     event,
+		Status:    status,
+		UserID:    operator.UserID,
+		APIKeyID:  operator.APIKeyID,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+}
+
+// normalizeIdentifierValue makes card numbers and RFID UIDs match however the reader formats them,
+// e.g. "04:a2:1b:9c" and "04A21B9C". QR payloads are taken as they are.
+func normalizeIdentifierValue(identifierType, value string) string {
+	value = strings.TrimSpace(value)
+	if identifierType == constants.ClientIdentifierQR {
+		return value
+	}
+
+	return strings.ToUpper(strings.NewReplacer(" ", "", ":", "", "-", "").Replace(value))
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/customErr"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+)
+
+// GetClientCategoryIDsByName resolves client category names to their IDs. Names of categories
+// that do not exist are left out of the result.
+func (u *ClientUseCase) GetClientCategoryIDsByName(names []string) (map[string]uint, *customErr.CustomError) {
+	ids := make(map[string]uint)
+
+	for _, name := range names {
+		if _, ok := ids[name]; ok || name == "" {
+			continue
+		}
+
+		clientCategory, err := u.repoClient.GetClientCategoryByName(name)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			} else {
+				return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+			}
+		}
+		ids[name] = clientCategory.ID
+	}
+
+	return ids, nil
+}
+
+// ImportClients creates the clients of all rows without an error in one transaction, recording their opening
+// balances like CreateClient. Rows with an email address that an existing client or an earlier row already has
+// get an error and are skipped. A dry run only checks the rows. It returns the number of clients created.
+func (u *ClientUseCase) ImportClients(rows []*models.ClientImportRow, dryRun bool, operator *models.Operator) (int, *customErr.CustomError) {
+	var emails []string
+	for _, row := range rows {
+		if row.Error == "" {
+			emails = append(emails, row.Client.Email)
+		}
+	}
+	if len(emails) == 0 {
+		return 0, nil
+	}
+
+	existingClients, err := u.repoClient.GetClientsByEmails(emails)
+	if err != nil {
+		return 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+	takenEmails := make(map[string]bool, len(*existingClients))
+	for _, client := range *existingClients {
+		takenEmails[strings.ToLower(client.Email)] = true
+	}
+
+	var clients []*models.Client
+	var openingBalances []*models.BalanceTransaction
+	for _, row := range rows {
+		if row.Error != "" {
+			continue
+		}
+
+		email := strings.ToLower(row.Client.Email)
+		if takenEmails[email] {
+			row.Error = customErr.EmailAlreadyExists.Error()
+			continue
+		}
+		takenEmails[email] = true
+
+		clients = append(clients, row.Client)
+		openingBalances = append(openingBalances, openingBalanceTransaction(row.Client, operator))
+	}
+
+	if dryRun || len(clients) == 0 {
+		return 0, nil
+	}
+
+	if err := u.repoClient.CreateClients(clients, openingBalances); err != nil {
+		// a client with one of the email addresses was created since they were checked
+		if ok, _ := customErr.IsDuplicateKeyError(err); ok {
+			return 0, customErr.NewCustomError(err, customErr.EmailAlreadyExists.Error(), http.StatusConflict)
+		} else {
+			return 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return len(clients), nil
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/money"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+// SetClientSpendingLimits replaces the client's spending limits.
+func (u *ClientUseCase) SetClientSpendingLimits(clientID uint, limits *models.SpendingLimits) *customErr.CustomError {
+	if err := u.repoClient.SetClientSpendingLimits(clientID, limits); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// checkSpendingLimits refuses a purchase that would exceed one of the client's spending limits,
+// telling how much the client can still spend under the tightest of them.
+func (u *ClientUseCase) checkSpendingLimits(transaction *models.BalanceTransaction) *customErr.CustomError {
+	client, err := u.repoClient.GetClientByID(transaction.ClientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	limits := client.SpendingLimits
+	periods := []struct {
+		name  string
+		limit *money.Money
+		since func(time.Time) time.Time
+	}{
+		{"per-transaction", limits.Transaction, nil},
+		{"daily", limits.Daily, helpers.StartOfDay},
+		{"weekly", limits.Weekly, helpers.StartOfWeek},
+	}
+
+	charge := -transaction.Amount
+	for _, period := range periods {
+		if period.limit == nil {
+			continue
+		}
+
+		allowance := *period.limit
+		if period.since != nil {
+			spent, err := u.repoClient.GetSpendingSince(client.ID, period.since(transaction.CreatedAt))
+			if err != nil {
+				return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+			}
+			allowance = max(allowance-spent, 0)
+		}
+
+		if charge > allowance {
+			return customErr.NewCustomError(customErr.SpendingLimitExceeded,
+				fmt.Sprintf("%s, %s left of the %s limit", customErr.SpendingLimitExceeded.Error(), allowance, period.name), http.StatusPaymentRequired)
+		}
+	}
+
+	return nil
+}
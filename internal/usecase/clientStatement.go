@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/customErr"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+// GetClientStatement returns the client's statement of the period from `from` up to, not including, `to`.
+func (u *ClientUseCase) GetClientStatement(clientID uint, from, to time.Time) (*models.Statement, *customErr.CustomError) {
+	if to.Before(from) {
+		return nil, customErr.NewCustomError(customErr.StatementPeriodInvalid, customErr.StatementPeriodInvalid.Error(), http.StatusBadRequest)
+	}
+
+	client, err := u.repoClient.GetClientByID(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	clientCategory, err := u.repoClient.GetClientCategoryByID(client.ClientCategoryID)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return u.buildStatement(client, clientCategory.Name, from, to)
+}
+
+// GetClientCategoryStatements returns the statements of the period of every client of the category, ordered by name.
+func (u *ClientUseCase) GetClientCategoryStatements(clientCategoryID uint, from, to time.Time) (*[]models.Statement, *customErr.CustomError) {
+	if to.Before(from) {
+		return nil, customErr.NewCustomError(customErr.StatementPeriodInvalid, customErr.StatementPeriodInvalid.Error(), http.StatusBadRequest)
+	}
+
+	clientCategory, err := u.repoClient.GetClientCategoryByID(clientCategoryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.ClientCategoryNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	clients, err := u.repoClient.GetClientsByCategoryID(clientCategoryID)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	statements := make([]models.Statement, len(*clients))
+	for i := range *clients {
+		statement, customError := u.buildStatement(&(*clients)[i], clientCategory.Name, from, to)
+		if customError != nil {
+			return nil, customError
+		}
+		statements[i] = *statement
+	}
+
+	return &statements, nil
+}
+
+// buildStatement reads the client's ledger for the period. The closing balance is worked out from the transactions,
+// so a statement always adds up even if its period ends in the future.
+func (u *ClientUseCase) buildStatement(client *models.Client, clientCategoryName string, from, to time.Time) (*models.Statement, *customErr.CustomError) {
+	openingBalance, err := u.repoClient.GetBalanceBefore(client.ID, from)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	transactions, err := u.repoClient.GetBalanceTransactionsByClientID(client.ID, &models.BalanceTransactionFilter{From: from, To: to})
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	statement := &models.Statement{
+		Client:             *client,
+		ClientCategoryName: clientCategoryName,
+		From:               from,
+		To:                 to,
+		OpeningBalance:     openingBalance,
+		Transactions:       make([]models.BalanceTransaction, len(*transactions)),
+		ClosingBalance:     openingBalance,
+	}
+	// the ledger comes most recent first
+	for i, transaction := range *transactions {
+		statement.Transactions[len(*transactions)-1-i] = transaction
+		statement.ClosingBalance += transaction.Amount
+	}
+
+	return statement, nil
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/internal/repository"
+	"Canteen-Backend/pkg/customErr"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+type DishUseCase struct {
+	repoDish       repository.Dish
+	repoIngredient repository.Ingredient
+}
+
+func NewDishUseCase(repoDish repository.Dish, repoIngredient repository.Ingredient) *DishUseCase {
+	return &DishUseCase{repoDish: repoDish, repoIngredient: repoIngredient}
+}
+
+func (u *DishUseCase) CreateDish(dish *models.Dish) (uint, *customErr.CustomError) {
+	id, err := u.repoDish.CreateDish(dish)
+	if err != nil {
+		if ok, _ := customErr.IsDuplicateKeyError(err); ok {
+			return 0, customErr.NewCustomError(err, customErr.DishAlreadyExists.Error(), http.StatusConflict)
+		} else {
+			return 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return id, nil
+}
+
+func (u *DishUseCase) GetAllDishes() (*[]models.Dish, *customErr.CustomError) {
+	dishes, err := u.repoDish.GetAllDishes()
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return dishes, nil
+}
+
+func (u *DishUseCase) GetDishByID(id uint) (*models.Dish, *customErr.CustomError) {
+	dish, err := u.repoDish.GetDishByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.DishNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return dish, nil
+}
+
+func (u *DishUseCase) UpdateDish(dish *models.Dish) *customErr.CustomError {
+	dish.UpdatedAt = time.Now()
+
+	if err := u.repoDish.UpdateDish(dish); err != nil {
+		if ok, _ := customErr.IsDuplicateKeyError(err); ok {
+			return customErr.NewCustomError(err, customErr.DishAlreadyExists.Error(), http.StatusConflict)
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.DishNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// GetDishDietaryInfo returns the allergens the dish contains and whether it is vegetarian and halal, going by its ingredients.
+func (u *DishUseCase) GetDishDietaryInfo(id uint) (*models.DishDietaryInfo, *customErr.CustomError) {
+	infos, err := u.repoDish.GetDishDietaryInfo([]uint{id})
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	if len(*infos) == 0 {
+		return nil, customErr.NewCustomError(customErr.DishNotFound, customErr.DishNotFound.Error(), http.StatusNotFound)
+	}
+
+	return &(*infos)[0], nil
+}
+
+// SetDishIngredients replaces the ingredients the dish is made of, which its dietary info is worked out from.
+func (u *DishUseCase) SetDishIngredients(id uint, ingredientIDs []uint) *customErr.CustomError {
+	if _, err := u.repoDish.GetDishByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.DishNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if len(ingredientIDs) > 0 {
+		ingredients, err := u.repoIngredient.GetIngredientsByIDs(ingredientIDs)
+		if err != nil {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+
+		if len(*ingredients) != len(ingredientIDs) {
+			return customErr.NewCustomError(customErr.IngredientNotFound, customErr.IngredientNotFound.Error(), http.StatusNotFound)
+		}
+	}
+
+	if err := u.repoDish.SetDishIngredients(id, ingredientIDs); err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/internal/repository"
+	"errors"
+	"gorm.io/gorm"
+	"sync"
+)
+
+// The in-memory repositories of the UserUseCase tests. Each implements the methods the tests need,
+// any other method panics.
+
+type memUsers struct {
+	repository.User
+	mu     sync.Mutex
+	users  map[uint]*models.User
+	nextID uint
+}
+
+// newMemUsers stores the users, numbering them from 1.
+func newMemUsers(users ...*models.User) *memUsers {
+	r := &memUsers{users: map[uint]*models.User{}}
+	for _, user := range users {
+		if _, err := r.create(user); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// create stores a new user, with the unique constraints of the user table.
+func (r *memUsers) create(user *models.User) (uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, other := range r.users {
+		if other.Username == user.Username {
+			return 0, errors.New(`duplicate key value violates unique constraint "user_username_key"`)
+		}
+		if user.Email != "" && other.Email == user.Email {
+			return 0, errors.New(`duplicate key value violates unique constraint "user_email_key"`)
+		}
+	}
+
+	r.nextID++
+	user.ID = r.nextID
+	stored := *user
+	r.users[user.ID] = &stored
+	return user.ID, nil
+}
+
+func (r *memUsers) GetUserByID(id uint) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *user
+	return &found, nil
+}
+
+func (r *memUsers) GetUserByEmail(email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// UpdateUser updates the role, the only field the tests change this way.
+func (r *memUsers) UpdateUser(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if user.UserRoleID != 0 {
+		stored.UserRoleID = user.UserRoleID
+	}
+	return nil
+}
+
+func (r *memUsers) EnableTOTP(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	user.TOTPEnabled = true
+	return nil
+}
+
+func (r *memUsers) SetPassword(id uint, hashedPassword string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	user.Password = hashedPassword
+	return nil
+}
+
+func (r *memUsers) ResetFailedSignInAttempts(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	user.FailedSignInAttempts = 0
+	user.LastFailedSignInAt = nil
+	user.LockedUntil = nil
+	return nil
+}
+
+type memSessions struct {
+	repository.Session
+	mu      sync.Mutex
+	created []*models.Session
+	// the users whose sessions were revoked
+	revoked []uint
+}
+
+func (r *memSessions) CreateSession(session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.created = append(r.created, session)
+	return nil
+}
+
+func (r *memSessions) RevokeSessionsByUserID(userID uint, exceptFamilyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+type memSignInAttempts struct {
+	repository.SignInAttempt
+	mu       sync.Mutex
+	attempts []*models.SignInAttempt
+	// the usernames whose failed attempts were forgotten
+	forgotten []string
+}
+
+func (r *memSignInAttempts) CreateSignInAttempt(attempt *models.SignInAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func (r *memSignInAttempts) DeleteFailedSignInAttemptsByUsername(username string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.forgotten = append(r.forgotten, username)
+	return nil
+}
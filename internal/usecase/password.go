@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/auth"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/logger"
+	"Canteen-Backend/pkg/mailer"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ForgotPassword emails a password reset link to the active user with the given email address.
+// To avoid revealing which email addresses belong to users, it succeeds whether or not there is one,
+// and the email is sent in the background.
+func (u *UserUseCase) ForgotPassword(email string) *customErr.CustomError {
+	user, err := u.userRepo.GetUserByEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if !user.IsActive {
+		return nil
+	}
+
+	resetToken, err := auth.GeneratePasswordResetToken()
+	if err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	tokenDuration := helpers.GetEnvDuration("PASSWORD_RESET_TOKEN_DURATION", time.Hour)
+	err = u.passwordResetTokenRepo.CreatePasswordResetToken(&models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: auth.HashPasswordResetToken(resetToken),
+		ExpiresAt: time.Now().Add(tokenDuration),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	message := passwordResetMessage(user, resetToken, tokenDuration)
+	go func() {
+		if err := u.mailer.Send(message); err != nil {
+			logger.GetLogger().Error("error sending password reset email", zap.Uint("user_id", user.ID), zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+func passwordResetMessage(user *models.User, resetToken string, tokenDuration time.Duration) *mailer.Message {
+	resetURL := helpers.GetEnv("PASSWORD_RESET_URL", "http://localhost:8080/reset-password") + "?token=" + url.QueryEscape(resetToken)
+
+	return &mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your Canteen password",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"someone asked to reset the password of your Canteen account %s. To choose a new password, open\n\n"+
+			"%s\n\n"+
+			"The link can be used once and expires in %s. If you did not ask for it, you can ignore this email.\n",
+			user.FirstName, user.Username, resetURL, tokenDuration),
+	}
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword. Since whoever resets the password
+// controls the user's email, all sessions are revoked and a sign-in lockout is lifted.
+func (u *UserUseCase) ResetPassword(resetToken, newPassword string) *customErr.CustomError {
+	userID, err := u.passwordResetTokenRepo.UsePasswordResetToken(auth.HashPasswordResetToken(resetToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.PasswordResetTokenInvalid.Error(), http.StatusBadRequest)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if customError := u.setPassword(userID, newPassword); customError != nil {
+		return customError
+	}
+
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+	if customError := u.resetSignInThrottle(user); customError != nil {
+		return customError
+	}
+
+	return u.RevokeAllSessions(userID, "")
+}
+
+// ChangePasswordOnSignIn sets the new password of a user who has to change it before signing in,
+// and finishes the sign-in.
+func (u *UserUseCase) ChangePasswordOnSignIn(challengeToken, newPassword string, metadata *models.SessionMetadata) (*models.SignInResult, *customErr.CustomError) {
+	user, customError := u.getChallengedUser(challengeToken, auth.ChallengePasswordChange, metadata)
+	if customError != nil {
+		return nil, customError
+	}
+
+	// the password was changed in some other way after the challenge had been issued
+	if !user.MustChangePassword {
+		return nil, customErr.NewCustomError(customErr.SignInChallengeInvalid, customErr.SignInChallengeInvalid.Error(), http.StatusUnauthorized)
+	}
+
+	if err := helpers.CheckPassword(newPassword, user.Password); err == nil {
+		return nil, customErr.NewCustomError(customErr.PasswordUnchanged, customErr.PasswordUnchanged.Error(), http.StatusBadRequest)
+	}
+
+	if customError := u.setPassword(user.ID, newPassword); customError != nil {
+		return nil, customError
+	}
+	user.MustChangePassword = false
+
+	return u.completeSignIn(user, metadata)
+}
+
+// SetMustChangePassword makes the user choose a new password on the next sign-in, or stops requiring it.
+func (u *UserUseCase) SetMustChangePassword(id uint, mustChangePassword bool) *customErr.CustomError {
+	if err := u.userRepo.SetMustChangePassword(id, mustChangePassword); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.UserNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// setPassword hashes and stores a password the user has chosen, which also satisfies a required password change.
+func (u *UserUseCase) setPassword(userID uint, password string) *customErr.CustomError {
+	hashedPassword, err := helpers.HashPassword(password)
+	if err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	if err := u.userRepo.SetPassword(userID, hashedPassword); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.UserNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
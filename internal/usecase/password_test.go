@@ -0,0 +1,241 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/mailer"
+	"gorm.io/gorm"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMailer records the messages sent, instead of sending them.
+type recordingMailer struct {
+	messages chan *mailer.Message
+}
+
+func newRecordingMailer() *recordingMailer {
+	return &recordingMailer{messages: make(chan *mailer.Message, 10)}
+}
+
+func (m *recordingMailer) Send(message *mailer.Message) error {
+	m.messages <- message
+	return nil
+}
+
+// next waits for the next message, which is sent in the background.
+func (m *recordingMailer) next(t *testing.T) *mailer.Message {
+	t.Helper()
+
+	select {
+	case message := <-m.messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email was sent")
+		return nil
+	}
+}
+
+// memPasswordResetTokens keeps tokens in memory like PasswordResetTokenPostgres: a new token
+// invalidates the user's earlier ones, and a token is used once before it expires.
+type memPasswordResetTokens struct {
+	mu     sync.Mutex
+	tokens []*models.PasswordResetToken
+}
+
+func (r *memPasswordResetTokens) CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, earlier := range r.tokens {
+		if earlier.UserID == token.UserID && earlier.UsedAt == nil {
+			earlier.UsedAt = &now
+		}
+	}
+	stored := *token
+	r.tokens = append(r.tokens, &stored)
+	return nil
+}
+
+func (r *memPasswordResetTokens) UsePasswordResetToken(tokenHash string) (uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash && token.UsedAt == nil && token.ExpiresAt.After(now) {
+			token.UsedAt = &now
+			return token.UserID, nil
+		}
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+func (r *memPasswordResetTokens) DeleteExpiredPasswordResetTokens(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// expire lets all tokens expire, as if the token duration had passed.
+func (r *memPasswordResetTokens) expire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		token.ExpiresAt = time.Now().Add(-time.Second)
+	}
+}
+
+type passwordResetTest struct {
+	useCase        *UserUseCase
+	users          *memUsers
+	tokens         *memPasswordResetTokens
+	sessions       *memSessions
+	signInAttempts *memSignInAttempts
+	mailer         *recordingMailer
+}
+
+func newPasswordResetTest(t *testing.T) *passwordResetTest {
+	t.Helper()
+
+	password, err := helpers.HashPassword("old password")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	lockedUntil := time.Now().Add(time.Hour)
+
+	tt := &passwordResetTest{
+		users: newMemUsers(
+			&models.User{Username: "alice", Email: "alice@example.com", FirstName: "Alice", Password: password, IsActive: true,
+				FailedSignInAttempts: 5, LockedUntil: &lockedUntil},
+			&models.User{Username: "bob", Email: "bob@example.com", Password: password, IsActive: false},
+		),
+		tokens:         &memPasswordResetTokens{},
+		sessions:       &memSessions{},
+		signInAttempts: &memSignInAttempts{},
+		mailer:         newRecordingMailer(),
+	}
+	tt.useCase = NewUserUseCase(tt.users, nil, tt.sessions, tt.signInAttempts, nil, tt.tokens, nil, nil, tt.mailer, nil)
+
+	return tt
+}
+
+var resetTokenPattern = regexp.MustCompile(`\?token=(\S+)`)
+
+// forgotPassword asks for a reset link for the email address and returns the token it was emailed with.
+func (tt *passwordResetTest) forgotPassword(t *testing.T, email string) string {
+	t.Helper()
+
+	if customError := tt.useCase.ForgotPassword(email); customError != nil {
+		t.Fatalf("ForgotPassword() error = %v", customError.Error)
+	}
+
+	message := tt.mailer.next(t)
+	if len(message.To) != 1 || message.To[0] != email {
+		t.Fatalf("email sent to %v, want %s", message.To, email)
+	}
+	match := resetTokenPattern.FindStringSubmatch(message.Body)
+	if match == nil {
+		t.Fatalf("email has no reset link:\n%s", message.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatalf("invalid reset link token %q: %v", match[1], err)
+	}
+	return token
+}
+
+func assertResetTokenInvalid(t *testing.T, customError *customErr.CustomError) {
+	t.Helper()
+
+	if customError == nil || customError.StatusCode != http.StatusBadRequest || customError.Message != customErr.PasswordResetTokenInvalid.Error() {
+		t.Fatalf("ResetPassword() error = %v, want %v", customError, customErr.PasswordResetTokenInvalid)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	tt := newPasswordResetTest(t)
+	token := tt.forgotPassword(t, "alice@example.com")
+
+	if customError := tt.useCase.ResetPassword(token, "new password"); customError != nil {
+		t.Fatalf("ResetPassword() error = %v", customError.Error)
+	}
+
+	user, _ := tt.users.GetUserByID(1)
+	if err := helpers.CheckPassword("new password", user.Password); err != nil {
+		t.Fatalf("new password does not match: %v", err)
+	}
+	if len(tt.sessions.revoked) != 1 || tt.sessions.revoked[0] != 1 {
+		t.Fatalf("revoked the sessions of users %v, want [1]", tt.sessions.revoked)
+	}
+	if user.LockedUntil != nil || len(tt.signInAttempts.forgotten) != 1 || tt.signInAttempts.forgotten[0] != "alice" {
+		t.Fatalf("lockout until %v and forgotten failed attempts of %v, want the lockout lifted for alice", user.LockedUntil, tt.signInAttempts.forgotten)
+	}
+}
+
+func TestResetPasswordTokenIsSingleUse(t *testing.T) {
+	tt := newPasswordResetTest(t)
+	token := tt.forgotPassword(t, "alice@example.com")
+
+	if customError := tt.useCase.ResetPassword(token, "new password"); customError != nil {
+		t.Fatalf("ResetPassword() error = %v", customError.Error)
+	}
+	assertResetTokenInvalid(t, tt.useCase.ResetPassword(token, "another password"))
+
+	user, _ := tt.users.GetUserByID(1)
+	if err := helpers.CheckPassword("new password", user.Password); err != nil {
+		t.Fatalf("password changed by a used token: %v", err)
+	}
+}
+
+func TestResetPasswordOnlyLatestTokenWorks(t *testing.T) {
+	tt := newPasswordResetTest(t)
+	first := tt.forgotPassword(t, "alice@example.com")
+	second := tt.forgotPassword(t, "alice@example.com")
+
+	assertResetTokenInvalid(t, tt.useCase.ResetPassword(first, "new password"))
+	if customError := tt.useCase.ResetPassword(second, "new password"); customError != nil {
+		t.Fatalf("ResetPassword() error = %v", customError.Error)
+	}
+}
+
+func TestResetPasswordTokenExpires(t *testing.T) {
+	tt := newPasswordResetTest(t)
+	token := tt.forgotPassword(t, "alice@example.com")
+	tt.tokens.expire()
+
+	assertResetTokenInvalid(t, tt.useCase.ResetPassword(token, "new password"))
+	if len(tt.sessions.revoked) != 0 {
+		t.Fatalf("revoked the sessions of users %v, want none", tt.sessions.revoked)
+	}
+}
+
+func TestResetPasswordUnknownToken(t *testing.T) {
+	tt := newPasswordResetTest(t)
+
+	assertResetTokenInvalid(t, tt.useCase.ResetPassword("not a token", "new password"))
+}
+
+func TestForgotPasswordSendsNothingForUnknownOrInactiveUser(t *testing.T) {
+	tt := newPasswordResetTest(t)
+
+	for _, email := range []string{"nobody@example.com", "bob@example.com"} {
+		if customError := tt.useCase.ForgotPassword(email); customError != nil {
+			t.Fatalf("ForgotPassword(%s) error = %v, want nil", email, customError.Error)
+		}
+	}
+
+	select {
+	case message := <-tt.mailer.messages:
+		t.Fatalf("email sent to %v, want none", message.To)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if len(tt.tokens.tokens) != 0 {
+		t.Fatalf("created %d reset tokens, want none", len(tt.tokens.tokens))
+	}
+}
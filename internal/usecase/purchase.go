@@ -116,7 +116,7 @@ func (u *PurchaseUseCase) CreatePurchase(purchase *models.Purchase) (uint, *cust
 			Quantity:       ingredientInStorage.Quantity + purchasedIngredient.Amount,
 			ExpirationDate: purchasedIngredient.ExpirationDate,
 			PurchaseDate:   purchase.PurchaseDate,
-			UnitPrice:      ingredientInStorage.UnitPrice + purchasedIngredient.Cost/purchasedIngredient.Amount,
+			UnitPrice:      ingredientInStorage.UnitPrice + purchasedIngredient.Cost.Div(purchasedIngredient.Amount),
 		}
 
 		err = u.repoIngredient.UpdateIngredient(ingredient)
@@ -137,7 +137,7 @@ func (u *PurchaseUseCase) CreatePurchase(purchase *models.Purchase) (uint, *cust
 		purchasesIngredients.IngredientID = ingredient.ID
 		purchasesIngredients.Amount = ingredient.Amount
 		purchasesIngredients.Cost = ingredient.Cost
-		purchasesIngredients.CurrentUnitPrice = ingredient.Cost / ingredient.Amount
+		purchasesIngredients.CurrentUnitPrice = ingredient.Cost.Div(ingredient.Amount)
 		purchasesIngredientsSlice = append(purchasesIngredientsSlice, purchasesIngredients)
 	}
 
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/internal/repository"
+	"Canteen-Backend/pkg/customErr"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+type RoleUseCase struct {
+	roleRepo repository.Role
+}
+
+func NewRoleUseCase(roleRepo repository.Role) *RoleUseCase {
+	return &RoleUseCase{roleRepo: roleRepo}
+}
+
+func (u *RoleUseCase) CreateRole(role *models.UserRole) (uint, *customErr.CustomError) {
+	if customErr := u.checkPermissionsExist(role.Permissions); customErr != nil {
+		return 0, customErr
+	}
+
+	id, err := u.roleRepo.CreateRole(role)
+	if err != nil {
+		if ok, _ := customErr.IsDuplicateKeyError(err); ok {
+			return 0, customErr.NewCustomError(err, customErr.RoleAlreadyExists.Error(), http.StatusConflict)
+		} else {
+			return 0, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return id, nil
+}
+
+func (u *RoleUseCase) GetAllRoles() (*[]models.UserRole, *customErr.CustomError) {
+	roles, err := u.roleRepo.GetAllRoles()
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return roles, nil
+}
+
+func (u *RoleUseCase) GetRoleByID(id uint) (*models.UserRole, *customErr.CustomError) {
+	role, err := u.roleRepo.GetRoleByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.RoleNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return role, nil
+}
+
+// UpdateRole updates the role and, unless role.Permissions is nil, replaces its permission grants.
+// The admin role cannot be changed, so that there is always a role able to manage roles.
+func (u *RoleUseCase) UpdateRole(role *models.UserRole) *customErr.CustomError {
+	if customErr := u.checkRoleNotProtected(role.ID); customErr != nil {
+		return customErr
+	}
+
+	if customErr := u.checkPermissionsExist(role.Permissions); customErr != nil {
+		return customErr
+	}
+
+	role.UpdatedAt = time.Now()
+
+	if err := u.roleRepo.UpdateRole(role); err != nil {
+		if ok, _ := customErr.IsDuplicateKeyError(err); ok {
+			return customErr.NewCustomError(err, customErr.RoleAlreadyExists.Error(), http.StatusConflict)
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.RoleNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// DeleteRole deletes a role that is not assigned to any user or API key.
+func (u *RoleUseCase) DeleteRole(id uint) *customErr.CustomError {
+	if customErr := u.checkRoleNotProtected(id); customErr != nil {
+		return customErr
+	}
+
+	userCount, err := u.roleRepo.CountUsersByRoleID(id)
+	if err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+	apiKeyCount, err := u.roleRepo.CountAPIKeysByRoleID(id)
+	if err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+	if userCount > 0 || apiKeyCount > 0 {
+		return customErr.NewCustomError(customErr.RoleInUse, customErr.RoleInUse.Error(), http.StatusConflict)
+	}
+
+	if err := u.roleRepo.DeleteRole(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.RoleNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+func (u *RoleUseCase) GetAllPermissions() (*[]models.Permission, *customErr.CustomError) {
+	permissions, err := u.roleRepo.GetAllPermissions()
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return permissions, nil
+}
+
+// CheckPermissions makes sure the role is granted every one of the given permissions.
+func (u *RoleUseCase) CheckPermissions(userRoleID uint, permissions ...string) *customErr.CustomError {
+	grantedPermissions, err := u.roleRepo.GetPermissionsByRoleID(userRoleID)
+	if err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	granted := make(map[string]bool, len(grantedPermissions))
+	for _, permission := range grantedPermissions {
+		granted[permission] = true
+	}
+
+	for _, permission := range permissions {
+		if !granted[permission] {
+			return customErr.NewCustomError(customErr.PermissionDenied, customErr.PermissionDenied.Error(), http.StatusForbidden)
+		}
+	}
+
+	return nil
+}
+
+func (u *RoleUseCase) checkRoleNotProtected(id uint) *customErr.CustomError {
+	role, customError := u.GetRoleByID(id)
+	if customError != nil {
+		return customError
+	}
+
+	if role.Name == constants.AdminRoleName {
+		return customErr.NewCustomError(customErr.RoleProtected, customErr.RoleProtected.Error(), http.StatusForbidden)
+	}
+
+	return nil
+}
+
+func (u *RoleUseCase) checkPermissionsExist(permissions []string) *customErr.CustomError {
+	for _, permission := range permissions {
+		if _, ok := constants.Permissions[permission]; !ok {
+			return customErr.NewCustomError(customErr.PermissionNotFound, customErr.PermissionNotFound.Error()+": "+permission, http.StatusNotFound)
+		}
+	}
+
+	return nil
+}
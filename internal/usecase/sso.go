@@ -0,0 +1,279 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/auth"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/logger"
+	"Canteen-Backend/pkg/sso"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ssoTimeout bounds the requests to the provider made while starting or finishing a sign-in.
+const ssoTimeout = 10 * time.Second
+
+// StartOIDCSignIn begins a sign-in through the OpenID Connect provider. It returns the URL
+// to send the user to and the state, which the callback has to present to finish the sign-in.
+func (u *UserUseCase) StartOIDCSignIn() (string, string, *customErr.CustomError) {
+	if u.ssoProvider == nil {
+		return "", "", customErr.NewCustomError(customErr.SSONotConfigured, customErr.SSONotConfigured.Error(), http.StatusNotFound)
+	}
+
+	state, err := auth.GenerateOIDCState()
+	if err != nil {
+		return "", "", customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+	nonce, err := auth.GenerateOIDCState()
+	if err != nil {
+		return "", "", customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+	codeVerifier := oauth2.GenerateVerifier()
+
+	err = u.oidcStateRepo.CreateOIDCState(&models.OIDCState{
+		StateHash:    auth.HashOIDCState(state),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(helpers.GetEnvDuration("OIDC_STATE_DURATION", 10*time.Minute)),
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		return "", "", customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ssoTimeout)
+	defer cancel()
+
+	authURL, err := u.ssoProvider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return "", "", customErr.NewCustomError(err, customErr.SSOProviderUnavailable.Error(), http.StatusBadGateway)
+	}
+
+	return authURL, state, nil
+}
+
+// FinishOIDCSignIn redeems the authorization code from the provider's callback and starts a session
+// for the user the provider vouches for. The user's role follows the provider groups on every sign-in,
+// and a user without a mapped group is turned away. On the first sign-in the identity is linked to the
+// user with the same verified email address, or a new user is created. Users with two-factor authentication
+// enabled, or whose role requires it, are challenged for the local second factor like on a password sign-in,
+// since the provider's own sign-in may not have asked for one.
+func (u *UserUseCase) FinishOIDCSignIn(code, state string, metadata *models.SessionMetadata) (*models.SignInResult, *customErr.CustomError) {
+	if u.ssoProvider == nil {
+		return nil, customErr.NewCustomError(customErr.SSONotConfigured, customErr.SSONotConfigured.Error(), http.StatusNotFound)
+	}
+
+	oidcState, err := u.oidcStateRepo.UseOIDCState(auth.HashOIDCState(state))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.SSOStateInvalid.Error(), http.StatusBadRequest)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ssoTimeout)
+	defer cancel()
+
+	identity, err := u.ssoProvider.Exchange(ctx, code, oidcState.Nonce, oidcState.CodeVerifier)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.SSOSignInFailed.Error(), http.StatusUnauthorized)
+	}
+
+	roleName, ok := u.ssoProvider.RoleName(identity.Groups)
+	if !ok {
+		logger.GetLogger().Warn("security event: single sign-on denied to a user without a mapped group",
+			zap.String("subject", identity.Subject), zap.String("email", identity.Email), zap.Strings("groups", identity.Groups))
+		return nil, customErr.NewCustomError(customErr.SSONoRole, customErr.SSONoRole.Error(), http.StatusForbidden)
+	}
+
+	role, err := u.roleRepo.GetRoleByName(roleName)
+	if err != nil {
+		// the group mapping names a role that does not exist
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	user, userIdentity, customError := u.getOrCreateSSOUser(identity, role)
+	if customError != nil {
+		return nil, customError
+	}
+
+	if !user.IsActive {
+		return nil, customErr.NewCustomError(customErr.UserInactive, customErr.UserInactive.Error(), http.StatusForbidden)
+	}
+
+	if user.UserRoleID != role.ID {
+		if err := u.userRepo.UpdateUser(&models.User{ID: user.ID, UserRoleID: role.ID}); err != nil {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+		logger.GetLogger().Warn("security event: single sign-on changed the user's role to follow the provider groups",
+			zap.Uint("user_id", user.ID), zap.Uint("previous_role_id", user.UserRoleID), zap.String("role", role.Name),
+			zap.String("subject", identity.Subject), zap.Strings("groups", identity.Groups))
+		user.UserRoleID = role.ID
+	}
+
+	if err := u.userIdentityRepo.UpdateUserIdentityLastSignIn(userIdentity.ID); err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	if user.TOTPEnabled {
+		return u.challengeSignIn(user, auth.ChallengeTwoFactor)
+	}
+	if constants.TwoFactorRequiredRoles[role.Name] {
+		return u.challengeSignIn(user, auth.ChallengeTwoFactorSetup)
+	}
+
+	if customError := u.succeedSignIn(user, metadata); customError != nil {
+		return nil, customError
+	}
+
+	tokens, customError := u.createSession(user, nil, metadata)
+	if customError != nil {
+		return nil, customError
+	}
+
+	return &models.SignInResult{Token: tokens}, nil
+}
+
+// getOrCreateSSOUser returns the user linked to the identity, linking or creating one on the first sign-in.
+func (u *UserUseCase) getOrCreateSSOUser(identity *sso.Identity, role *models.UserRole) (*models.User, *models.UserIdentity, *customErr.CustomError) {
+	userIdentity, err := u.userIdentityRepo.GetUserIdentity(identity.Issuer, identity.Subject)
+	if err == nil {
+		user, err := u.userRepo.GetUserByID(userIdentity.UserID)
+		if err != nil {
+			return nil, nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+		return user, userIdentity, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	// an unverified email address could belong to anyone, so it must not take over an existing user
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, nil, customErr.NewCustomError(customErr.SSOEmailUnverified, customErr.SSOEmailUnverified.Error(), http.StatusForbidden)
+	}
+
+	userIdentity = &models.UserIdentity{Issuer: identity.Issuer, Subject: identity.Subject, CreatedAt: time.Now()}
+
+	user, err := u.userRepo.GetUserByEmail(identity.Email)
+	if err == nil {
+		userIdentity.UserID = user.ID
+		if err := u.userIdentityRepo.CreateUserIdentity(userIdentity); err != nil {
+			return nil, nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+
+		logger.GetLogger().Info("linked single sign-on identity to existing user",
+			zap.Uint("user_id", user.ID), zap.String("issuer", identity.Issuer), zap.String("subject", identity.Subject))
+		return user, userIdentity, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	user, customError := u.createSSOUser(identity, role, userIdentity)
+	if customError != nil {
+		return nil, nil, customError
+	}
+
+	return user, userIdentity, nil
+}
+
+// createSSOUser creates a user for the identity. Its password is random and never revealed,
+// so the user can only sign in through the provider unless a password is reset.
+func (u *UserUseCase) createSSOUser(identity *sso.Identity, role *models.UserRole, userIdentity *models.UserIdentity) (*models.User, *customErr.CustomError) {
+	password, err := auth.GeneratePasswordResetToken()
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+	hashedPassword, err := helpers.HashPassword(password)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	baseUsername := ssoUsername(identity)
+	user := &models.User{
+		UserRoleID: role.ID,
+		Password:   hashedPassword,
+		Email:      identity.Email,
+		FirstName:  truncate(firstNonEmpty(identity.GivenName, baseUsername), 50),
+		LastName:   truncate(firstNonEmpty(identity.FamilyName, "-"), 50),
+		IsActive:   true,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	// the username taken from the provider may already be used by a local user
+	for attempt := 1; ; attempt++ {
+		user.Username = baseUsername
+		if attempt > 1 {
+			suffix := "-" + strconv.Itoa(attempt)
+			user.Username = truncate(baseUsername, 50-len(suffix)) + suffix
+		}
+
+		_, err := u.userIdentityRepo.CreateUserWithIdentity(user, userIdentity)
+		if err == nil {
+			break
+		}
+
+		if ok, columnName := customErr.IsDuplicateKeyError(err); ok && columnName == "username" && attempt < 10 {
+			user.ID = 0
+			continue
+		} else if ok && columnName == "email" {
+			return nil, customErr.NewCustomError(err, customErr.EmailAlreadyExists.Error(), http.StatusConflict)
+		}
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	logger.GetLogger().Info("created user on first single sign-on",
+		zap.Uint("user_id", user.ID), zap.String("username", user.Username), zap.String("role", role.Name))
+	return user, nil
+}
+
+// ssoUsername derives a username from the provider's preferred username or, failing that, the email address.
+func ssoUsername(identity *sso.Identity) string {
+	candidate := identity.PreferredUsername
+	if candidate == "" || strings.Contains(candidate, "@") {
+		candidate, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	username := strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return -1
+	}, candidate)
+	if username == "" {
+		username = "user"
+	}
+
+	return truncate(username, 50)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) > length {
+		return string(runes[:length])
+	}
+
+	return value
+}
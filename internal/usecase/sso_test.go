@@ -0,0 +1,393 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/internal/repository"
+	"Canteen-Backend/pkg/auth"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/logger"
+	"Canteen-Backend/pkg/sso"
+	"Canteen-Backend/pkg/sso/ssotest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memOIDCStates keeps states in memory like OIDCStatePostgres: a state is used once before it expires.
+type memOIDCStates struct {
+	mu     sync.Mutex
+	states []*models.OIDCState
+}
+
+func (r *memOIDCStates) CreateOIDCState(state *models.OIDCState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *state
+	r.states = append(r.states, &stored)
+	return nil
+}
+
+func (r *memOIDCStates) UseOIDCState(stateHash string) (*models.OIDCState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, state := range r.states {
+		if state.StateHash == stateHash && state.UsedAt == nil && state.ExpiresAt.After(now) {
+			state.UsedAt = &now
+			used := *state
+			return &used, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memOIDCStates) DeleteExpiredOIDCStates(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// expire lets all states expire, as if the state duration had passed.
+func (r *memOIDCStates) expire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, state := range r.states {
+		state.ExpiresAt = time.Now().Add(-time.Second)
+	}
+}
+
+// memUserIdentities keeps identities in memory, creating users in users.
+type memUserIdentities struct {
+	mu         sync.Mutex
+	users      *memUsers
+	identities []*models.UserIdentity
+}
+
+func (r *memUserIdentities) GetUserIdentity(issuer, subject string) (*models.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			found := *identity
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memUserIdentities) CreateUserIdentity(identity *models.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	identity.ID = uint(len(r.identities) + 1)
+	stored := *identity
+	r.identities = append(r.identities, &stored)
+	return nil
+}
+
+func (r *memUserIdentities) CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) (uint, error) {
+	id, err := r.users.create(user)
+	if err != nil {
+		return 0, err
+	}
+
+	identity.UserID = id
+	return id, r.CreateUserIdentity(identity)
+}
+
+func (r *memUserIdentities) UpdateUserIdentityLastSignIn(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.identities[id-1].LastSignInAt = &now
+	return nil
+}
+
+// memRoles serves the roles by name and ID, any other method panics.
+type memRoles struct {
+	repository.Role
+	roles []*models.UserRole
+}
+
+func (r *memRoles) GetRoleByID(id uint) (*models.UserRole, error) {
+	for _, role := range r.roles {
+		if role.ID == id {
+			return role, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memRoles) GetRoleByName(name string) (*models.UserRole, error) {
+	for _, role := range r.roles {
+		if role.Name == name {
+			return role, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+const (
+	testAdminRoleID   = 1
+	testCashierRoleID = 2
+)
+
+type ssoTest struct {
+	useCase    *UserUseCase
+	issuer     *ssotest.Issuer
+	users      *memUsers
+	identities *memUserIdentities
+	states     *memOIDCStates
+	sessions   *memSessions
+}
+
+func newSSOTest(t *testing.T) *ssoTest {
+	t.Helper()
+
+	t.Setenv("JWT_KEY", "test key")
+	t.Setenv("JWT_ACTIVE_KEY_ID", "")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("ACCESS_TOKEN_DURATION", "15m")
+	t.Setenv("REFRESH_TOKEN_DURATION", "24h")
+	if err := auth.LoadKeys(); err != nil {
+		t.Fatalf("LoadKeys() error = %v", err)
+	}
+	if logger.GetLogger() == nil {
+		logger.Logger = zap.NewNop()
+	}
+
+	issuer := ssotest.NewIssuer("canteen", "secret")
+	t.Cleanup(issuer.Close)
+
+	provider := sso.NewProvider(sso.Config{
+		IssuerURL:    issuer.URL(),
+		ClientID:     issuer.ClientID,
+		ClientSecret: issuer.ClientSecret,
+		RedirectURL:  "http://localhost:8080/api/auth/oidc/callback",
+		GroupsClaim:  "groups",
+		GroupRoles:   sso.GroupRoleMapping{{Group: "canteen-admins", RoleName: "admin"}, {Group: "canteen-staff", RoleName: "cashier"}},
+	})
+
+	users := newMemUsers(&models.User{Username: "carol", Email: "carol@example.com", UserRoleID: testCashierRoleID, IsActive: true})
+	roles := &memRoles{roles: []*models.UserRole{{ID: testAdminRoleID, Name: "admin"}, {ID: testCashierRoleID, Name: "cashier"}}}
+	tt := &ssoTest{
+		issuer:     issuer,
+		users:      users,
+		identities: &memUserIdentities{users: users},
+		states:     &memOIDCStates{},
+		sessions:   &memSessions{},
+	}
+	tt.useCase = NewUserUseCase(users, roles, tt.sessions, &memSignInAttempts{}, nil, nil, tt.states, tt.identities, newRecordingMailer(), provider)
+
+	return tt
+}
+
+// authorize starts a sign-in and signs the user with the given claims in at the provider,
+// returning the code and state the provider redirects back with.
+func (tt *ssoTest) authorize(t *testing.T, claims map[string]interface{}) (string, string) {
+	t.Helper()
+
+	authURL, state, customError := tt.useCase.StartOIDCSignIn()
+	if customError != nil {
+		t.Fatalf("StartOIDCSignIn() error = %v", customError.Error)
+	}
+
+	code, redirectState, err := tt.issuer.Authorize(authURL, claims)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	if redirectState != state {
+		t.Fatalf("provider redirected back with state %q, want %q", redirectState, state)
+	}
+
+	return code, state
+}
+
+// signIn runs a whole sign-in of the user with the given claims.
+func (tt *ssoTest) signIn(t *testing.T, claims map[string]interface{}) (*models.SignInResult, *customErr.CustomError) {
+	t.Helper()
+
+	code, state := tt.authorize(t, claims)
+	return tt.useCase.FinishOIDCSignIn(code, state, &models.SessionMetadata{IPAddress: "127.0.0.1"})
+}
+
+func assertSSOError(t *testing.T, customError *customErr.CustomError, want error, status int) {
+	t.Helper()
+
+	if customError == nil || customError.Message != want.Error() || customError.StatusCode != status {
+		t.Fatalf("FinishOIDCSignIn() error = %v, want %v with status %d", customError, want, status)
+	}
+}
+
+func TestFinishOIDCSignInCreatesUserOnFirstSignIn(t *testing.T) {
+	tt := newSSOTest(t)
+	claims := map[string]interface{}{
+		"sub":                "alice-subject",
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"preferred_username": "Alice.Smith",
+		"given_name":         "Alice",
+		"family_name":        "Smith",
+		"groups":             []string{"everyone", "canteen-staff"},
+	}
+
+	result, customError := tt.signIn(t, claims)
+	if customError != nil {
+		t.Fatalf("FinishOIDCSignIn() error = %v", customError.Error)
+	}
+	if result.Token == nil || result.AccessToken == "" || result.RefreshToken == "" || len(tt.sessions.created) != 1 {
+		t.Fatalf("FinishOIDCSignIn() = %+v with %d sessions, want a new session", result, len(tt.sessions.created))
+	}
+
+	if len(tt.identities.identities) != 1 {
+		t.Fatalf("%d identities, want 1", len(tt.identities.identities))
+	}
+	identity := tt.identities.identities[0]
+	user, err := tt.users.GetUserByID(identity.UserID)
+	if err != nil {
+		t.Fatalf("the identity's user: %v", err)
+	}
+	if identity.Issuer != tt.issuer.URL() || identity.Subject != "alice-subject" {
+		t.Fatalf("identity = %+v, want the issuer's alice-subject", identity)
+	}
+	if user.Username != "alice.smith" || user.Email != "alice@example.com" || user.FirstName != "Alice" ||
+		user.LastName != "Smith" || user.UserRoleID != testCashierRoleID || !user.IsActive {
+		t.Fatalf("created user = %+v, want active alice.smith with the cashier role", user)
+	}
+
+	// the next sign-in finds the user by the identity, and the role follows the groups
+	core, logs := observer.New(zap.WarnLevel)
+	previousLogger := logger.Logger
+	logger.Logger = zap.New(core)
+	t.Cleanup(func() { logger.Logger = previousLogger })
+
+	claims["groups"] = []string{"canteen-admins"}
+	if _, customError := tt.signIn(t, claims); customError != nil {
+		t.Fatalf("second FinishOIDCSignIn() error = %v", customError.Error)
+	}
+	if len(tt.users.users) != 2 || len(tt.identities.identities) != 1 {
+		t.Fatalf("%d users and %d identities after the second sign-in, want 2 and 1", len(tt.users.users), len(tt.identities.identities))
+	}
+	if user, _ := tt.users.GetUserByID(identity.UserID); user.UserRoleID != testAdminRoleID {
+		t.Fatalf("role after the second sign-in = %d, want the admin role", user.UserRoleID)
+	}
+
+	roleChanges := logs.FilterMessageSnippet("security event: single sign-on changed the user's role").All()
+	if len(roleChanges) != 1 || roleChanges[0].ContextMap()["previous_role_id"] != uint64(testCashierRoleID) || roleChanges[0].ContextMap()["role"] != "admin" {
+		t.Fatalf("logged role changes %+v, want one from the cashier role to admin", roleChanges)
+	}
+}
+
+func TestFinishOIDCSignInChallengesRolesRequiringTwoFactor(t *testing.T) {
+	tt := newSSOTest(t)
+	claims := map[string]interface{}{"sub": "alice-subject", "email": "alice@example.com", "email_verified": true, "groups": []string{"canteen-admins"}}
+
+	// the provider's sign-in does not stand in for the second factor admins must have
+	result, customError := tt.signIn(t, claims)
+	if customError != nil {
+		t.Fatalf("FinishOIDCSignIn() error = %v", customError.Error)
+	}
+	if result.Token != nil || !result.TwoFactorSetupRequired || result.ChallengeToken == "" || len(tt.sessions.created) != 0 {
+		t.Fatalf("FinishOIDCSignIn() for an admin = %+v with %d sessions, want a two-factor setup challenge", result, len(tt.sessions.created))
+	}
+
+	alice, _ := tt.users.GetUserByEmail("alice@example.com")
+	if err := tt.users.EnableTOTP(alice.ID); err != nil {
+		t.Fatalf("EnableTOTP() error = %v", err)
+	}
+	result, customError = tt.signIn(t, claims)
+	if customError != nil {
+		t.Fatalf("FinishOIDCSignIn() error = %v", customError.Error)
+	}
+	if result.Token != nil || !result.TwoFactorRequired || result.ChallengeToken == "" || len(tt.sessions.created) != 0 {
+		t.Fatalf("FinishOIDCSignIn() once enrolled = %+v with %d sessions, want a two-factor challenge", result, len(tt.sessions.created))
+	}
+}
+
+func TestFinishOIDCSignInLinksUserWithVerifiedEmail(t *testing.T) {
+	tt := newSSOTest(t)
+
+	_, customError := tt.signIn(t, map[string]interface{}{
+		"sub": "carol-subject", "email": "carol@example.com", "email_verified": true, "groups": []string{"canteen-staff"},
+	})
+	if customError != nil {
+		t.Fatalf("FinishOIDCSignIn() error = %v", customError.Error)
+	}
+
+	carol, _ := tt.users.GetUserByEmail("carol@example.com")
+	if len(tt.users.users) != 1 || len(tt.identities.identities) != 1 || tt.identities.identities[0].UserID != carol.ID {
+		t.Fatalf("%d users and identities %+v, want the identity linked to carol", len(tt.users.users), tt.identities.identities)
+	}
+}
+
+func TestFinishOIDCSignInRejectsUnverifiedEmail(t *testing.T) {
+	tt := newSSOTest(t)
+
+	// whoever controls the provider account must not take over carol by claiming her email address
+	_, customError := tt.signIn(t, map[string]interface{}{
+		"sub": "mallory-subject", "email": "carol@example.com", "email_verified": false, "groups": []string{"canteen-admins"},
+	})
+	assertSSOError(t, customError, customErr.SSOEmailUnverified, http.StatusForbidden)
+
+	if len(tt.identities.identities) != 0 || len(tt.sessions.created) != 0 {
+		t.Fatalf("%d identities and %d sessions, want none", len(tt.identities.identities), len(tt.sessions.created))
+	}
+}
+
+func TestFinishOIDCSignInRejectsUserWithoutMappedGroup(t *testing.T) {
+	tt := newSSOTest(t)
+
+	_, customError := tt.signIn(t, map[string]interface{}{
+		"sub": "dave-subject", "email": "dave@example.com", "email_verified": true, "groups": []string{"everyone"},
+	})
+	assertSSOError(t, customError, customErr.SSONoRole, http.StatusForbidden)
+
+	if len(tt.users.users) != 1 || len(tt.sessions.created) != 0 {
+		t.Fatalf("%d users and %d sessions, want no new user or session", len(tt.users.users), len(tt.sessions.created))
+	}
+}
+
+func TestFinishOIDCSignInChecksState(t *testing.T) {
+	tt := newSSOTest(t)
+	claims := map[string]interface{}{"sub": "alice-subject", "email": "alice@example.com", "email_verified": true, "groups": []string{"canteen-staff"}}
+	metadata := &models.SessionMetadata{IPAddress: "127.0.0.1"}
+
+	code, _ := tt.authorize(t, claims)
+	_, customError := tt.useCase.FinishOIDCSignIn(code, "forged state", metadata)
+	assertSSOError(t, customError, customErr.SSOStateInvalid, http.StatusBadRequest)
+
+	// a state is used once
+	code, state := tt.authorize(t, claims)
+	if _, customError := tt.useCase.FinishOIDCSignIn(code, state, metadata); customError != nil {
+		t.Fatalf("FinishOIDCSignIn() error = %v", customError.Error)
+	}
+	_, customError = tt.useCase.FinishOIDCSignIn(code, state, metadata)
+	assertSSOError(t, customError, customErr.SSOStateInvalid, http.StatusBadRequest)
+
+	code, state = tt.authorize(t, claims)
+	tt.states.expire()
+	_, customError = tt.useCase.FinishOIDCSignIn(code, state, metadata)
+	assertSSOError(t, customError, customErr.SSOStateInvalid, http.StatusBadRequest)
+}
+
+func TestFinishOIDCSignInChecksNonce(t *testing.T) {
+	tt := newSSOTest(t)
+
+	// an ID token issued for another sign-in, replayed into this one
+	_, customError := tt.signIn(t, map[string]interface{}{
+		"sub": "alice-subject", "email": "alice@example.com", "email_verified": true, "groups": []string{"canteen-admins"},
+		"nonce": "another sign-in's nonce",
+	})
+	assertSSOError(t, customError, customErr.SSOSignInFailed, http.StatusUnauthorized)
+
+	if len(tt.users.users) != 1 || len(tt.sessions.created) != 0 {
+		t.Fatalf("%d users and %d sessions, want no new user or session", len(tt.users.users), len(tt.sessions.created))
+	}
+}
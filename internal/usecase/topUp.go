@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/internal/repository"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/logger"
+	"Canteen-Backend/pkg/payment"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// paymentTimeout bounds the request to the payment provider made while creating a top-up.
+const paymentTimeout = 15 * time.Second
+
+type TopUpUseCase struct {
+	repoTopUp  repository.TopUp
+	repoClient repository.Client
+	// provider is nil unless online payments are configured
+	provider payment.Provider
+}
+
+func NewTopUpUseCase(repoTopUp repository.TopUp, repoClient repository.Client, provider payment.Provider) *TopUpUseCase {
+	return &TopUpUseCase{repoTopUp: repoTopUp, repoClient: repoClient, provider: provider}
+}
+
+// CreateTopUp starts an online top-up of the client's balance: a payment is created with the provider, to be paid
+// on its checkout page, whose URL is set on the top-up. The balance is only credited once the provider's webhook
+// reports that the payment succeeded.
+func (u *TopUpUseCase) CreateTopUp(topUp *models.TopUp, operator *models.Operator) *customErr.CustomError {
+	if u.provider == nil {
+		return customErr.NewCustomError(customErr.PaymentsNotConfigured, customErr.PaymentsNotConfigured.Error(), http.StatusNotFound)
+	}
+
+	client, err := u.repoClient.GetClientByID(topUp.ClientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+	if !client.IsActive {
+		return customErr.NewCustomError(customErr.ClientInactive, customErr.ClientInactive.Error(), http.StatusConflict)
+	}
+
+	topUp.Status = constants.TopUpPending
+	topUp.Provider = u.provider.Name()
+	topUp.UserID = operator.UserID
+	topUp.APIKeyID = operator.APIKeyID
+	topUp.CreatedAt = time.Now()
+	topUp.UpdatedAt = topUp.CreatedAt
+	if _, err := u.repoTopUp.CreateTopUp(topUp); err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), paymentTimeout)
+	defer cancel()
+
+	created, err := u.provider.CreatePayment(ctx, &payment.Request{
+		Reference:   strconv.FormatUint(uint64(topUp.ID), 10),
+		Amount:      topUp.Amount,
+		Description: "Canteen balance top-up",
+	})
+	if err != nil {
+		if failErr := u.repoTopUp.FailTopUp(topUp.ID); failErr != nil {
+			logger.GetLogger().Error("error marking top-up as failed", zap.Uint("top_up_id", topUp.ID), zap.Error(failErr))
+		}
+		return customErr.NewCustomError(err, customErr.PaymentProviderUnavailable.Error(), http.StatusBadGateway)
+	}
+
+	if err := u.repoTopUp.SetTopUpPayment(topUp.ID, created.ID, created.CheckoutURL); err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+	topUp.ProviderPaymentID = &created.ID
+	topUp.CheckoutURL = created.CheckoutURL
+
+	return nil
+}
+
+func (u *TopUpUseCase) GetTopUpByID(id uint) (*models.TopUp, *customErr.CustomError) {
+	topUp, err := u.repoTopUp.GetTopUpByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.TopUpNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return topUp, nil
+}
+
+// GetClientTopUps returns the client's online top-ups, most recent first.
+func (u *TopUpUseCase) GetClientTopUps(clientID uint) (*[]models.TopUp, *customErr.CustomError) {
+	if _, err := u.repoClient.GetClientByID(clientID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	topUps, err := u.repoTopUp.GetTopUpsByClientID(clientID)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return topUps, nil
+}
+
+// HandlePaymentWebhook processes the provider's report of a payment's outcome, after verifying that the provider
+// signed it. A successful payment credits the client's balance with a top-up transaction, once per transaction of
+// the provider: a webhook delivered again is answered with the top-up as it is. A failed payment marks a pending
+// top-up as failed, the client can start another.
+func (u *TopUpUseCase) HandlePaymentWebhook(header http.Header, body []byte) (*models.TopUp, *customErr.CustomError) {
+	if u.provider == nil {
+		return nil, customErr.NewCustomError(customErr.PaymentsNotConfigured, customErr.PaymentsNotConfigured.Error(), http.StatusNotFound)
+	}
+
+	event, err := u.provider.ParseWebhook(header, body)
+	if err != nil {
+		if errors.Is(err, payment.ErrSignatureInvalid) {
+			return nil, customErr.NewCustomError(err, customErr.WebhookSignatureInvalid.Error(), http.StatusUnauthorized)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.WebhookEventInvalid.Error(), http.StatusBadRequest)
+		}
+	}
+
+	topUp, err := u.repoTopUp.GetTopUpByProviderPaymentID(u.provider.Name(), event.PaymentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.TopUpNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if event.Status == payment.StatusFailed {
+		if err := u.repoTopUp.FailTopUp(topUp.ID); err != nil {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+		return u.GetTopUpByID(topUp.ID)
+	}
+
+	if event.Amount != topUp.Amount {
+		return nil, customErr.NewCustomError(customErr.TopUpAmountMismatch, customErr.TopUpAmountMismatch.Error(), http.StatusConflict)
+	}
+
+	// the top-up's own key guards the ledger too, so the balance is never credited twice for the same top-up
+	idempotencyKey := "top-up:" + strconv.FormatUint(uint64(topUp.ID), 10)
+	transaction := &models.BalanceTransaction{
+		ClientID:       topUp.ClientID,
+		Type:           constants.BalanceTransactionTopUp,
+		Amount:         topUp.Amount,
+		UserID:         topUp.UserID,
+		APIKeyID:       topUp.APIKeyID,
+		Reason:         "online top-up #" + strconv.FormatUint(uint64(topUp.ID), 10),
+		IdempotencyKey: &idempotencyKey,
+		CreatedAt:      time.Now(),
+	}
+
+	completed, err := u.repoTopUp.CompleteTopUp(topUp.ID, event.TransactionID, transaction)
+	if err != nil {
+		if errors.Is(err, customErr.TopUpAlreadyPaid) {
+			return nil, customErr.NewCustomError(err, customErr.TopUpAlreadyPaid.Error(), http.StatusConflict)
+		} else if ok, _ := customErr.IsDuplicateKeyError(err); ok {
+			return nil, customErr.NewCustomError(err, customErr.PaymentTransactionAlreadyUsed.Error(), http.StatusConflict)
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.ClientNotFound.Error(), http.StatusNotFound)
+		} else if errors.Is(err, customErr.ClientBusy) {
+			// the provider delivers the webhook again later
+			return nil, customErr.NewCustomError(err, customErr.ClientBusy.Error(), http.StatusServiceUnavailable)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return completed, nil
+}
@@ -0,0 +1,396 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/internal/repository"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/money"
+	"Canteen-Backend/pkg/payment"
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memTopUps keeps top-ups in memory and credits the clients' balances like TopUpPostgres, including
+// the uniqueness of provider transactions and of the ledger's idempotency keys. While busy is set,
+// completing a top-up fails as if the client's lock timed out.
+type memTopUps struct {
+	mu       sync.Mutex
+	topUps   map[uint]*models.TopUp
+	ledger   map[string]*models.BalanceTransaction
+	balances map[uint]money.Money
+	busy     bool
+}
+
+func newMemTopUps() *memTopUps {
+	return &memTopUps{topUps: map[uint]*models.TopUp{}, ledger: map[string]*models.BalanceTransaction{}, balances: map[uint]money.Money{}}
+}
+
+func (r *memTopUps) CreateTopUp(topUp *models.TopUp) (uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	topUp.ID = uint(len(r.topUps) + 1)
+	stored := *topUp
+	r.topUps[topUp.ID] = &stored
+	return topUp.ID, nil
+}
+
+func (r *memTopUps) SetTopUpPayment(id uint, providerPaymentID, checkoutURL string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	topUp, ok := r.topUps[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	topUp.ProviderPaymentID = &providerPaymentID
+	topUp.CheckoutURL = checkoutURL
+	return nil
+}
+
+func (r *memTopUps) GetTopUpByID(id uint) (*models.TopUp, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	topUp, ok := r.topUps[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *topUp
+	return &found, nil
+}
+
+func (r *memTopUps) GetTopUpByProviderPaymentID(provider, providerPaymentID string) (*models.TopUp, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, topUp := range r.topUps {
+		if topUp.Provider == provider && topUp.ProviderPaymentID != nil && *topUp.ProviderPaymentID == providerPaymentID {
+			found := *topUp
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memTopUps) GetTopUpsByClientID(clientID uint) (*[]models.TopUp, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var topUps []models.TopUp
+	for _, topUp := range r.topUps {
+		if topUp.ClientID == clientID {
+			topUps = append(topUps, *topUp)
+		}
+	}
+	return &topUps, nil
+}
+
+func (r *memTopUps) FailTopUp(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if topUp, ok := r.topUps[id]; ok && topUp.Status == constants.TopUpPending {
+		topUp.Status = constants.TopUpFailed
+	}
+	return nil
+}
+
+func (r *memTopUps) CompleteTopUp(id uint, providerTransactionID string, transaction *models.BalanceTransaction) (*models.TopUp, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	topUp, ok := r.topUps[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if topUp.Status == constants.TopUpSucceeded {
+		if topUp.ProviderTransactionID != nil && *topUp.ProviderTransactionID == providerTransactionID {
+			completed := *topUp
+			return &completed, nil
+		}
+		return nil, customErr.TopUpAlreadyPaid
+	}
+	if r.busy {
+		return nil, customErr.ClientBusy
+	}
+	for _, other := range r.topUps {
+		if other.Provider == topUp.Provider && other.ProviderTransactionID != nil && *other.ProviderTransactionID == providerTransactionID {
+			return nil, errors.New(`duplicate key value violates unique constraint "top_up_provider_transaction_id_idx"`)
+		}
+	}
+
+	if earlier, ok := r.ledger[*transaction.IdempotencyKey]; ok {
+		*transaction = *earlier
+	} else {
+		transaction.ID = uint(len(r.ledger) + 1)
+		recorded := *transaction
+		r.ledger[*transaction.IdempotencyKey] = &recorded
+		r.balances[transaction.ClientID] += transaction.Amount
+	}
+
+	topUp.Status = constants.TopUpSucceeded
+	topUp.ProviderTransactionID = &providerTransactionID
+	topUp.BalanceTransactionID = &transaction.ID
+	completed := *topUp
+	return &completed, nil
+}
+
+func (r *memTopUps) balance(clientID uint) money.Money {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.balances[clientID]
+}
+
+// memClients serves the clients a TopUpUseCase looks up, any other method panics.
+type memClients struct {
+	repository.Client
+	clients map[uint]*models.Client
+}
+
+func (r *memClients) GetClientByID(id uint) (*models.Client, error) {
+	client, ok := r.clients[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return client, nil
+}
+
+type topUpTest struct {
+	useCase  *TopUpUseCase
+	topUps   *memTopUps
+	provider *payment.FakeProvider
+}
+
+func newTopUpTest() *topUpTest {
+	topUps := newMemTopUps()
+	clients := &memClients{clients: map[uint]*models.Client{
+		1: {ID: 1, IsActive: true},
+		2: {ID: 2, IsActive: false},
+	}}
+	provider := payment.NewFakeProvider(payment.FakeConfig{WebhookSecret: "whsec_test", CheckoutURL: "https://pay.example.com/checkout"})
+
+	return &topUpTest{useCase: NewTopUpUseCase(topUps, clients, provider), topUps: topUps, provider: provider}
+}
+
+// createTopUp starts a top-up of the client's balance and returns it with the provider's payment.
+func (tt *topUpTest) createTopUp(t *testing.T, clientID uint, amount money.Money) *models.TopUp {
+	t.Helper()
+
+	userID := uint(7)
+	topUp := &models.TopUp{ClientID: clientID, Amount: amount}
+	if customError := tt.useCase.CreateTopUp(topUp, &models.Operator{UserID: &userID}); customError != nil {
+		t.Fatalf("CreateTopUp() error = %v", customError.Error)
+	}
+	return topUp
+}
+
+// deliver sends the provider's webhook for the event to the use case.
+func (tt *topUpTest) deliver(t *testing.T, event *payment.Event) (*models.TopUp, *customErr.CustomError) {
+	t.Helper()
+
+	body, header, err := tt.provider.Webhook(event)
+	if err != nil {
+		t.Fatalf("Webhook() error = %v", err)
+	}
+	return tt.useCase.HandlePaymentWebhook(header, body)
+}
+
+func succeeded(topUp *models.TopUp, transactionID string) *payment.Event {
+	return &payment.Event{TransactionID: transactionID, PaymentID: *topUp.ProviderPaymentID, Status: payment.StatusSucceeded, Amount: topUp.Amount}
+}
+
+func TestCreateTopUp(t *testing.T) {
+	tt := newTopUpTest()
+	topUp := tt.createTopUp(t, 1, money.FromMinorUnits(5000))
+
+	if topUp.Status != constants.TopUpPending || topUp.Provider != payment.FakeProviderName {
+		t.Fatalf("CreateTopUp() = %+v, want a pending top-up with the fake provider", topUp)
+	}
+	if topUp.ProviderPaymentID == nil || topUp.CheckoutURL == "" {
+		t.Fatalf("CreateTopUp() = %+v, want the provider's payment and checkout URL", topUp)
+	}
+	if balance := tt.topUps.balance(1); balance != 0 {
+		t.Fatalf("balance before the payment = %v, want 0", balance)
+	}
+
+	if customError := tt.useCase.CreateTopUp(&models.TopUp{ClientID: 2, Amount: 100}, &models.Operator{}); customError == nil || customError.StatusCode != http.StatusConflict {
+		t.Fatalf("CreateTopUp() for an inactive client = %v, want status %d", customError, http.StatusConflict)
+	}
+}
+
+func TestHandlePaymentWebhookCreditsOnceWhenDeliveredTwice(t *testing.T) {
+	tt := newTopUpTest()
+	topUp := tt.createTopUp(t, 1, money.FromMinorUnits(5000))
+	event := succeeded(topUp, "txn_1")
+
+	for i := 0; i < 2; i++ {
+		completed, customError := tt.deliver(t, event)
+		if customError != nil {
+			t.Fatalf("delivery %d: HandlePaymentWebhook() error = %v", i+1, customError.Error)
+		}
+		if completed.Status != constants.TopUpSucceeded {
+			t.Fatalf("delivery %d: status = %s, want %s", i+1, completed.Status, constants.TopUpSucceeded)
+		}
+	}
+
+	if balance := tt.topUps.balance(1); balance != topUp.Amount {
+		t.Fatalf("balance = %v, want %v", balance, topUp.Amount)
+	}
+}
+
+func TestHandlePaymentWebhookAsksForRedeliveryWhileClientIsBusy(t *testing.T) {
+	tt := newTopUpTest()
+	topUp := tt.createTopUp(t, 1, money.FromMinorUnits(5000))
+	event := succeeded(topUp, "txn_1")
+
+	tt.topUps.mu.Lock()
+	tt.topUps.busy = true
+	tt.topUps.mu.Unlock()
+	if _, customError := tt.deliver(t, event); customError == nil || customError.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("HandlePaymentWebhook() while the client is busy = %v, want status %d", customError, http.StatusServiceUnavailable)
+	}
+	if balance := tt.topUps.balance(1); balance != 0 {
+		t.Fatalf("balance after the failed delivery = %v, want 0", balance)
+	}
+
+	tt.topUps.mu.Lock()
+	tt.topUps.busy = false
+	tt.topUps.mu.Unlock()
+	if _, customError := tt.deliver(t, event); customError != nil {
+		t.Fatalf("HandlePaymentWebhook() on redelivery error = %v", customError.Error)
+	}
+	if balance := tt.topUps.balance(1); balance != topUp.Amount {
+		t.Fatalf("balance after the redelivery = %v, want %v", balance, topUp.Amount)
+	}
+}
+
+func TestHandlePaymentWebhookCreditsOnceWhenDeliveredConcurrently(t *testing.T) {
+	tt := newTopUpTest()
+	topUp := tt.createTopUp(t, 1, money.FromMinorUnits(5000))
+	body, header, err := tt.provider.Webhook(succeeded(topUp, "txn_1"))
+	if err != nil {
+		t.Fatalf("Webhook() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, customError := tt.useCase.HandlePaymentWebhook(header, body); customError != nil {
+				t.Errorf("HandlePaymentWebhook() error = %v", customError.Error)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if balance := tt.topUps.balance(1); balance != topUp.Amount {
+		t.Fatalf("balance = %v, want %v", balance, topUp.Amount)
+	}
+}
+
+func TestHandlePaymentWebhookFailureAfterSuccessLeavesTopUpPaid(t *testing.T) {
+	tt := newTopUpTest()
+	topUp := tt.createTopUp(t, 1, money.FromMinorUnits(5000))
+
+	if _, customError := tt.deliver(t, succeeded(topUp, "txn_1")); customError != nil {
+		t.Fatalf("HandlePaymentWebhook() error = %v", customError.Error)
+	}
+
+	failed := &payment.Event{TransactionID: "txn_0", PaymentID: *topUp.ProviderPaymentID, Status: payment.StatusFailed, Amount: topUp.Amount}
+	afterFailure, customError := tt.deliver(t, failed)
+	if customError != nil {
+		t.Fatalf("HandlePaymentWebhook() error = %v", customError.Error)
+	}
+	if afterFailure.Status != constants.TopUpSucceeded {
+		t.Fatalf("status = %s, want %s", afterFailure.Status, constants.TopUpSucceeded)
+	}
+	if balance := tt.topUps.balance(1); balance != topUp.Amount {
+		t.Fatalf("balance = %v, want %v", balance, topUp.Amount)
+	}
+}
+
+func TestHandlePaymentWebhookFailsPendingTopUp(t *testing.T) {
+	tt := newTopUpTest()
+	topUp := tt.createTopUp(t, 1, money.FromMinorUnits(5000))
+
+	failed := &payment.Event{TransactionID: "txn_0", PaymentID: *topUp.ProviderPaymentID, Status: payment.StatusFailed, Amount: topUp.Amount}
+	afterFailure, customError := tt.deliver(t, failed)
+	if customError != nil {
+		t.Fatalf("HandlePaymentWebhook() error = %v", customError.Error)
+	}
+	if afterFailure.Status != constants.TopUpFailed {
+		t.Fatalf("status = %s, want %s", afterFailure.Status, constants.TopUpFailed)
+	}
+}
+
+func TestHandlePaymentWebhookRejectsAmountMismatch(t *testing.T) {
+	tt := newTopUpTest()
+	topUp := tt.createTopUp(t, 1, money.FromMinorUnits(5000))
+
+	event := succeeded(topUp, "txn_1")
+	event.Amount = money.FromMinorUnits(500000)
+	_, customError := tt.deliver(t, event)
+	if customError == nil || !errors.Is(customError.Error, customErr.TopUpAmountMismatch) || customError.StatusCode != http.StatusConflict {
+		t.Fatalf("HandlePaymentWebhook() error = %v, want %v with status %d", customError, customErr.TopUpAmountMismatch, http.StatusConflict)
+	}
+
+	stored, _ := tt.topUps.GetTopUpByID(topUp.ID)
+	if stored.Status != constants.TopUpPending || tt.topUps.balance(1) != 0 {
+		t.Fatalf("after a mismatched amount got %s and balance %v, want a pending top-up and balance 0", stored.Status, tt.topUps.balance(1))
+	}
+}
+
+func TestHandlePaymentWebhookRejectsAnotherTransaction(t *testing.T) {
+	tt := newTopUpTest()
+	topUp := tt.createTopUp(t, 1, money.FromMinorUnits(5000))
+
+	if _, customError := tt.deliver(t, succeeded(topUp, "txn_1")); customError != nil {
+		t.Fatalf("HandlePaymentWebhook() error = %v", customError.Error)
+	}
+	_, customError := tt.deliver(t, succeeded(topUp, "txn_2"))
+	if customError == nil || !errors.Is(customError.Error, customErr.TopUpAlreadyPaid) || customError.StatusCode != http.StatusConflict {
+		t.Fatalf("HandlePaymentWebhook() error = %v, want %v with status %d", customError, customErr.TopUpAlreadyPaid, http.StatusConflict)
+	}
+
+	// a transaction paying one top-up cannot pay another
+	other := tt.createTopUp(t, 1, money.FromMinorUnits(5000))
+	_, customError = tt.deliver(t, succeeded(other, "txn_1"))
+	if customError == nil || customError.StatusCode != http.StatusConflict {
+		t.Fatalf("HandlePaymentWebhook() error = %v, want status %d", customError, http.StatusConflict)
+	}
+
+	if balance := tt.topUps.balance(1); balance != topUp.Amount {
+		t.Fatalf("balance = %v, want %v", balance, topUp.Amount)
+	}
+}
+
+func TestHandlePaymentWebhookRejectsInvalidSignature(t *testing.T) {
+	tt := newTopUpTest()
+	topUp := tt.createTopUp(t, 1, money.FromMinorUnits(5000))
+	body, _, err := tt.provider.Webhook(succeeded(topUp, "txn_1"))
+	if err != nil {
+		t.Fatalf("Webhook() error = %v", err)
+	}
+
+	stale := http.Header{}
+	stale.Set(payment.SignatureHeader, payment.Sign("whsec_test", time.Now().Add(-time.Hour), body))
+	forged := http.Header{}
+	forged.Set(payment.SignatureHeader, payment.Sign("not the secret", time.Now(), body))
+
+	for name, header := range map[string]http.Header{"missing": {}, "stale": stale, "forged": forged} {
+		if _, customError := tt.useCase.HandlePaymentWebhook(header, body); customError == nil || customError.StatusCode != http.StatusUnauthorized {
+			t.Errorf("HandlePaymentWebhook() with %s signature error = %v, want status %d", name, customError, http.StatusUnauthorized)
+		}
+	}
+	if balance := tt.topUps.balance(1); balance != 0 {
+		t.Fatalf("balance = %v, want 0", balance)
+	}
+}
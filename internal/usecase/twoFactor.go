@@ -0,0 +1,369 @@
+package usecase
+
+import (
+	"Canteen-Backend/internal/constants"
+	"Canteen-Backend/internal/models"
+	"Canteen-Backend/pkg/auth"
+	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/logger"
+	"Canteen-Backend/pkg/twofactor"
+	"errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+// recoveryCodeCount is the number of recovery codes issued when two-factor authentication is enabled.
+const recoveryCodeCount = 10
+
+// challengeSignIn interrupts a sign-in whose password has been verified and returns a challenge token
+// to continue it with the second factor, with setting one up if the user's role requires it,
+// or with choosing a new password.
+func (u *UserUseCase) challengeSignIn(user *models.User, purpose string) (*models.SignInResult, *customErr.CustomError) {
+	challengeToken, err := auth.GenerateChallengeToken(user.ID, purpose)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return &models.SignInResult{
+		TwoFactorRequired:      purpose == auth.ChallengeTwoFactor,
+		TwoFactorSetupRequired: purpose == auth.ChallengeTwoFactorSetup,
+		PasswordChangeRequired: purpose == auth.ChallengePasswordChange,
+		ChallengeToken:         challengeToken,
+	}, nil
+}
+
+// getChallengedUser returns the user a challenge token was issued for, applying the same
+// throttling and activity checks as SignIn.
+func (u *UserUseCase) getChallengedUser(challengeToken, purpose string, metadata *models.SessionMetadata) (*models.User, *customErr.CustomError) {
+	userID, err := auth.ParseChallengeToken(challengeToken, purpose)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.SignInChallengeInvalid.Error(), http.StatusUnauthorized)
+	}
+
+	if customError := u.checkIPThrottle(metadata); customError != nil {
+		return nil, customError
+	}
+
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.SignInChallengeInvalid.Error(), http.StatusUnauthorized)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if customError := u.checkSignInThrottle(user.Username); customError != nil {
+		return nil, customError
+	}
+
+	if !user.IsActive {
+		return nil, customErr.NewCustomError(customErr.UserInactive, customErr.UserInactive.Error(), http.StatusForbidden)
+	}
+
+	return user, nil
+}
+
+// VerifyTwoFactorSignIn finishes a sign-in with a TOTP or recovery code. Wrong codes count
+// as failed sign-in attempts, so they lead to the same delays and lockout as wrong passwords.
+func (u *UserUseCase) VerifyTwoFactorSignIn(challengeToken, code string, metadata *models.SessionMetadata) (*models.SignInResult, *customErr.CustomError) {
+	user, customError := u.getChallengedUser(challengeToken, auth.ChallengeTwoFactor, metadata)
+	if customError != nil {
+		return nil, customError
+	}
+
+	// two-factor authentication was reset after the challenge had been issued
+	if !user.TOTPEnabled {
+		return nil, customErr.NewCustomError(customErr.SignInChallengeInvalid, customErr.SignInChallengeInvalid.Error(), http.StatusUnauthorized)
+	}
+
+	ok, customError := u.verifyTwoFactorCode(user, code)
+	if customError != nil {
+		return nil, customError
+	}
+	if !ok {
+		return nil, u.failSignIn(user, user.Username, metadata, customErr.TwoFactorCodeInvalid)
+	}
+
+	return u.completeSignIn(user, metadata)
+}
+
+// EnrollTwoFactorOnSignIn starts the enrollment of a user who cannot sign in before setting up two-factor authentication.
+func (u *UserUseCase) EnrollTwoFactorOnSignIn(challengeToken string, metadata *models.SessionMetadata) (*models.TwoFactorEnrollment, *customErr.CustomError) {
+	user, customError := u.getChallengedUser(challengeToken, auth.ChallengeTwoFactorSetup, metadata)
+	if customError != nil {
+		return nil, customError
+	}
+
+	return u.enrollTwoFactor(user)
+}
+
+// ConfirmTwoFactorOnSignIn enables two-factor authentication enrolled with EnrollTwoFactorOnSignIn
+// and finishes the sign-in. The result carries the user's recovery codes.
+func (u *UserUseCase) ConfirmTwoFactorOnSignIn(challengeToken, code string, metadata *models.SessionMetadata) (*models.SignInResult, *customErr.CustomError) {
+	user, customError := u.getChallengedUser(challengeToken, auth.ChallengeTwoFactorSetup, metadata)
+	if customError != nil {
+		return nil, customError
+	}
+
+	recoveryCodes, ok, customError := u.confirmTwoFactor(user, code)
+	if customError != nil {
+		return nil, customError
+	}
+	if !ok {
+		return nil, u.failSignIn(user, user.Username, metadata, customErr.TwoFactorCodeInvalid)
+	}
+
+	result, customError := u.completeSignIn(user, metadata)
+	if customError != nil {
+		return nil, customError
+	}
+	result.RecoveryCodes = recoveryCodes
+
+	return result, nil
+}
+
+// EnrollTwoFactor generates a new TOTP secret for the user. It is not used for signing in
+// until it is confirmed with a code from the authenticator app.
+func (u *UserUseCase) EnrollTwoFactor(userID uint) (*models.TwoFactorEnrollment, *customErr.CustomError) {
+	user, customError := u.GetUserByID(userID)
+	if customError != nil {
+		return nil, customError
+	}
+
+	return u.enrollTwoFactor(user)
+}
+
+func (u *UserUseCase) enrollTwoFactor(user *models.User) (*models.TwoFactorEnrollment, *customErr.CustomError) {
+	if user.TOTPEnabled {
+		return nil, customErr.NewCustomError(customErr.TwoFactorAlreadyEnabled, customErr.TwoFactorAlreadyEnabled.Error(), http.StatusConflict)
+	}
+
+	key, err := twofactor.GenerateKey(helpers.GetEnv("TWO_FACTOR_ISSUER", "Canteen"), user.Username)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	if err := u.userRepo.SetTOTPSecret(user.ID, key.Secret); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.UserNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return &models.TwoFactorEnrollment{
+		Secret:          key.Secret,
+		ProvisioningURI: key.ProvisioningURI,
+		QRCode:          key.QRCode,
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication enrolled with EnrollTwoFactor and returns the user's recovery codes.
+func (u *UserUseCase) ConfirmTwoFactor(userID uint, code string) ([]string, *customErr.CustomError) {
+	user, customError := u.GetUserByID(userID)
+	if customError != nil {
+		return nil, customError
+	}
+
+	recoveryCodes, ok, customError := u.confirmTwoFactor(user, code)
+	if customError != nil {
+		return nil, customError
+	}
+	if !ok {
+		return nil, customErr.NewCustomError(customErr.TwoFactorCodeInvalid, customErr.TwoFactorCodeInvalid.Error(), http.StatusBadRequest)
+	}
+
+	return recoveryCodes, nil
+}
+
+// confirmTwoFactor enables two-factor authentication if the code matches the enrolled secret.
+// Only a TOTP code proves that the authenticator app has been set up, so recovery codes are not accepted.
+func (u *UserUseCase) confirmTwoFactor(user *models.User, code string) ([]string, bool, *customErr.CustomError) {
+	if user.TOTPEnabled {
+		return nil, false, customErr.NewCustomError(customErr.TwoFactorAlreadyEnabled, customErr.TwoFactorAlreadyEnabled.Error(), http.StatusConflict)
+	}
+	if user.TOTPSecret == "" {
+		return nil, false, customErr.NewCustomError(customErr.TwoFactorNotEnrolled, customErr.TwoFactorNotEnrolled.Error(), http.StatusConflict)
+	}
+
+	step, ok := twofactor.ValidateCode(code, user.TOTPSecret, time.Now())
+	if !ok {
+		return nil, false, nil
+	}
+
+	if err := u.userRepo.UseTOTPStep(user.ID, step); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	if err := u.userRepo.EnableTOTP(user.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// the enrollment was replaced or removed in the meantime
+			return nil, false, customErr.NewCustomError(err, customErr.TwoFactorNotEnrolled.Error(), http.StatusConflict)
+		}
+		return nil, false, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	recoveryCodes, customError := u.replaceRecoveryCodes(user.ID)
+	if customError != nil {
+		return nil, false, customError
+	}
+
+	logger.GetLogger().Info("two-factor authentication enabled", zap.Uint("user_id", user.ID))
+
+	return recoveryCodes, true, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking a current code.
+// Users whose role requires two-factor authentication cannot disable it.
+func (u *UserUseCase) DisableTwoFactor(userID uint, code string) *customErr.CustomError {
+	user, customError := u.getTwoFactorUser(userID, code)
+	if customError != nil {
+		return customError
+	}
+
+	required, customError := u.isTwoFactorRequired(user)
+	if customError != nil {
+		return customError
+	}
+	if required {
+		return customErr.NewCustomError(customErr.TwoFactorRequired, customErr.TwoFactorRequired.Error(), http.StatusForbidden)
+	}
+
+	if customError := u.removeTwoFactor(user.ID); customError != nil {
+		return customError
+	}
+
+	logger.GetLogger().Info("two-factor authentication disabled", zap.Uint("user_id", user.ID))
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a current code.
+func (u *UserUseCase) RegenerateRecoveryCodes(userID uint, code string) ([]string, *customErr.CustomError) {
+	user, customError := u.getTwoFactorUser(userID, code)
+	if customError != nil {
+		return nil, customError
+	}
+
+	return u.replaceRecoveryCodes(user.ID)
+}
+
+// ResetTwoFactor removes the two-factor authentication of a user who lost both the authenticator
+// and the recovery codes, and signs the user out everywhere. If the role requires two-factor
+// authentication, it has to be set up again on the next sign-in.
+func (u *UserUseCase) ResetTwoFactor(userID uint) *customErr.CustomError {
+	if customError := u.removeTwoFactor(userID); customError != nil {
+		return customError
+	}
+
+	logger.GetLogger().Warn("security event: two-factor authentication reset", zap.Uint("user_id", userID))
+
+	return u.RevokeAllSessions(userID, "")
+}
+
+// getTwoFactorUser returns the user after verifying a code of the user's enabled two-factor authentication.
+func (u *UserUseCase) getTwoFactorUser(userID uint, code string) (*models.User, *customErr.CustomError) {
+	user, customError := u.GetUserByID(userID)
+	if customError != nil {
+		return nil, customError
+	}
+
+	if !user.TOTPEnabled {
+		return nil, customErr.NewCustomError(customErr.TwoFactorNotEnabled, customErr.TwoFactorNotEnabled.Error(), http.StatusConflict)
+	}
+
+	ok, customError := u.verifyTwoFactorCode(user, code)
+	if customError != nil {
+		return nil, customError
+	}
+	if !ok {
+		return nil, customErr.NewCustomError(customErr.TwoFactorCodeInvalid, customErr.TwoFactorCodeInvalid.Error(), http.StatusBadRequest)
+	}
+
+	return user, nil
+}
+
+// verifyTwoFactorCode checks a TOTP code, or a recovery code if the code does not look like a TOTP code,
+// and makes sure neither can be used a second time.
+func (u *UserUseCase) verifyTwoFactorCode(user *models.User, code string) (bool, *customErr.CustomError) {
+	if twofactor.IsTOTPCode(code) {
+		step, ok := twofactor.ValidateCode(code, user.TOTPSecret, time.Now())
+		if !ok {
+			return false, nil
+		}
+
+		if err := u.userRepo.UseTOTPStep(user.ID, step); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return false, nil
+			}
+			return false, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+
+		return true, nil
+	}
+
+	if err := u.recoveryCodeRepo.UseRecoveryCode(user.ID, twofactor.HashRecoveryCode(code)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	logger.GetLogger().Info("recovery code used", zap.Uint("user_id", user.ID))
+
+	return true, nil
+}
+
+func (u *UserUseCase) replaceRecoveryCodes(userID uint) ([]string, *customErr.CustomError) {
+	recoveryCodes, err := twofactor.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	codeHashes := make([]string, len(recoveryCodes))
+	for i, recoveryCode := range recoveryCodes {
+		codeHashes[i] = twofactor.HashRecoveryCode(recoveryCode)
+	}
+
+	if err := u.recoveryCodeRepo.ReplaceRecoveryCodes(userID, codeHashes); err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return recoveryCodes, nil
+}
+
+func (u *UserUseCase) removeTwoFactor(userID uint) *customErr.CustomError {
+	if err := u.userRepo.SetTOTPSecret(userID, ""); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.UserNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if err := u.recoveryCodeRepo.DeleteRecoveryCodesByUserID(userID); err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (u *UserUseCase) isTwoFactorRequired(user *models.User) (bool, *customErr.CustomError) {
+	role, err := u.roleRepo.GetRoleByID(user.UserRoleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, customErr.NewCustomError(err, customErr.RoleNotFound.Error(), http.StatusNotFound)
+		} else {
+			return false, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return constants.TwoFactorRequiredRoles[role.Name], nil
+}
@@ -4,6 +4,12 @@ import (
 	"Canteen-Backend/internal/models"
 	"Canteen-Backend/internal/repository"
 	"Canteen-Backend/pkg/customErr"
+	"Canteen-Backend/pkg/mailer"
+	"Canteen-Backend/pkg/money"
+	"Canteen-Backend/pkg/payment"
+	"Canteen-Backend/pkg/sso"
+	"net/http"
+	"time"
 )
 
 type User interface {
@@ -11,25 +17,94 @@ type User interface {
 	GetAllUsers() (*[]models.User, *customErr.CustomError)
 	GetUserByID(id uint) (*models.User, *customErr.CustomError)
 	UpdateUser(user *models.User) *customErr.CustomError
+	SetUserActive(id uint, isActive bool) *customErr.CustomError
 	DeleteUser(id uint) *customErr.CustomError
-	SignIn(userInput *models.User) (*models.Token, *customErr.CustomError)
-	RefreshTokens(refreshToken string) (*models.Token, *customErr.CustomError)
+	UnlockUser(id uint) *customErr.CustomError
+	GetProfile(userID uint) (*models.User, *models.UserRole, *customErr.CustomError)
+	ChangePassword(userID uint, sessionID, currentPassword, newPassword string) *customErr.CustomError
+	ForgotPassword(email string) *customErr.CustomError
+	ResetPassword(resetToken, newPassword string) *customErr.CustomError
+	ChangePasswordOnSignIn(challengeToken, newPassword string, metadata *models.SessionMetadata) (*models.SignInResult, *customErr.CustomError)
+	SetMustChangePassword(id uint, mustChangePassword bool) *customErr.CustomError
+	SignIn(userInput *models.User, metadata *models.SessionMetadata) (*models.SignInResult, *customErr.CustomError)
+	StartOIDCSignIn() (string, string, *customErr.CustomError)
+	FinishOIDCSignIn(code, state string, metadata *models.SessionMetadata) (*models.SignInResult, *customErr.CustomError)
+	VerifyTwoFactorSignIn(challengeToken, code string, metadata *models.SessionMetadata) (*models.SignInResult, *customErr.CustomError)
+	EnrollTwoFactorOnSignIn(challengeToken string, metadata *models.SessionMetadata) (*models.TwoFactorEnrollment, *customErr.CustomError)
+	ConfirmTwoFactorOnSignIn(challengeToken, code string, metadata *models.SessionMetadata) (*models.SignInResult, *customErr.CustomError)
+	RefreshTokens(refreshToken string, metadata *models.SessionMetadata) (*models.Token, *customErr.CustomError)
 	SignOut(refreshToken string) *customErr.CustomError
+	CheckSession(userID uint, sessionID string) *customErr.CustomError
+	GetActiveSessions(userID uint) (*[]models.Session, *customErr.CustomError)
+	RevokeSession(userID uint, sessionID string) *customErr.CustomError
+	RevokeAllSessions(userID uint, exceptSessionID string) *customErr.CustomError
+	EnrollTwoFactor(userID uint) (*models.TwoFactorEnrollment, *customErr.CustomError)
+	ConfirmTwoFactor(userID uint, code string) ([]string, *customErr.CustomError)
+	DisableTwoFactor(userID uint, code string) *customErr.CustomError
+	RegenerateRecoveryCodes(userID uint, code string) ([]string, *customErr.CustomError)
+	ResetTwoFactor(userID uint) *customErr.CustomError
+}
+
+type Role interface {
+	CreateRole(role *models.UserRole) (uint, *customErr.CustomError)
+	GetAllRoles() (*[]models.UserRole, *customErr.CustomError)
+	GetRoleByID(id uint) (*models.UserRole, *customErr.CustomError)
+	UpdateRole(role *models.UserRole) *customErr.CustomError
+	DeleteRole(id uint) *customErr.CustomError
+	GetAllPermissions() (*[]models.Permission, *customErr.CustomError)
+	CheckPermissions(userRoleID uint, permissions ...string) *customErr.CustomError
+}
+
+type APIKey interface {
+	CreateAPIKey(apiKey *models.APIKey) (uint, string, *customErr.CustomError)
+	GetAllAPIKeys() (*[]models.APIKey, *customErr.CustomError)
+	GetAPIKeyByID(id uint) (*models.APIKey, *customErr.CustomError)
+	RevokeAPIKey(id uint) *customErr.CustomError
+	AuthenticateAPIKey(key, ipAddress string) (*models.APIKey, *customErr.CustomError)
 }
 
 type Client interface {
-	CreateClient(client *models.Client) (uint, *customErr.CustomError)
-	GetAllClients(clientCategoryName string) (*[]models.Client, *customErr.CustomError)
+	CreateClient(client *models.Client, operator *models.Operator) (uint, *customErr.CustomError)
+	ImportClients(rows []*models.ClientImportRow, dryRun bool, operator *models.Operator) (int, *customErr.CustomError)
+	GetClients(filter *models.ClientFilter) (*[]models.Client, int64, *customErr.CustomError)
 	GetClientByID(id uint) (*models.Client, *customErr.CustomError)
 	UpdateClient(client *models.Client) *customErr.CustomError
 	DeleteClient(id uint) *customErr.CustomError
-	ModifyBalanceByClientID(id uint, difference float32) *customErr.CustomError
+	CreateBalanceTransaction(transaction *models.BalanceTransaction, operator *models.Operator) (*models.BalanceTransaction, *customErr.CustomError)
+	GetBalanceTransactions(clientID uint, filter *models.BalanceTransactionFilter) (*[]models.BalanceTransaction, *customErr.CustomError)
+	GetClientStatement(clientID uint, from, to time.Time) (*models.Statement, *customErr.CustomError)
+	GetClientCategoryStatements(clientCategoryID uint, from, to time.Time) (*[]models.Statement, *customErr.CustomError)
+	SetClientCreditLimit(clientID uint, creditLimit *money.Money) *customErr.CustomError
+	SetClientSpendingLimits(clientID uint, limits *models.SpendingLimits) *customErr.CustomError
+	GetClientDietaryRestrictions(clientID uint) (*models.DietaryRestrictions, *customErr.CustomError)
+	SetClientDietaryRestrictions(clientID uint, restrictions *models.DietaryRestrictions) *customErr.CustomError
+	CheckDietaryRestrictions(clientID uint, items []models.BalanceTransactionItem) (*[]models.DietaryConflict, *customErr.CustomError)
+	GetDietaryOverrides(clientID uint) (*[]models.DietaryOverride, *customErr.CustomError)
+
+	IssueClientIdentifier(identifier *models.ClientIdentifier, reason string, operator *models.Operator) (uint, *customErr.CustomError)
+	LookUpClient(identifierType, value string) (*models.ClientLookup, *customErr.CustomError)
+	GetClientIdentifiers(clientID uint) (*[]models.ClientIdentifier, *customErr.CustomError)
+	SetClientIdentifierStatus(clientID, identifierID uint, status, reason string, operator *models.Operator) *customErr.CustomError
+	ReplaceClientIdentifier(clientID, identifierID uint, status, value, reason string, operator *models.Operator) (*models.ClientIdentifier, *customErr.CustomError)
+	GetClientIdentifierHistory(clientID, identifierID uint) (*[]models.ClientIdentifierEvent, *customErr.CustomError)
 
 	CreateClientCategory(clientCategory *models.ClientCategory) (uint, *customErr.CustomError)
 	GetAllClientCategories() (*[]models.ClientCategory, *customErr.CustomError)
 	GetClientCategoryByID(id uint) (*models.ClientCategory, *customErr.CustomError)
+	GetClientCategoryIDsByName(names []string) (map[string]uint, *customErr.CustomError)
 	UpdateClientCategory(clientCategory *models.ClientCategory) *customErr.CustomError
 	DeleteClientCategory(id uint) *customErr.CustomError
+
+	CreateClientCategoryPricing(pricing *models.ClientCategoryPricing, operator *models.Operator) (uint, *customErr.CustomError)
+	GetClientCategoryPricings(clientCategoryID uint) (*[]models.ClientCategoryPricing, *customErr.CustomError)
+	DeleteClientCategoryPricing(clientCategoryID, pricingID uint) *customErr.CustomError
+}
+
+type TopUp interface {
+	CreateTopUp(topUp *models.TopUp, operator *models.Operator) *customErr.CustomError
+	GetTopUpByID(id uint) (*models.TopUp, *customErr.CustomError)
+	GetClientTopUps(clientID uint) (*[]models.TopUp, *customErr.CustomError)
+	HandlePaymentWebhook(header http.Header, body []byte) (*models.TopUp, *customErr.CustomError)
 }
 
 type Ingredient interface {
@@ -44,6 +119,22 @@ type Ingredient interface {
 	GetIngredientByID(id uint) (*models.Ingredient, *customErr.CustomError)
 	UpdateIngredient(ingredient *models.Ingredient) *customErr.CustomError
 	DeleteIngredient(id uint) *customErr.CustomError
+	GetIngredientDietaryInfo(id uint) (*models.IngredientDietaryInfo, *customErr.CustomError)
+	SetIngredientDietaryInfo(id uint, info *models.IngredientDietaryInfo) *customErr.CustomError
+
+	CreateAllergen(allergen *models.Allergen) (uint, *customErr.CustomError)
+	GetAllAllergens() (*[]models.Allergen, *customErr.CustomError)
+	UpdateAllergen(allergen *models.Allergen) *customErr.CustomError
+	DeleteAllergen(id uint) *customErr.CustomError
+}
+
+type Dish interface {
+	CreateDish(dish *models.Dish) (uint, *customErr.CustomError)
+	GetAllDishes() (*[]models.Dish, *customErr.CustomError)
+	GetDishByID(id uint) (*models.Dish, *customErr.CustomError)
+	UpdateDish(dish *models.Dish) *customErr.CustomError
+	GetDishDietaryInfo(id uint) (*models.DishDietaryInfo, *customErr.CustomError)
+	SetDishIngredients(id uint, ingredientIDs []uint) *customErr.CustomError
 }
 
 type Purchase interface {
@@ -58,16 +149,25 @@ type Purchase interface {
 
 type UseCase struct {
 	User
+	Role
+	APIKey
 	Client
+	TopUp
 	Ingredient
+	Dish
 	Purchase
 }
 
-func NewUseCase(repo *repository.Repository) *UseCase {
+func NewUseCase(repo *repository.Repository, mailer mailer.Mailer, ssoProvider *sso.Provider, paymentProvider payment.Provider) *UseCase {
 	return &UseCase{
-		User:       NewUserUseCase(repo.User, repo.Session),
-		Client:     NewClientUseCase(repo.Client),
+		User: NewUserUseCase(repo.User, repo.Role, repo.Session, repo.SignInAttempt, repo.RecoveryCode, repo.PasswordResetToken,
+			repo.OIDCState, repo.UserIdentity, mailer, ssoProvider),
+		Role:       NewRoleUseCase(repo.Role),
+		APIKey:     NewAPIKeyUseCase(repo.APIKey, repo.Role),
+		Client:     NewClientUseCase(repo.Client, repo.Dish, repo.Ingredient),
+		TopUp:      NewTopUpUseCase(repo.TopUp, repo.Client, paymentProvider),
 		Ingredient: NewIngredientUseCase(repo.Ingredient),
+		Dish:       NewDishUseCase(repo.Dish, repo.Ingredient),
 		Purchase:   NewPurchaseUseCase(repo.Purchase, repo.Ingredient),
 	}
 }
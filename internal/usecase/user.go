@@ -6,26 +6,154 @@ import (
 	"Canteen-Backend/pkg/auth"
 	"Canteen-Backend/pkg/customErr"
 	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/logger"
+	"Canteen-Backend/pkg/mailer"
+	"Canteen-Backend/pkg/sso"
 	"errors"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"net/http"
 	"time"
 )
 
 type UserUseCase struct {
-	userRepo    repository.User
-	sessionRepo repository.Session
+	userRepo               repository.User
+	roleRepo               repository.Role
+	sessionRepo            repository.Session
+	signInAttemptRepo      repository.SignInAttempt
+	recoveryCodeRepo       repository.RecoveryCode
+	passwordResetTokenRepo repository.PasswordResetToken
+	oidcStateRepo          repository.OIDCState
+	userIdentityRepo       repository.UserIdentity
+	mailer                 mailer.Mailer
+	// ssoProvider is nil unless single sign-on is configured
+	ssoProvider  *sso.Provider
+	signInPolicy *signInPolicy
 }
 
-func NewUserUseCase(userRepo repository.User, sessionRepo repository.Session) *UserUseCase {
-	return &UserUseCase{userRepo: userRepo, sessionRepo: sessionRepo}
+// signInPolicy configures the brute-force protection of SignIn.
+type signInPolicy struct {
+	// consecutive failed attempts for one username before it is locked, counted within failureWindow
+	maxFailedAttempts int
+	lockoutDuration   time.Duration
+	failureWindow     time.Duration
+	// failed attempts from one IP address within ipWindow before it is throttled
+	maxFailedAttemptsPerIP int
+	ipWindow               time.Duration
+	// the delay required after a failed attempt, doubled with every further failure up to maxDelay
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	// verified against when the username does not exist, so the response takes as long as for a wrong password
+	dummyPasswordHash string
 }
 
-func (u *UserUseCase) SignIn(userInput *models.User) (*models.Token, *customErr.CustomError) {
+func NewUserUseCase(userRepo repository.User, roleRepo repository.Role, sessionRepo repository.Session,
+	signInAttemptRepo repository.SignInAttempt, recoveryCodeRepo repository.RecoveryCode,
+	passwordResetTokenRepo repository.PasswordResetToken, oidcStateRepo repository.OIDCState,
+	userIdentityRepo repository.UserIdentity, mailer mailer.Mailer, ssoProvider *sso.Provider) *UserUseCase {
+	return &UserUseCase{
+		userRepo:               userRepo,
+		roleRepo:               roleRepo,
+		sessionRepo:            sessionRepo,
+		signInAttemptRepo:      signInAttemptRepo,
+		recoveryCodeRepo:       recoveryCodeRepo,
+		passwordResetTokenRepo: passwordResetTokenRepo,
+		oidcStateRepo:          oidcStateRepo,
+		userIdentityRepo:       userIdentityRepo,
+		mailer:                 mailer,
+		ssoProvider:            ssoProvider,
+		signInPolicy:           newSignInPolicy(),
+	}
+}
+
+func newSignInPolicy() *signInPolicy {
+	dummyPasswordHash, err := helpers.HashPassword("dummy password")
+	if err != nil {
+		logger.GetLogger().Error("error hashing dummy password", zap.Error(err))
+	}
+
+	return &signInPolicy{
+		maxFailedAttempts:      helpers.GetEnvInt("SIGN_IN_MAX_FAILED_ATTEMPTS", 5),
+		lockoutDuration:        helpers.GetEnvDuration("SIGN_IN_LOCKOUT_DURATION", 15*time.Minute),
+		failureWindow:          helpers.GetEnvDuration("SIGN_IN_FAILURE_WINDOW", 24*time.Hour),
+		maxFailedAttemptsPerIP: helpers.GetEnvInt("SIGN_IN_MAX_FAILED_ATTEMPTS_PER_IP", 20),
+		ipWindow:               helpers.GetEnvDuration("SIGN_IN_IP_WINDOW", 15*time.Minute),
+		baseDelay:              helpers.GetEnvDuration("SIGN_IN_BASE_DELAY", time.Second),
+		maxDelay:               helpers.GetEnvDuration("SIGN_IN_MAX_DELAY", 30*time.Second),
+		dummyPasswordHash:      dummyPasswordHash,
+	}
+}
+
+// delay returns how long a user has to wait after the given number of consecutive failed attempts.
+func (p *signInPolicy) delay(failedAttempts int) time.Duration {
+	if failedAttempts <= 0 {
+		return 0
+	}
+
+	delay := p.baseDelay
+	for i := 1; i < failedAttempts && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+
+	return delay
+}
+
+// signInThrottle is the sign-in state of a username, replayed from its consecutive failed attempts.
+type signInThrottle struct {
+	failedAttempts int
+	lastFailedAt   time.Time
+	lockedUntil    *time.Time
+}
+
+// fail counts a failed attempt made at the given time. Once a lockout has passed the count starts over,
+// so that the next failure only leads to the base delay.
+func (p *signInPolicy) fail(throttle *signInThrottle, at time.Time) {
+	if throttle.lockedUntil != nil && !at.Before(*throttle.lockedUntil) {
+		*throttle = signInThrottle{}
+	}
+
+	throttle.failedAttempts++
+	throttle.lastFailedAt = at
+	if throttle.failedAttempts >= p.maxFailedAttempts {
+		lockedUntil := at.Add(p.lockoutDuration)
+		throttle.lockedUntil = &lockedUntil
+	}
+}
+
+// throttle replays the failed attempts, oldest first, and returns the state they leave the username in at now.
+func (p *signInPolicy) throttle(failedAt []time.Time, now time.Time) *signInThrottle {
+	throttle := &signInThrottle{}
+	for _, at := range failedAt {
+		p.fail(throttle, at)
+	}
+	if throttle.lockedUntil != nil && !now.Before(*throttle.lockedUntil) {
+		return &signInThrottle{}
+	}
+
+	return throttle
+}
+
+// SignIn checks the credentials and starts a new session, or returns a challenge token if the user
+// still has to provide or set up a second factor. To avoid revealing which usernames exist,
+// an unknown username and a wrong password both result in customErr.InvalidCredentials, and failures are
+// throttled by the username submitted whether or not it exists.
+func (u *UserUseCase) SignIn(userInput *models.User, metadata *models.SessionMetadata) (*models.SignInResult, *customErr.CustomError) {
+	if customErr := u.checkIPThrottle(metadata); customErr != nil {
+		return nil, customErr
+	}
+
+	if customErr := u.checkSignInThrottle(userInput.Username); customErr != nil {
+		return nil, customErr
+	}
+
 	user, err := u.userRepo.GetUserByUsername(userInput.Username)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, customErr.NewCustomError(err, customErr.UserNotFound.Error(), http.StatusNotFound)
+			_ = helpers.CheckPassword(userInput.Password, u.signInPolicy.dummyPasswordHash)
+			return nil, u.failSignIn(nil, userInput.Username, metadata, customErr.InvalidCredentials)
 		} else {
 			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
 		}
@@ -33,17 +161,181 @@ func (u *UserUseCase) SignIn(userInput *models.User) (*models.Token, *customErr.
 
 	if err := helpers.CheckPassword(userInput.Password, user.Password); err != nil {
 		if errors.Is(err, customErr.PasswordInvalid) {
-			return nil, customErr.NewCustomError(err, customErr.PasswordInvalid.Error(), http.StatusUnauthorized)
+			return nil, u.failSignIn(user, userInput.Username, metadata, customErr.InvalidCredentials)
 		} else {
 			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
 		}
 	}
 
-	return u.createSession(user.ID, user.UserRoleID)
+	if !user.IsActive {
+		return nil, customErr.NewCustomError(customErr.UserInactive, customErr.UserInactive.Error(), http.StatusForbidden)
+	}
+
+	if helpers.PasswordNeedsRehash(user.Password) {
+		u.rehashPassword(user.ID, userInput.Password)
+	}
+
+	if user.TOTPEnabled {
+		return u.challengeSignIn(user, auth.ChallengeTwoFactor)
+	}
+
+	required, customError := u.isTwoFactorRequired(user)
+	if customError != nil {
+		return nil, customError
+	}
+	if required {
+		return u.challengeSignIn(user, auth.ChallengeTwoFactorSetup)
+	}
+
+	return u.completeSignIn(user, metadata)
+}
+
+// completeSignIn starts a new session for a user who has passed every sign-in step. A user who
+// has to change the password is challenged to do so first, which happens after the second factor
+// so that a leaked temporary password alone is not enough to take over the account.
+func (u *UserUseCase) completeSignIn(user *models.User, metadata *models.SessionMetadata) (*models.SignInResult, *customErr.CustomError) {
+	if user.MustChangePassword {
+		return u.challengeSignIn(user, auth.ChallengePasswordChange)
+	}
+
+	if customErr := u.succeedSignIn(user, metadata); customErr != nil {
+		return nil, customErr
+	}
+
+	tokens, customError := u.createSession(user, nil, metadata)
+	if customError != nil {
+		return nil, customError
+	}
+
+	return &models.SignInResult{Token: tokens}, nil
+}
+
+// checkIPThrottle rejects the attempt if too many sign-ins from the IP address failed recently.
+func (u *UserUseCase) checkIPThrottle(metadata *models.SessionMetadata) *customErr.CustomError {
+	failedAttemptsByIP, err := u.signInAttemptRepo.CountFailedSignInAttemptsByIP(metadata.IPAddress, time.Now().Add(-u.signInPolicy.ipWindow))
+	if err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+	if failedAttemptsByIP >= int64(u.signInPolicy.maxFailedAttemptsPerIP) {
+		return customErr.NewCustomError(customErr.TooManySignInAttempts, customErr.TooManySignInAttempts.Error(), http.StatusTooManyRequests)
+	}
+
+	return nil
+}
+
+// getSignInThrottle returns the state of the username's failed sign-in attempts at now.
+func (u *UserUseCase) getSignInThrottle(username string, now time.Time) (*signInThrottle, error) {
+	failedAt, err := u.signInAttemptRepo.GetFailedSignInAttemptTimes(username, now.Add(-u.signInPolicy.failureWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	return u.signInPolicy.throttle(failedAt, now), nil
+}
+
+// checkSignInThrottle rejects the attempt while the username is locked or the progressive delay
+// since its last failed attempt has not passed yet.
+func (u *UserUseCase) checkSignInThrottle(username string) *customErr.CustomError {
+	now := time.Now()
+	throttle, err := u.getSignInThrottle(username, now)
+	if err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	if throttle.lockedUntil != nil {
+		return customErr.NewCustomError(customErr.AccountLocked, customErr.TooManySignInAttempts.Error(), http.StatusTooManyRequests)
+	}
+
+	if throttle.failedAttempts > 0 && throttle.lastFailedAt.Add(u.signInPolicy.delay(throttle.failedAttempts)).After(now) {
+		return customErr.NewCustomError(customErr.TooManySignInAttempts, customErr.TooManySignInAttempts.Error(), http.StatusTooManyRequests)
+	}
+
+	return nil
+}
+
+// failSignIn records a failed attempt for the username and IP address, shows the user's resulting
+// lockout to admins if the user exists, and returns reason as an unauthorized error.
+func (u *UserUseCase) failSignIn(user *models.User, username string, metadata *models.SessionMetadata, reason error) *customErr.CustomError {
+	if err := u.signInAttemptRepo.CreateSignInAttempt(&models.SignInAttempt{Username: username, IPAddress: metadata.IPAddress}); err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	if user != nil {
+		throttle, err := u.getSignInThrottle(username, time.Now())
+		if err != nil {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+		if err := u.userRepo.SetFailedSignInAttempts(user.ID, throttle.failedAttempts, throttle.lastFailedAt, throttle.lockedUntil); err != nil {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+
+		if throttle.failedAttempts == u.signInPolicy.maxFailedAttempts {
+			logger.GetLogger().Warn("security event: account locked after failed sign-in attempts",
+				zap.Uint("user_id", user.ID), zap.String("ip_address", metadata.IPAddress), zap.Timep("locked_until", throttle.lockedUntil))
+		}
+	}
+
+	return customErr.NewCustomError(reason, reason.Error(), http.StatusUnauthorized)
+}
+
+// succeedSignIn records a successful attempt and resets the user's failed attempts.
+func (u *UserUseCase) succeedSignIn(user *models.User, metadata *models.SessionMetadata) *customErr.CustomError {
+	if err := u.signInAttemptRepo.CreateSignInAttempt(&models.SignInAttempt{Username: user.Username, IPAddress: metadata.IPAddress, IsSuccessful: true}); err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	if user.FailedSignInAttempts > 0 || user.LockedUntil != nil {
+		if err := u.userRepo.ResetFailedSignInAttempts(user.ID); err != nil {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// UnlockUser lifts a sign-in lockout of the user and resets the failed attempts counter.
+func (u *UserUseCase) UnlockUser(id uint) *customErr.CustomError {
+	user, err := u.userRepo.GetUserByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.UserNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return u.resetSignInThrottle(user)
+}
+
+// resetSignInThrottle forgets the failed sign-in attempts for the user's username, which lifts a lockout.
+func (u *UserUseCase) resetSignInThrottle(user *models.User) *customErr.CustomError {
+	if err := u.signInAttemptRepo.DeleteFailedSignInAttemptsByUsername(user.Username); err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	if err := u.userRepo.ResetFailedSignInAttempts(user.ID); err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// rehashPassword upgrades a stored hash to the current hasher. A failure is only logged,
+// since the user has already proven the password and the upgrade is retried on the next sign-in.
+func (u *UserUseCase) rehashPassword(userID uint, password string) {
+	hashedPassword, err := helpers.HashPassword(password)
+	if err != nil {
+		logger.GetLogger().Error("error rehashing password", zap.Uint("user_id", userID), zap.Error(err))
+		return
+	}
+
+	if err := u.userRepo.UpdateUser(&models.User{ID: userID, Password: hashedPassword}); err != nil {
+		logger.GetLogger().Error("error saving rehashed password", zap.Uint("user_id", userID), zap.Error(err))
+	}
 }
 
 func (u *UserUseCase) SignOut(refreshToken string) *customErr.CustomError {
-	session, err := u.sessionRepo.GetSessionByRefreshToken(refreshToken)
+	session, err := u.sessionRepo.GetSessionByRefreshTokenHash(auth.HashRefreshToken(refreshToken))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return customErr.NewCustomError(err, customErr.SessionNotFound.Error(), http.StatusNotFound)
@@ -52,15 +344,19 @@ func (u *UserUseCase) SignOut(refreshToken string) *customErr.CustomError {
 		}
 	}
 
-	if err := u.sessionRepo.DeleteSession(session); err != nil {
-		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	if err := u.sessionRepo.RevokeSessionFamily(session.UserID, session.FamilyID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.SessionNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
 	}
 
 	return nil
 }
 
-func (u *UserUseCase) RefreshTokens(refreshToken string) (*models.Token, *customErr.CustomError) {
-	session, err := u.sessionRepo.GetSessionByRefreshToken(refreshToken)
+func (u *UserUseCase) RefreshTokens(refreshToken string, metadata *models.SessionMetadata) (*models.Token, *customErr.CustomError) {
+	session, err := u.sessionRepo.GetSessionByRefreshTokenHash(auth.HashRefreshToken(refreshToken))
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, customErr.NewCustomError(err, customErr.SessionNotFound.Error(), http.StatusNotFound)
@@ -69,12 +365,24 @@ func (u *UserUseCase) RefreshTokens(refreshToken string) (*models.Token, *custom
 		}
 	}
 
-	if err := u.sessionRepo.DeleteSession(session); err != nil {
-		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	if session.RevokedAt != nil {
+		return nil, customErr.NewCustomError(customErr.SessionRevoked, customErr.SessionRevoked.Error(), http.StatusUnauthorized)
+	}
+
+	if session.RotatedAt != nil {
+		return nil, u.revokeReusedSessionFamily(session)
 	}
 
 	if session.ExpiresAt.Before(time.Now()) {
-		return nil, customErr.NewCustomError(err, customErr.SessionExpired.Error(), http.StatusUnauthorized)
+		return nil, customErr.NewCustomError(customErr.SessionExpired, customErr.SessionExpired.Error(), http.StatusUnauthorized)
+	}
+
+	if err := u.sessionRepo.RotateSession(session.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// the token was rotated by a concurrent request in the meantime
+			return nil, u.revokeReusedSessionFamily(session)
+		}
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
 	}
 
 	user, err := u.userRepo.GetUserByID(session.UserID)
@@ -85,11 +393,80 @@ func (u *UserUseCase) RefreshTokens(refreshToken string) (*models.Token, *custom
 		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
 	}
 
-	return u.createSession(user.ID, user.UserRoleID)
+	if !user.IsActive {
+		return nil, customErr.NewCustomError(customErr.UserInactive, customErr.UserInactive.Error(), http.StatusForbidden)
+	}
+
+	return u.createSession(user, session, metadata)
+}
+
+// revokeReusedSessionFamily is called when an already rotated refresh token is presented again.
+// Either the legitimate client or an attacker holds a stolen copy, so the whole token family is revoked.
+func (u *UserUseCase) revokeReusedSessionFamily(session *models.Session) *customErr.CustomError {
+	logger.GetLogger().Warn("security event: refresh token reuse detected, revoking token family",
+		zap.Uint("user_id", session.UserID), zap.Uint("session_id", session.ID), zap.String("family_id", session.FamilyID))
+
+	if err := u.sessionRepo.RevokeSessionFamily(session.UserID, session.FamilyID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return customErr.NewCustomError(customErr.RefreshTokenReused, customErr.RefreshTokenReused.Error(), http.StatusUnauthorized)
+}
+
+// CheckSession makes sure the session an access token was issued for is still active.
+func (u *UserUseCase) CheckSession(userID uint, sessionID string) *customErr.CustomError {
+	active, err := u.sessionRepo.IsSessionFamilyActive(userID, sessionID)
+	if err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	if !active {
+		return customErr.NewCustomError(customErr.SessionRevoked, customErr.SessionRevoked.Error(), http.StatusUnauthorized)
+	}
+
+	return nil
+}
+
+func (u *UserUseCase) GetActiveSessions(userID uint) (*[]models.Session, *customErr.CustomError) {
+	if _, err := u.userRepo.GetUserByID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.UserNotFound.Error(), http.StatusNotFound)
+		} else {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	sessions, err := u.sessionRepo.GetActiveSessionsByUserID(userID)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return sessions, nil
+}
+
+func (u *UserUseCase) RevokeSession(userID uint, sessionID string) *customErr.CustomError {
+	if err := u.sessionRepo.RevokeSessionFamily(userID, sessionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.SessionNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// RevokeAllSessions revokes every session of the user except exceptSessionID, which may be empty.
+func (u *UserUseCase) RevokeAllSessions(userID uint, exceptSessionID string) *customErr.CustomError {
+	if err := u.sessionRepo.RevokeSessionsByUserID(userID, exceptSessionID); err != nil {
+		return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
 }
 
 func (u *UserUseCase) CreateUser(user *models.User) (uint, *customErr.CustomError) {
-	if _, err := u.userRepo.GetRoleByID(user.UserRoleID); err != nil {
+	if _, err := u.roleRepo.GetRoleByID(user.UserRoleID); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return 0, customErr.NewCustomError(err, customErr.RoleNotFound.Error(), http.StatusNotFound)
 		} else {
@@ -143,7 +520,7 @@ func (u *UserUseCase) GetUserByID(userID uint) (*models.User, *customErr.CustomE
 
 func (u *UserUseCase) UpdateUser(user *models.User) *customErr.CustomError {
 	if user.UserRoleID != 0 {
-		if _, err := u.userRepo.GetRoleByID(user.UserRoleID); err != nil {
+		if _, err := u.roleRepo.GetRoleByID(user.UserRoleID); err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return customErr.NewCustomError(err, customErr.RoleNotFound.Error(), http.StatusNotFound)
 			} else {
@@ -152,6 +529,14 @@ func (u *UserUseCase) UpdateUser(user *models.User) *customErr.CustomError {
 		}
 	}
 
+	if user.Password != "" {
+		hashedPassword, err := helpers.HashPassword(user.Password)
+		if err != nil {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+		user.Password = hashedPassword
+	}
+
 	user.UpdatedAt = time.Now()
 
 	if err := u.userRepo.UpdateUser(user); err != nil {
@@ -172,9 +557,47 @@ func (u *UserUseCase) UpdateUser(user *models.User) *customErr.CustomError {
 	return nil
 }
 
-func (u *UserUseCase) DeleteUser(id uint) *customErr.CustomError {
-	err := u.userRepo.DeleteUser(id)
+// GetProfile returns the user together with the role and its permissions.
+func (u *UserUseCase) GetProfile(userID uint) (*models.User, *models.UserRole, *customErr.CustomError) {
+	user, customError := u.GetUserByID(userID)
+	if customError != nil {
+		return nil, nil, customError
+	}
+
+	role, err := u.roleRepo.GetRoleByID(user.UserRoleID)
 	if err != nil {
+		return nil, nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
+	return user, role, nil
+}
+
+// ChangePassword sets a new password for a user who knows the current one.
+// Every session of the user except sessionID is revoked, signing out anyone else who had the old password.
+func (u *UserUseCase) ChangePassword(userID uint, sessionID, currentPassword, newPassword string) *customErr.CustomError {
+	user, customError := u.GetUserByID(userID)
+	if customError != nil {
+		return customError
+	}
+
+	if err := helpers.CheckPassword(currentPassword, user.Password); err != nil {
+		if errors.Is(err, customErr.PasswordInvalid) {
+			return customErr.NewCustomError(customErr.CurrentPasswordInvalid, customErr.CurrentPasswordInvalid.Error(), http.StatusForbidden)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if customError := u.setPassword(userID, newPassword); customError != nil {
+		return customError
+	}
+
+	return u.RevokeAllSessions(userID, sessionID)
+}
+
+// SetUserActive activates or deactivates the user. Deactivation revokes all of the user's sessions.
+func (u *UserUseCase) SetUserActive(id uint, isActive bool) *customErr.CustomError {
+	if err := u.userRepo.SetUserActive(id, isActive); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return customErr.NewCustomError(err, customErr.UserNotFound.Error(), http.StatusNotFound)
 		} else {
@@ -182,24 +605,64 @@ func (u *UserUseCase) DeleteUser(id uint) *customErr.CustomError {
 		}
 	}
 
+	if !isActive {
+		return u.RevokeAllSessions(id, "")
+	}
+
 	return nil
 }
 
-func (u *UserUseCase) createSession(userID uint, userRoleID uint) (*models.Token, *customErr.CustomError) {
-	accessToken, err := auth.GenerateAccessToken(userID, userRoleID)
+func (u *UserUseCase) DeleteUser(id uint) *customErr.CustomError {
+	if customErr := u.RevokeAllSessions(id, ""); customErr != nil {
+		return customErr
+	}
+
+	err := u.userRepo.DeleteUser(id)
 	if err != nil {
-		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customErr.NewCustomError(err, customErr.UserNotFound.Error(), http.StatusNotFound)
+		} else {
+			return customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
 	}
 
+	return nil
+}
+
+// createSession issues a new token pair. A nil previous session starts a new token family,
+// otherwise the new refresh token replaces the previous one within its family.
+func (u *UserUseCase) createSession(user *models.User, previous *models.Session, metadata *models.SessionMetadata) (*models.Token, *customErr.CustomError) {
 	refreshToken, expTime, err := auth.GenerateRefreshToken()
 	if err != nil {
 		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
 	}
 
 	session := &models.Session{
-		UserID:       userID,
-		RefreshToken: refreshToken,
-		ExpiresAt:    expTime,
+		UserID:           user.ID,
+		RefreshTokenHash: auth.HashRefreshToken(refreshToken),
+		IPAddress:        metadata.IPAddress,
+		UserAgent:        metadata.UserAgent,
+		LastUsedAt:       time.Now(),
+		ExpiresAt:        expTime,
+	}
+
+	if previous != nil {
+		session.FamilyID = previous.FamilyID
+		session.SignedInAt = previous.SignedInAt
+	} else {
+		session.FamilyID, err = auth.GenerateTokenFamilyID()
+		if err != nil {
+			return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+		}
+		session.SignedInAt = time.Now()
+	}
+
+	role, err := u.roleRepo.GetRoleByID(user.UserRoleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErr.NewCustomError(err, customErr.RoleNotFound.Error(), http.StatusNotFound)
+		}
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
 	}
 
 	err = u.sessionRepo.CreateSession(session)
@@ -207,5 +670,10 @@ func (u *UserUseCase) createSession(userID uint, userRoleID uint) (*models.Token
 		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
 	}
 
+	accessToken, err := auth.GenerateAccessToken(user.ID, role.ID, role.Name, session.FamilyID)
+	if err != nil {
+		return nil, customErr.NewCustomError(err, customErr.ServerError.Error(), http.StatusInternalServerError)
+	}
+
 	return &models.Token{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
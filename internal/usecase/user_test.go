@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+)
+
+func testSignInPolicy() *signInPolicy {
+	return &signInPolicy{
+		maxFailedAttempts: 3,
+		lockoutDuration:   15 * time.Minute,
+		failureWindow:     24 * time.Hour,
+		baseDelay:         time.Second,
+		maxDelay:          30 * time.Second,
+	}
+}
+
+func TestSignInThrottleLocksAfterMaxFailedAttempts(t *testing.T) {
+	policy := testSignInPolicy()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	failedAt := []time.Time{start, start.Add(time.Minute), start.Add(2 * time.Minute)}
+
+	throttle := policy.throttle(failedAt[:2], start.Add(2*time.Minute))
+	if throttle.failedAttempts != 2 || throttle.lockedUntil != nil {
+		t.Fatalf("after 2 failures got %+v, want 2 failures and no lockout", throttle)
+	}
+
+	throttle = policy.throttle(failedAt, start.Add(3*time.Minute))
+	if throttle.lockedUntil == nil || !throttle.lockedUntil.Equal(start.Add(17*time.Minute)) {
+		t.Fatalf("after 3 failures got %+v, want a lockout until %s", throttle, start.Add(17*time.Minute))
+	}
+}
+
+func TestSignInThrottleStartsOverOnceLockoutHasPassed(t *testing.T) {
+	policy := testSignInPolicy()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	failedAt := []time.Time{start, start.Add(time.Minute), start.Add(2 * time.Minute)}
+
+	throttle := policy.throttle(failedAt, start.Add(17*time.Minute))
+	if throttle.failedAttempts != 0 || throttle.lockedUntil != nil {
+		t.Fatalf("after the lockout got %+v, want a fresh state", throttle)
+	}
+
+	// one more failure only leads to the base delay, not to another lockout
+	failedAt = append(failedAt, start.Add(20*time.Minute))
+	throttle = policy.throttle(failedAt, start.Add(20*time.Minute))
+	if throttle.failedAttempts != 1 || throttle.lockedUntil != nil {
+		t.Fatalf("after a failure past the lockout got %+v, want 1 failure and no lockout", throttle)
+	}
+	if delay := policy.delay(throttle.failedAttempts); delay != time.Second {
+		t.Fatalf("delay = %s, want %s", delay, time.Second)
+	}
+}
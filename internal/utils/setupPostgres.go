@@ -5,6 +5,7 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"time"
 )
 
 type Config struct {
@@ -14,6 +15,11 @@ type Config struct {
 	Port     string
 	DBName   string
 	SSLMode  string
+
+	// MaxOpenConns, MaxIdleConns and ConnMaxLifetime limit the connection pool, zero means no limit
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
 }
 
 func ConnectDatabase(config Config) (*gorm.DB, error) {
@@ -30,5 +36,13 @@ func ConnectDatabase(config Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+
 	return db, nil
 }
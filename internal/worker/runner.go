@@ -0,0 +1,154 @@
+package worker
+
+import (
+	"Canteen-Backend/internal/repository"
+	"Canteen-Backend/pkg/logger"
+	"context"
+	"go.uber.org/zap"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Results of a job run.
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+	// ResultSkipped means another replica was running the job at the same time
+	ResultSkipped = "skipped"
+)
+
+// Job is work run periodically in the background. Run returns the number of records it affected.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) (int64, error)
+}
+
+// JobStatus describes the last run of a job in this replica.
+type JobStatus struct {
+	Name           string
+	Interval       string
+	Running        bool
+	LastStartedAt  *time.Time
+	LastFinishedAt *time.Time
+	LastResult     string
+	LastError      string
+	AffectedRows   int64
+	NextRunAt      *time.Time
+}
+
+// Runner runs jobs in the background of the app. Every replica runs the same jobs, so a job only runs
+// while holding a Postgres advisory lock derived from its name, and is skipped if another replica holds it.
+type Runner struct {
+	locker repository.Locker
+	jobs   []*Job
+
+	mu       sync.Mutex
+	statuses map[string]*JobStatus
+}
+
+func NewRunner(locker repository.Locker) *Runner {
+	return &Runner{locker: locker, statuses: make(map[string]*JobStatus)}
+}
+
+// Register adds a job. Jobs must be registered before Start is called.
+func (r *Runner) Register(job *Job) {
+	r.jobs = append(r.jobs, job)
+	r.statuses[job.Name] = &JobStatus{Name: job.Name, Interval: job.Interval.String()}
+}
+
+// Start runs every job right away and then once per interval, until ctx is cancelled.
+func (r *Runner) Start(ctx context.Context) {
+	for _, job := range r.jobs {
+		go r.schedule(ctx, job)
+	}
+}
+
+func (r *Runner) schedule(ctx context.Context, job *Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.run(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) run(ctx context.Context, job *Job) {
+	startedAt := time.Now()
+	r.updateStatus(job.Name, func(status *JobStatus) {
+		status.Running = true
+		status.LastStartedAt = &startedAt
+	})
+
+	var affectedRows int64
+	acquired, err := r.locker.WithAdvisoryLock(lockKey(job.Name), func() error {
+		var err error
+		affectedRows, err = job.Run(ctx)
+		return err
+	})
+
+	finishedAt := time.Now()
+	nextRunAt := startedAt.Add(job.Interval)
+	r.updateStatus(job.Name, func(status *JobStatus) {
+		status.Running = false
+		status.LastFinishedAt = &finishedAt
+		status.NextRunAt = &nextRunAt
+		status.AffectedRows = affectedRows
+		status.LastError = ""
+
+		switch {
+		case err != nil:
+			status.LastResult = ResultFailed
+			status.LastError = err.Error()
+		case !acquired:
+			status.LastResult = ResultSkipped
+		default:
+			status.LastResult = ResultSucceeded
+		}
+	})
+
+	if err != nil {
+		logger.GetLogger().Error("background job failed", zap.String("job", job.Name), zap.Error(err))
+	} else if acquired {
+		logger.GetLogger().Info("background job finished", zap.String("job", job.Name),
+			zap.Int64("affected_rows", affectedRows), zap.Duration("duration", finishedAt.Sub(startedAt)))
+	}
+}
+
+func (r *Runner) updateStatus(name string, update func(status *JobStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	update(r.statuses[name])
+}
+
+// Statuses returns the status of every registered job, ordered by name.
+func (r *Runner) Statuses() []JobStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(r.statuses))
+	for _, status := range r.statuses {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
+
+// lockKey maps a job name to the key of its advisory lock.
+func lockKey(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte("canteen-job:" + name))
+	return int64(hash.Sum64())
+}
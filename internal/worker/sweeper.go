@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"Canteen-Backend/internal/repository"
+	"Canteen-Backend/pkg/helpers"
+	"context"
+	"time"
+)
+
+// RegisterSweeperJobs registers the jobs that delete expired sessions and other time-bound records.
+// They run every SWEEPER_INTERVAL. Sign-in attempts are kept for SIGN_IN_ATTEMPT_RETENTION, which has to
+// be longer than SIGN_IN_IP_WINDOW and SIGN_IN_FAILURE_WINDOW for the sign-in throttling to keep working.
+func RegisterSweeperJobs(runner *Runner, repo *repository.Repository) {
+	interval := helpers.GetEnvDuration("SWEEPER_INTERVAL", time.Hour)
+	signInAttemptRetention := helpers.GetEnvDuration("SIGN_IN_ATTEMPT_RETENTION", 30*24*time.Hour)
+
+	runner.Register(&Job{
+		Name:     "expired-sessions",
+		Interval: interval,
+		Run: func(ctx context.Context) (int64, error) {
+			return repo.Session.DeleteExpiredSessions(time.Now())
+		},
+	})
+
+	runner.Register(&Job{
+		Name:     "expired-password-reset-tokens",
+		Interval: interval,
+		Run: func(ctx context.Context) (int64, error) {
+			return repo.PasswordResetToken.DeleteExpiredPasswordResetTokens(time.Now())
+		},
+	})
+
+	runner.Register(&Job{
+		Name:     "expired-oidc-states",
+		Interval: interval,
+		Run: func(ctx context.Context) (int64, error) {
+			return repo.OIDCState.DeleteExpiredOIDCStates(time.Now())
+		},
+	})
+
+	runner.Register(&Job{
+		Name:     "old-sign-in-attempts",
+		Interval: interval,
+		Run: func(ctx context.Context) (int64, error) {
+			return repo.SignInAttempt.DeleteSignInAttemptsBefore(time.Now().Add(-signInAttemptRetention))
+		},
+	})
+}
@@ -1,26 +1,58 @@
 package auth
 
 import (
+	"Canteen-Backend/pkg/helpers"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"github.com/dgrijalva/jwt-go"
-	"math/rand"
+	"github.com/golang-jwt/jwt/v4"
 	"os"
 	"time"
 )
 
 var (
-	jwtKey                     string
 	accessTokenDurationString  string
 	refreshTokenDurationString string
 )
 
-type jwtClaims struct {
-	UserID     uint `json:"user_id"`
-	UserRoleID uint `json:"user_role_id"`
-	jwt.StandardClaims
+// Types of the tokens signed by the keyring, carried in the typ claim. Access and challenge tokens
+// are signed with the same keys, the type keeps one from being accepted as the other.
+const (
+	tokenTypeAccess    = "access"
+	tokenTypeChallenge = "challenge"
+)
+
+// Claims are the claims carried by an access token. SessionID is the token family
+// of the refresh token issued together with the access token. UserRoleName is informational,
+// permissions are always checked by UserRoleID since roles can be renamed.
+type Claims struct {
+	UserID       uint   `json:"user_id"`
+	UserRoleID   uint   `json:"user_role_id"`
+	UserRoleName string `json:"user_role"`
+	SessionID    string `json:"sid"`
+	Type         string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// Purposes of challenge tokens. A challenge token proves that the password has been checked
+// and lets the client finish the sign-in with the missing step.
+const (
+	ChallengeTwoFactor      = "2fa"
+	ChallengeTwoFactorSetup = "2fa-setup"
+	ChallengePasswordChange = "password-change"
+)
+
+// ChallengeClaims are the claims carried by a challenge token.
+type ChallengeClaims struct {
+	UserID  uint   `json:"user_id"`
+	Purpose string `json:"purpose"`
+	Type    string `json:"typ"`
+	jwt.RegisteredClaims
 }
 
-func GenerateAccessToken(userID, roleID uint) (string, error) {
+func GenerateAccessToken(userID, roleID uint, roleName, sessionID string) (string, error) {
 	// converting string to time.Duration
 	accessTokenDurationString = os.Getenv("ACCESS_TOKEN_DURATION")
 	accessTokenDuration, err := time.ParseDuration(accessTokenDurationString)
@@ -29,18 +61,16 @@ func GenerateAccessToken(userID, roleID uint) (string, error) {
 	}
 	expirationTime := time.Now().Add(accessTokenDuration)
 
-	claims := &jwtClaims{
-		UserID:     userID,
-		UserRoleID: roleID,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
-		},
+	claims := &Claims{
+		UserID:           userID,
+		UserRoleID:       roleID,
+		UserRoleName:     roleName,
+		SessionID:        sessionID,
+		Type:             tokenTypeAccess,
+		RegisteredClaims: keyring.registeredClaims(expirationTime),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	jwtKey = os.Getenv("JWT_KEY")
-	tokenString, err := token.SignedString([]byte(jwtKey))
+	tokenString, err := keyring.sign(claims)
 	if err != nil {
 		return "", err
 	}
@@ -48,39 +78,143 @@ func GenerateAccessToken(userID, roleID uint) (string, error) {
 	return tokenString, nil
 }
 
+// GenerateRefreshToken returns a new random refresh token and its expiration time.
+// Only the hash of the token (see HashRefreshToken) should ever be persisted.
 func GenerateRefreshToken() (string, time.Time, error) {
-	b := make([]byte, 32)
-
-	s := rand.NewSource(time.Now().Unix())
-	r := rand.New(s)
-
-	if _, err := r.Read(b); err != nil {
+	token, err := generateRandomString(32)
+	if err != nil {
 		return "", time.Time{}, err
 	}
+
 	refreshTokenDurationString = os.Getenv("REFRESH_TOKEN_DURATION")
 	refreshTokenDuration, err := time.ParseDuration(refreshTokenDurationString)
 	if err != nil {
 		return "", time.Time{}, err
 	}
-	return fmt.Sprintf("%x", b), time.Now().Add(refreshTokenDuration), nil
+	return token, time.Now().Add(refreshTokenDuration), nil
+}
+
+// GenerateTokenFamilyID returns a random identifier shared by all refresh tokens rotated from one sign-in.
+func GenerateTokenFamilyID() (string, error) {
+	return generateRandomString(16)
+}
+
+// HashRefreshToken returns the hex encoded SHA-256 hash under which a refresh token is stored.
+func HashRefreshToken(refreshToken string) string {
+	return hashToken(refreshToken)
 }
 
-func ParseToken(accessToken string) (uint, uint, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &jwtClaims{}, func(token *jwt.Token) (i interface{}, err error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		jwtKey = os.Getenv("JWT_KEY")
-		return []byte(jwtKey), nil
-	})
+// GeneratePasswordResetToken returns a new random password reset token.
+// Only the hash of the token (see HashPasswordResetToken) should ever be persisted.
+func GeneratePasswordResetToken() (string, error) {
+	return generateRandomString(32)
+}
+
+// HashPasswordResetToken returns the hex encoded SHA-256 hash under which a password reset token is stored.
+func HashPasswordResetToken(resetToken string) string {
+	return hashToken(resetToken)
+}
+
+// GenerateOIDCState returns a random value for the state or the nonce of an OpenID Connect sign-in.
+func GenerateOIDCState() (string, error) {
+	return generateRandomString(16)
+}
+
+// HashOIDCState returns the hex encoded SHA-256 hash under which the state of an OpenID Connect sign-in is stored.
+func HashOIDCState(state string) string {
+	return hashToken(state)
+}
+
+// apiKeyPrefix marks API keys, so that they can be recognised, e.g. by secret scanners.
+const apiKeyPrefix = "ck_"
+
+// GenerateAPIKey returns a new random API key together with its prefix, which identifies the key
+// in listings. Only the hash of the key (see HashAPIKey) should ever be persisted.
+func GenerateAPIKey() (string, string, error) {
+	randomString, err := generateRandomString(24)
 	if err != nil {
-		return 0, 0, err
+		return "", "", err
 	}
 
-	claims, ok := token.Claims.(*jwtClaims)
+	key := apiKeyPrefix + randomString
+	return key, key[:len(apiKeyPrefix)+8], nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash under which an API key is stored.
+func HashAPIKey(apiKey string) string {
+	return hashToken(apiKey)
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func generateRandomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateChallengeToken returns a short-lived token that can only be used to continue
+// the sign-in of the user with the step given by purpose.
+func GenerateChallengeToken(userID uint, purpose string) (string, error) {
+	expirationTime := time.Now().Add(helpers.GetEnvDuration("CHALLENGE_TOKEN_DURATION", 5*time.Minute))
+
+	claims := &ChallengeClaims{
+		UserID:           userID,
+		Purpose:          purpose,
+		Type:             tokenTypeChallenge,
+		RegisteredClaims: keyring.registeredClaims(expirationTime),
+	}
+
+	return keyring.sign(claims)
+}
+
+func ParseToken(accessToken string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(accessToken, &Claims{}, keyring.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
 	if !ok {
-		return 0, 0, fmt.Errorf("error get user claims from token")
+		return nil, fmt.Errorf("error get user claims from token")
+	}
+
+	if err := keyring.verifyRegisteredClaims(&claims.RegisteredClaims); err != nil {
+		return nil, err
+	}
+	// every access token belongs to a session
+	if claims.Type != tokenTypeAccess || claims.SessionID == "" {
+		return nil, errors.New("token is not an access token")
+	}
+
+	return claims, nil
+}
+
+// ParseChallengeToken returns the user a challenge token was issued for,
+// provided it has not expired and was issued for the given purpose.
+func ParseChallengeToken(challengeToken, purpose string) (uint, error) {
+	token, err := jwt.ParseWithClaims(challengeToken, &ChallengeClaims{}, keyring.keyFunc)
+	if err != nil {
+		return 0, err
+	}
+
+	claims, ok := token.Claims.(*ChallengeClaims)
+	if !ok {
+		return 0, fmt.Errorf("error get challenge claims from token")
+	}
+
+	if err := keyring.verifyRegisteredClaims(&claims.RegisteredClaims); err != nil {
+		return 0, err
+	}
+	if claims.Type != tokenTypeChallenge || claims.Purpose != purpose || claims.UserID == 0 {
+		return 0, fmt.Errorf("token is not a %s challenge token", purpose)
 	}
 
-	return claims.UserID, claims.UserRoleID, nil
+	return claims.UserID, nil
 }
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+	"testing"
+	"time"
+)
+
+func loadTestKeys(t *testing.T, issuer, audience string) {
+	t.Helper()
+	t.Setenv("JWT_KEY", "test key")
+	t.Setenv("JWT_ACTIVE_KEY_ID", "")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_ISSUER", issuer)
+	t.Setenv("JWT_AUDIENCE", audience)
+	t.Setenv("ACCESS_TOKEN_DURATION", "15m")
+	if err := LoadKeys(); err != nil {
+		t.Fatalf("LoadKeys() error = %v", err)
+	}
+}
+
+func TestTokensAreOnlyAcceptedForTheirType(t *testing.T) {
+	loadTestKeys(t, "", "")
+
+	accessToken, err := GenerateAccessToken(1, 2, "admin", "family")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+	challengeToken, err := GenerateChallengeToken(1, ChallengeTwoFactor)
+	if err != nil {
+		t.Fatalf("GenerateChallengeToken() error = %v", err)
+	}
+
+	claims, err := ParseToken(accessToken)
+	if err != nil || claims.UserID != 1 || claims.SessionID != "family" {
+		t.Fatalf("ParseToken() = %+v, %v, want the claims of user 1", claims, err)
+	}
+	if claims.Issuer != defaultIssuer || len(claims.Audience) != 1 || claims.Audience[0] != defaultAudience {
+		t.Errorf("access token issuer %q, audience %q, want %q and %q", claims.Issuer, claims.Audience, defaultIssuer, defaultAudience)
+	}
+	if userID, err := ParseChallengeToken(challengeToken, ChallengeTwoFactor); err != nil || userID != 1 {
+		t.Fatalf("ParseChallengeToken() = %d, %v, want user 1", userID, err)
+	}
+
+	if _, err := ParseToken(challengeToken); err == nil {
+		t.Error("ParseToken() accepted a challenge token")
+	}
+	if _, err := ParseChallengeToken(accessToken, ChallengeTwoFactor); err == nil {
+		t.Error("ParseChallengeToken() accepted an access token")
+	}
+	if _, err := ParseChallengeToken(challengeToken, ChallengePasswordChange); err == nil {
+		t.Error("ParseChallengeToken() accepted a challenge token issued for another purpose")
+	}
+
+	// a token signed before the typ claim was added, with a session id of its own
+	untyped, err := keyring.sign(&Claims{UserID: 1, SessionID: "family", RegisteredClaims: keyring.registeredClaims(time.Now().Add(time.Minute))})
+	if err != nil {
+		t.Fatalf("sign() error = %v", err)
+	}
+	if _, err := ParseToken(untyped); err == nil {
+		t.Error("ParseToken() accepted a token without a type")
+	}
+}
+
+func TestTokensAreOnlyAcceptedFromTheIssuerForTheAudience(t *testing.T) {
+	tests := []struct {
+		name             string
+		issuer, audience string
+	}{
+		{"other issuer", "reports-service", defaultAudience},
+		{"other audience", defaultIssuer, "reports-api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadTestKeys(t, tt.issuer, tt.audience)
+			accessToken, err := GenerateAccessToken(1, 2, "admin", "family")
+			if err != nil {
+				t.Fatalf("GenerateAccessToken() error = %v", err)
+			}
+			challengeToken, err := GenerateChallengeToken(1, ChallengeTwoFactor)
+			if err != nil {
+				t.Fatalf("GenerateChallengeToken() error = %v", err)
+			}
+
+			// the same keys, but this service's issuer and audience
+			loadTestKeys(t, "", "")
+			if _, err := ParseToken(accessToken); err == nil {
+				t.Error("ParseToken() accepted the token")
+			}
+			if _, err := ParseChallengeToken(challengeToken, ChallengeTwoFactor); err == nil {
+				t.Error("ParseChallengeToken() accepted the token")
+			}
+		})
+	}
+
+	// a token without an audience is meant for no one
+	loadTestKeys(t, "", "")
+	claims := &Claims{UserID: 1, SessionID: "family", Type: tokenTypeAccess, RegisteredClaims: keyring.registeredClaims(time.Now().Add(time.Minute))}
+	claims.Audience = jwt.ClaimStrings{}
+	token, err := keyring.sign(claims)
+	if err != nil {
+		t.Fatalf("sign() error = %v", err)
+	}
+	if _, err := ParseToken(token); err == nil {
+		t.Error("ParseToken() accepted a token without an audience")
+	}
+}
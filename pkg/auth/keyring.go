@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"Canteen-Backend/pkg/helpers"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tokens are signed with the active key of the keyring and carry its id in the kid header,
+// so tokens signed with any key that is still in the keyring stay valid while keys are rotated.
+//
+// Keys are PEM files named <kid>.pem in JWT_KEYS_DIR, holding a PKCS #8 RSA or Ed25519 private key:
+//
+//	openssl genpkey -algorithm ed25519 -out keys/2024-06.pem
+//	openssl genpkey -algorithm rsa -pkeyopt rsa_keygen_bits:2048 -out keys/2024-06.pem
+//
+// A retired key, whose tokens should only be verified until they expire, can be replaced by
+// its public key (openssl pkey -in keys/2024-06.pem -pubout). JWT_ACTIVE_KEY_ID names the key
+// new tokens are signed with. The HS256 secret JWT_KEY, used before the keyring was introduced,
+// verifies tokens without a kid header and is the active key if JWT_KEYS_DIR is not set.
+//
+// Tokens name this service in the iss claim, JWT_ISSUER, and the services they are meant for
+// in the aud claim, JWT_AUDIENCE, so they cannot be replayed against another service trusting the same keys.
+
+// sharedKeyID is the kid of the HS256 key read from JWT_KEY.
+const sharedKeyID = "shared"
+
+const (
+	defaultIssuer   = "canteen-backend"
+	defaultAudience = "canteen-api"
+)
+
+// Key is a signing key of the keyring. Verification-only keys have no signKey.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+type Keyring struct {
+	active *Key
+	keys   map[string]*Key
+	// shared verifies tokens issued without a kid header
+	shared   *Key
+	issuer   string
+	audience string
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+var keyring *Keyring
+
+// LoadKeys loads the keyring from the environment. It must be called once at startup,
+// before any token is generated or parsed.
+func LoadKeys() error {
+	k, err := NewKeyringFromEnv()
+	if err != nil {
+		return err
+	}
+
+	keyring = k
+	return nil
+}
+
+func NewKeyringFromEnv() (*Keyring, error) {
+	k := &Keyring{
+		keys:     make(map[string]*Key),
+		issuer:   helpers.GetEnv("JWT_ISSUER", defaultIssuer),
+		audience: helpers.GetEnv("JWT_AUDIENCE", defaultAudience),
+	}
+
+	if secret := os.Getenv("JWT_KEY"); secret != "" {
+		k.shared = &Key{ID: sharedKeyID, Method: jwt.SigningMethodHS256, signKey: []byte(secret), verifyKey: []byte(secret)}
+		k.keys[sharedKeyID] = k.shared
+	}
+
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		if err := k.loadDir(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	activeKeyID := os.Getenv("JWT_ACTIVE_KEY_ID")
+	if activeKeyID == "" && len(k.keys) == 1 && k.shared != nil {
+		activeKeyID = sharedKeyID
+	}
+	if activeKeyID == "" {
+		return nil, errors.New("JWT_ACTIVE_KEY_ID is not set")
+	}
+
+	active, ok := k.keys[activeKeyID]
+	if !ok {
+		return nil, fmt.Errorf("active JWT key %q not found", activeKeyID)
+	}
+	if active.signKey == nil {
+		return nil, fmt.Errorf("active JWT key %q has no private key", activeKeyID)
+	}
+	k.active = active
+
+	return k, nil
+}
+
+func (k *Keyring) loadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		id := strings.TrimSuffix(filepath.Base(path), ".pem")
+		if id == sharedKeyID {
+			return fmt.Errorf("JWT key id %q is reserved for JWT_KEY", sharedKeyID)
+		}
+
+		key, err := loadKey(id, path)
+		if err != nil {
+			return fmt.Errorf("error loading JWT key %q: %w", id, err)
+		}
+		k.keys[id] = key
+	}
+
+	return nil
+}
+
+func loadKey(id, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, signKey: key, verifyKey: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, verifyKey: key}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, signKey: key, verifyKey: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, verifyKey: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
+
+// sign signs the claims with the active key.
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.active.Method, claims)
+	token.Header["kid"] = k.active.ID
+
+	return token.SignedString(k.active.signKey)
+}
+
+// registeredClaims returns the registered claims of a token of the keyring expiring at expiresAt.
+func (k *Keyring) registeredClaims(expiresAt time.Time) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Issuer:    k.issuer,
+		Audience:  jwt.ClaimStrings{k.audience},
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+}
+
+// verifyRegisteredClaims checks that a token was issued by this service for the keyring's audience.
+// The expiry is already checked when the token is parsed.
+func (k *Keyring) verifyRegisteredClaims(claims *jwt.RegisteredClaims) error {
+	if !claims.VerifyIssuer(k.issuer, true) {
+		return fmt.Errorf("unexpected token issuer: %q", claims.Issuer)
+	}
+	if !claims.VerifyAudience(k.audience, true) {
+		return fmt.Errorf("unexpected token audience: %q", claims.Audience)
+	}
+
+	return nil
+}
+
+// keyFunc returns the key named by the token's kid header. The token has to be signed
+// with that key's algorithm, so a public key can never be used as an HMAC secret.
+func (k *Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	key := k.shared
+	if kid, ok := token.Header["kid"]; ok {
+		id, _ := kid.(string)
+		key = k.keys[id]
+	}
+	if key == nil {
+		return nil, fmt.Errorf("unknown signing key: %v", token.Header["kid"])
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.verifyKey, nil
+}
+
+// PublicJWKS returns the public keys of the keyring. HS256 keys are secret and never published.
+func (k *Keyring) PublicJWKS() *JWKS {
+	jwks := &JWKS{Keys: []JWK{}}
+
+	for _, key := range k.keys {
+		switch verifyKey := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.Method.Alg(),
+				N:         base64.RawURLEncoding.EncodeToString(verifyKey.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(verifyKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.Method.Alg(),
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(verifyKey),
+			})
+		}
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID
+	})
+
+	return jwks
+}
+
+// PublicJWKS returns the public keys other services can verify access tokens with.
+func PublicJWKS() *JWKS {
+	return keyring.PublicJWKS()
+}
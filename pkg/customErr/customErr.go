@@ -25,17 +25,87 @@ var IngredientCategoryAlreadyExists = errors.New("ingredient category already ex
 var IngredientAlreadyExists = errors.New("ingredient already exists")
 var SupplierAlreadyExists = errors.New("supplier already exists")
 var ClientCategoryAlreadyExists = errors.New("client category already exists")
+var ClientIdentifierAlreadyExists = errors.New("identifier is already issued")
+var ClientCategoryPricingAlreadyExists = errors.New("the category already has pricing rules taking effect at that time")
+var DishAlreadyExists = errors.New("dish already exists")
+var AllergenAlreadyExists = errors.New("allergen already exists")
 var PurchaseAlreadyExists = errors.New("purchase already exists")
+var RoleAlreadyExists = errors.New("role already exists")
 
 var PasswordInvalid = errors.New("password invalid")
+var PasswordResetTokenInvalid = errors.New("invalid or expired password reset token")
+var PasswordUnchanged = errors.New("new password must differ from the current one")
+var CurrentPasswordInvalid = errors.New("current password is incorrect")
+var InvalidCredentials = errors.New("invalid username or password")
+var TooManySignInAttempts = errors.New("too many sign-in attempts, try again later")
+var AccountLocked = errors.New("account is temporarily locked")
+var TwoFactorCodeInvalid = errors.New("invalid two-factor code")
+var SignInChallengeInvalid = errors.New("invalid or expired sign-in challenge")
+var TwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+var TwoFactorNotEnabled = errors.New("two-factor authentication not enabled")
+var TwoFactorNotEnrolled = errors.New("two-factor enrollment not started")
+var TwoFactorRequired = errors.New("two-factor authentication is required for this role")
 var SessionExpired = errors.New("session expired")
 var SessionNotFound = errors.New("session not found")
+var SessionRevoked = errors.New("session revoked")
+var RefreshTokenReused = errors.New("refresh token reuse detected")
 var RoleNotFound = errors.New("role not found")
+var PermissionNotFound = errors.New("permission not found")
+var RoleInUse = errors.New("role is assigned to users or API keys")
+var RoleProtected = errors.New("the admin role cannot be modified")
+var APIKeyNotFound = errors.New("API key not found")
+var APIKeyAllowedIPInvalid = errors.New("invalid IP address or CIDR range")
+var APIKeyInvalid = errors.New("invalid API key")
+var APIKeyExpired = errors.New("API key expired")
+var APIKeyRevoked = errors.New("API key revoked")
+var APIKeyIPNotAllowed = errors.New("API key cannot be used from this IP address")
+var APIKeyNotAllowed = errors.New("this endpoint requires a user session")
+var SSONotConfigured = errors.New("single sign-on is not configured")
+var SSOProviderUnavailable = errors.New("the identity provider is unavailable")
+var SSOStateInvalid = errors.New("invalid or expired single sign-on state")
+var SSOSignInFailed = errors.New("single sign-on failed")
+var SSONoRole = errors.New("your account is not in a group that may use this service")
+var SSOEmailUnverified = errors.New("the identity provider did not confirm your email address")
 var UserNotFound = errors.New("user not found")
+var UserInactive = errors.New("user is deactivated")
 var SupplierNotFound = errors.New("supplier not found")
 var ClientCategoryNotFound = errors.New("client category not found")
 var ClientNotFound = errors.New("client not found")
+var ClientIdentifierNotFound = errors.New("client identifier not found")
+var ClientIdentifierLost = errors.New("identifier was reported lost")
+var ClientIdentifierBlocked = errors.New("identifier is blocked")
+var ClientIdentifierReplaced = errors.New("identifier was replaced and cannot be reactivated")
+var ClientCategoryPricingNotFound = errors.New("client category pricing not found")
+var ClientCategoryPricingInEffect = errors.New("pricing rules that are or were in effect cannot be deleted")
+var EffectiveDateInPast = errors.New("pricing rules cannot take effect in the past")
+var DishNotFound = errors.New("dish not found")
+var DishInactive = errors.New("dish is not on sale")
+var BalanceTransactionItemsInvalid = errors.New("only purchases can list dishes")
+var CreditLimitExceeded = errors.New("the charge would take the balance below the client's credit limit")
+var SpendingLimitExceeded = errors.New("the charge exceeds the client's spending limit")
+var DietaryConflict = errors.New("the purchase conflicts with the client's dietary restrictions")
+var BalanceTransactionAmountInvalid = errors.New("amount must be positive for top-ups and refunds, negative for purchases and not zero")
+var BalanceTransactionReasonRequired = errors.New("a reason is required for balance corrections")
+var IdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+var IdempotencyKeyInProgress = errors.New("a request with the same idempotency key is in progress, try again")
+var ClientBusy = errors.New("the client's balance is being changed by another request, try again")
+var StatementPeriodInvalid = errors.New("the statement period cannot end before it starts")
+var IdempotencyKeyInvalid = errors.New("idempotency key must be at most 100 characters")
+var TopUpNotFound = errors.New("top-up not found")
+var TopUpAlreadyPaid = errors.New("the top-up was already paid by another transaction")
+var TopUpAmountMismatch = errors.New("the amount paid does not match the top-up")
+var PaymentTransactionAlreadyUsed = errors.New("the payment transaction was already credited to another top-up")
+var PaymentsNotConfigured = errors.New("online payments are not configured")
+var PaymentProviderUnavailable = errors.New("the payment provider is unavailable")
+var WebhookSignatureInvalid = errors.New("invalid webhook signature")
+var WebhookEventInvalid = errors.New("invalid webhook event")
+var ClientInactive = errors.New("client is deactivated")
 var IngredientCategoryNotFound = errors.New("ingredient category not found")
 var IngredientNotFound = errors.New("ingredient not found")
+var AllergenNotFound = errors.New("allergen not found")
+
+var SortFieldInvalid = errors.New("cannot sort by this field")
+
+var PermissionDenied = errors.New("permission denied")
 
 var ServerError = errors.New("server error")
@@ -17,3 +17,8 @@ func IsDuplicateKeyError(err error) (bool, string) {
 
 	return false, ""
 }
+
+// IsLockTimeoutError checks if the error indicates that a lock was not acquired within lock_timeout.
+func IsLockTimeoutError(err error) bool {
+	return strings.Contains(err.Error(), "lock timeout")
+}
@@ -1,55 +1,149 @@
-package main
+// Package excelizer writes branded XLSX reports: a title band in the brand's colour, a block of labelled fields
+// and a table with a styled header, banded rows and money columns.
+package excelizer
 
 import (
 	"fmt"
 	"github.com/360EntSecGroup-Skylar/excelize"
+	"io"
 )
 
-func main() {
-	// Create a new Excel file
-	file := excelize.NewFile()
+// Brand is how reports look: the organisation's name printed above every title, and the colour, e.g. "#1F4E78",
+// of the title band and of the table header.
+type Brand struct {
+	Name  string
+	Color string
+}
 
-	// Create a new sheet
-	sheetName := "Sheet1"
-	//index := file.NewSheet(sheetName)
+// Column is a column of a report's table. Money columns show their values, float64s, with two decimals.
+type Column struct {
+	Title string
+	Width float64
+	Money bool
+}
 
-	// Set the column headers and apply formatting
-	headers := map[string]string{
-		"A1": "Food",
-		"B1": "Students",
-		"C1": "Cost",
-		"D1": "Time",
+// Report is an XLSX report of a single sheet, written top to bottom.
+type Report struct {
+	file    *excelize.File
+	sheet   string
+	columns []Column
+	row     int
+	styles  map[string]int
+}
+
+const moneyFormat = `#,##0.00;[Red]-#,##0.00`
+
+// NewReport starts a report on a sheet of the given name, laid out in the columns of its table.
+func NewReport(sheet string, brand Brand, columns []Column) (*Report, error) {
+	file := excelize.NewFile()
+	file.SetSheetName("Sheet1", sheet)
+
+	styles := map[string]string{
+		"brand":      fmt.Sprintf(`{"font":{"color":"#FFFFFF","size":10},"fill":{"type":"pattern","color":["%s"],"pattern":1}}`, brand.Color),
+		"title":      fmt.Sprintf(`{"font":{"bold":true,"color":"#FFFFFF","size":16},"fill":{"type":"pattern","color":["%s"],"pattern":1},"alignment":{"vertical":"center"}}`, brand.Color),
+		"label":      `{"font":{"bold":true}}`,
+		"value":      `{"alignment":{"horizontal":"left"}}`,
+		"moneyValue": fmt.Sprintf(`{"custom_number_format":"%s","alignment":{"horizontal":"left"}}`, moneyFormat),
+		"header":     fmt.Sprintf(`{"font":{"bold":true,"color":"#FFFFFF"},"fill":{"type":"pattern","color":["%s"],"pattern":1},"alignment":{"horizontal":"center","vertical":"center","wrap_text":true}}`, brand.Color),
+		"text":       `{"border":[{"type":"bottom","color":"#D9D9D9","style":1}]}`,
+		"money":      fmt.Sprintf(`{"custom_number_format":"%s","border":[{"type":"bottom","color":"#D9D9D9","style":1}]}`, moneyFormat),
+		"textBand":   `{"fill":{"type":"pattern","color":["#F2F2F2"],"pattern":1},"border":[{"type":"bottom","color":"#D9D9D9","style":1}]}`,
+		"moneyBand":  fmt.Sprintf(`{"custom_number_format":"%s","fill":{"type":"pattern","color":["#F2F2F2"],"pattern":1},"border":[{"type":"bottom","color":"#D9D9D9","style":1}]}`, moneyFormat),
+	}
+	report := &Report{file: file, sheet: sheet, columns: columns, row: 1, styles: make(map[string]int, len(styles))}
+	for name, style := range styles {
+		id, err := file.NewStyle(style)
+		if err != nil {
+			return nil, err
+		}
+		report.styles[name] = id
 	}
 
-	for cell, value := range headers {
-		file.SetCellValue(sheetName, cell, value)
-		styleID, _ := file.NewStyle(`{"font":{"bold":true},"alignment":{"horizontal":"center"},"border":[{"type":"bottom","color":"000000","style":2}],"fill":{"type":"pattern","color":["F2F2F2"],"pattern":1}}`)
-		file.SetCellStyle(sheetName, cell, cell, styleID)
+	for i, column := range columns {
+		name := excelize.ToAlphaString(i)
+		file.SetColWidth(sheet, name, name, column.Width)
 	}
 
-	// Add some sample data and apply formatting
-	data := map[string]interface{}{
-		"A2": "Pizza",
-		"B2": "John Doe",
-		"C2": 10.5,
-		"D2": "12:30 PM",
+	if brand.Name != "" {
+		report.setBand(brand.Name, "brand")
 	}
 
-	for cell, value := range data {
-		file.SetCellValue(sheetName, cell, value)
-		styleID, _ := file.NewStyle(`{"alignment":{"horizontal":"center"},"border":[{"type":"bottom","color":"000000","style":1}]}`)
-		file.SetCellStyle(sheetName, cell, cell, styleID)
+	return report, nil
+}
+
+// AddTitle writes the report's title in a band across the table's width, and leaves a row empty below it.
+func (r *Report) AddTitle(title string) {
+	r.file.SetRowHeight(r.sheet, r.row, 28)
+	r.setBand(title, "title")
+	r.row++
+}
+
+// AddField writes a labelled value, e.g. the period a report covers, above the table.
+func (r *Report) AddField(label, value string) {
+	r.setField(label, value, "value")
+}
+
+// AddMoneyField writes a labelled amount of money, e.g. a total, above or below the table.
+func (r *Report) AddMoneyField(label string, value float64) {
+	r.setField(label, value, "moneyValue")
+}
+
+// AddBlankRow leaves a row empty, to set the table apart from the fields.
+func (r *Report) AddBlankRow() {
+	r.row++
+}
+
+// AddTable writes the table's header and rows, every row holding a value for each column.
+func (r *Report) AddTable(rows [][]interface{}) {
+	for i, column := range r.columns {
+		cell := r.cell(i)
+		r.file.SetCellValue(r.sheet, cell, column.Title)
+		r.file.SetCellStyle(r.sheet, cell, cell, r.styles["header"])
 	}
+	r.file.SetRowHeight(r.sheet, r.row, 20)
+	r.row++
 
-	// Increase the font size for all cells
-	file.SetColWidth(sheetName, "A", "D", 15)
+	for i, row := range rows {
+		for j, value := range row {
+			style := "text"
+			if j < len(r.columns) && r.columns[j].Money {
+				style = "money"
+			}
+			if i%2 == 1 {
+				style += "Band"
+			}
 
-	// Save the file with a given name
-	fileName := "beautiful_example.xlsx"
-	if err := file.SaveAs(fileName); err != nil {
-		fmt.Println(err)
-		return
+			cell := r.cell(j)
+			r.file.SetCellValue(r.sheet, cell, value)
+			r.file.SetCellStyle(r.sheet, cell, cell, r.styles[style])
+		}
+		r.row++
 	}
+}
+
+// Write writes the report as an XLSX file.
+func (r *Report) Write(w io.Writer) error {
+	return r.file.Write(w)
+}
+
+func (r *Report) setBand(value, style string) {
+	first, last := r.cell(0), r.cell(max(len(r.columns), 1)-1)
+	r.file.SetCellValue(r.sheet, first, value)
+	r.file.MergeCell(r.sheet, first, last)
+	r.file.SetCellStyle(r.sheet, first, last, r.styles[style])
+	r.row++
+}
+
+func (r *Report) setField(label string, value interface{}, style string) {
+	labelCell, valueCell := r.cell(0), r.cell(1)
+	r.file.SetCellValue(r.sheet, labelCell, label)
+	r.file.SetCellStyle(r.sheet, labelCell, labelCell, r.styles["label"])
+	r.file.SetCellValue(r.sheet, valueCell, value)
+	r.file.SetCellStyle(r.sheet, valueCell, valueCell, r.styles[style])
+	r.row++
+}
 
-	fmt.Printf("Excel file '%s' created successfully.\n", fileName)
+// cell returns the name, e.g. "B7", of the cell in the given column of the current row.
+func (r *Report) cell(column int) string {
+	return fmt.Sprintf("%s%d", excelize.ToAlphaString(column), r.row)
 }
@@ -2,12 +2,125 @@ package helpers
 
 import (
 	"Canteen-Backend/pkg/customErr"
+	"crypto/rand"
 	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"golang.org/x/crypto/argon2"
 	"os"
+	"strings"
 )
 
-func HashPassword(password string) (string, error) {
+// PasswordHasher hashes passwords into a self-describing string and verifies passwords against it.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, hashedPassword string) (bool, error)
+	// Supports reports whether the hashed password was produced by this hasher.
+	Supports(hashedPassword string) bool
+}
+
+// Argon2idHasher produces PHC formatted hashes, e.g. $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>,
+// so the parameters used for every password are stored next to it.
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+const argon2idPrefix = "$argon2id$"
+
+var errInvalidArgon2idHash = errors.New("invalid argon2id hash")
+
+// DefaultPasswordHasher is used for every new password.
+var DefaultPasswordHasher = &Argon2idHasher{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// legacyPasswordHasher only verifies the salted SHA-1 hashes stored before argon2id was introduced.
+var legacyPasswordHasher = &sha1Hasher{}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(password, hashedPassword string) (bool, error) {
+	params, salt, key, err := decodeArgon2idHash(hashedPassword)
+	if err != nil {
+		return false, err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+}
+
+func (h *Argon2idHasher) Supports(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, argon2idPrefix)
+}
+
+// needsRehash reports whether the hash was made with weaker parameters than the hasher's current ones.
+func (h *Argon2idHasher) needsRehash(hashedPassword string) bool {
+	params, salt, key, err := decodeArgon2idHash(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory < h.Memory || params.Iterations < h.Iterations || params.Parallelism < h.Parallelism ||
+		uint32(len(salt)) < h.SaltLength || uint32(len(key)) < h.KeyLength
+}
+
+func decodeArgon2idHash(hashedPassword string) (*Argon2idHasher, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, errInvalidArgon2idHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, nil, nil, errInvalidArgon2idHash
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, errInvalidArgon2idHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, errInvalidArgon2idHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, errInvalidArgon2idHash
+	}
+
+	return params, salt, key, nil
+}
+
+type sha1Hasher struct{}
+
+func (h *sha1Hasher) Hash(password string) (string, error) {
 	hash := sha1.New()
 	_, err := hash.Write([]byte(password))
 	if err != nil {
@@ -17,16 +130,54 @@ func HashPassword(password string) (string, error) {
 	return fmt.Sprintf("%x", hash.Sum([]byte(os.Getenv("SALT")))), nil
 }
 
+func (h *sha1Hasher) Verify(password, hashedPassword string) (bool, error) {
+	otherHash, err := h.Hash(password)
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare([]byte(otherHash), []byte(hashedPassword)) == 1, nil
+}
+
+func (h *sha1Hasher) Supports(hashedPassword string) bool {
+	return !strings.HasPrefix(hashedPassword, "$")
+}
+
+// HashPassword hashes the password with DefaultPasswordHasher.
+func HashPassword(password string) (string, error) {
+	return DefaultPasswordHasher.Hash(password)
+}
+
+// CheckPassword verifies the password against a hash made by any supported hasher
+// and returns customErr.PasswordInvalid if it does not match.
 func CheckPassword(password, hashedPassword string) error {
-	hash := sha1.New()
-	_, err := hash.Write([]byte(password))
+	var hasher PasswordHasher
+	switch {
+	case DefaultPasswordHasher.Supports(hashedPassword):
+		hasher = DefaultPasswordHasher
+	case legacyPasswordHasher.Supports(hashedPassword):
+		hasher = legacyPasswordHasher
+	default:
+		return errors.New("unsupported password hash format")
+	}
+
+	ok, err := hasher.Verify(password, hashedPassword)
 	if err != nil {
 		return err
 	}
-
-	if fmt.Sprintf("%x", hash.Sum([]byte(os.Getenv("SALT")))) != hashedPassword {
+	if !ok {
 		return customErr.PasswordInvalid
 	}
 
 	return nil
 }
+
+// PasswordNeedsRehash reports whether the hash should be replaced by a fresh DefaultPasswordHasher hash,
+// either because it uses a legacy format or outdated parameters.
+func PasswordNeedsRehash(hashedPassword string) bool {
+	if !DefaultPasswordHasher.Supports(hashedPassword) {
+		return true
+	}
+
+	return DefaultPasswordHasher.needsRehash(hashedPassword)
+}
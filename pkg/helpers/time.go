@@ -0,0 +1,45 @@
+package helpers
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	canteenLocation     *time.Location
+	canteenLocationOnce sync.Once
+)
+
+// CanteenLocation returns the canteen's time zone, CANTEEN_TIME_ZONE, e.g. "Asia/Almaty".
+// Days of spending and subsidies start at midnight in this time zone.
+func CanteenLocation() *time.Location {
+	canteenLocationOnce.Do(func() {
+		location, err := time.LoadLocation(GetEnv("CANTEEN_TIME_ZONE", "UTC"))
+		if err != nil {
+			location = time.UTC
+		}
+		canteenLocation = location
+	})
+
+	return canteenLocation
+}
+
+// StartOfDay returns the midnight in the canteen's time zone that starts the day t falls in.
+// Timestamps are stored in the server's local time, so it is returned in that time zone.
+func StartOfDay(t time.Time) time.Time {
+	t = t.In(CanteenLocation())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).In(time.Local)
+}
+
+// StartOfWeek returns the midnight in the canteen's time zone that starts the week, from Monday, t falls in.
+func StartOfWeek(t time.Time) time.Time {
+	t = t.In(CanteenLocation())
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, t.Location()).In(time.Local)
+}
+
+// ConvertStringToCanteenDay returns the start of the day, given as "2006-01-02", in the canteen's time zone.
+func ConvertStringToCanteenDay(date string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02", date, CanteenLocation())
+	return t.In(time.Local)
+}
@@ -0,0 +1,109 @@
+package mailer
+
+import (
+	"Canteen-Backend/pkg/helpers"
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails. SMTPMailer is the only implementation, other transports can be plugged in
+// wherever a Mailer is expected.
+type Mailer interface {
+	Send(message *Message) error
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	// From is the sender address, optionally with a display name, e.g. "Canteen <no-reply@canteen.local>"
+	From string
+}
+
+// SMTPMailer sends emails through an SMTP server. STARTTLS is used whenever the server supports it,
+// and the server is only authenticated with if a username is set, so that a local catcher such as
+// MailHog can be used during development.
+type SMTPMailer struct {
+	config SMTPConfig
+}
+
+func NewSMTPMailer(config SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{config: config}
+}
+
+// NewSMTPMailerFromEnv configures an SMTPMailer from the SMTP_* environment variables.
+// The defaults point to a MailHog instance on localhost.
+func NewSMTPMailerFromEnv() *SMTPMailer {
+	return NewSMTPMailer(SMTPConfig{
+		Host:     helpers.GetEnv("SMTP_HOST", "localhost"),
+		Port:     helpers.GetEnv("SMTP_PORT", "1025"),
+		Username: helpers.GetEnv("SMTP_USERNAME", ""),
+		Password: helpers.GetEnv("SMTP_PASSWORD", ""),
+		From:     helpers.GetEnv("SMTP_FROM", "Canteen <no-reply@canteen.local>"),
+	})
+}
+
+func (m *SMTPMailer) Send(message *Message) error {
+	from, err := mail.ParseAddress(m.config.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	data, err := buildMessage(from, message)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(m.config.Host, m.config.Port), auth, from.Address, message.To, data)
+}
+
+func buildMessage(from *mail.Address, message *Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	headers := []string{
+		"From: " + from.String(),
+		"To: " + strings.Join(message.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+	}
+	for _, header := range headers {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, fmt.Errorf("invalid header %q", header)
+		}
+		buf.WriteString(header + "\r\n")
+	}
+	buf.WriteString("\r\n")
+
+	writer := quotedprintable.NewWriter(&buf)
+	if _, err := writer.Write([]byte(message.Body)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
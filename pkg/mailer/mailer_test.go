@@ -0,0 +1,108 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mailHogMessage is a message as listed by MailHog's API.
+type mailHogMessage struct {
+	Raw struct {
+		From string
+		To   []string
+	}
+	Content struct {
+		Headers map[string][]string
+		Body    string
+	}
+}
+
+// TestSMTPMailerSendsToMailHog sends an email through the SMTP_* configuration and looks it up in MailHog.
+// It runs only if MAILHOG_API_URL is set, e.g. to http://localhost:8025 with MailHog's SMTP server on SMTP_PORT 1025.
+func TestSMTPMailerSendsToMailHog(t *testing.T) {
+	apiURL := os.Getenv("MAILHOG_API_URL")
+	if apiURL == "" {
+		t.Skip("MAILHOG_API_URL is not set")
+	}
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	marker := "test-" + hex.EncodeToString(b)
+
+	message := &Message{
+		To:      []string{"alice@example.com"},
+		Subject: "Réinitialisation du mot de passe " + marker,
+		Body:    "Hello Alice,\n\nyour code is " + marker + ", and this line is long enough to be wrapped by the quoted-printable encoding of the body.\n",
+	}
+	if err := NewSMTPMailerFromEnv().Send(message); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	received := findMailHogMessage(t, apiURL, marker)
+	if len(received.Raw.To) != 1 || received.Raw.To[0] != "alice@example.com" {
+		t.Errorf("recipients = %v, want [alice@example.com]", received.Raw.To)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(strings.Join(received.Content.Headers["Subject"], ""))
+	if err != nil || subject != message.Subject {
+		t.Errorf("subject = %q (%v), want %q", subject, err, message.Subject)
+	}
+
+	body, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(received.Content.Body)))
+	if err != nil || strings.ReplaceAll(string(body), "\r\n", "\n") != message.Body {
+		t.Errorf("body = %q (%v), want %q", body, err, message.Body)
+	}
+}
+
+// findMailHogMessage waits for the message containing the marker to show up in MailHog.
+func findMailHogMessage(t *testing.T, apiURL, marker string) *mailHogMessage {
+	t.Helper()
+
+	searchURL := strings.TrimSuffix(apiURL, "/") + "/api/v2/search?" + url.Values{"kind": {"containing"}, "query": {marker}}.Encode()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		response, err := http.Get(searchURL)
+		if err != nil {
+			t.Fatalf("searching MailHog: %v", err)
+		}
+
+		var result struct {
+			Items []mailHogMessage `json:"items"`
+		}
+		err = json.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			t.Fatalf("decoding MailHog search result: %v", err)
+		}
+
+		if len(result.Items) > 0 {
+			return &result.Items[0]
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no message containing %s in MailHog", marker)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func TestBuildMessageRejectsHeaderInjection(t *testing.T) {
+	from := &mail.Address{Name: "Canteen", Address: "no-reply@canteen.local"}
+
+	_, err := buildMessage(from, &Message{To: []string{"alice@example.com\r\nBcc: eve@example.com"}, Subject: "Hello", Body: "Hello"})
+	if err == nil {
+		t.Fatal("buildMessage() with a CRLF in a recipient succeeded, want an error")
+	}
+}
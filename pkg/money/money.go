@@ -0,0 +1,215 @@
+// Package money represents amounts of money exactly, as a whole number of minor units
+// (tiyn, kopecks), instead of as floating point numbers that cannot hold most decimal amounts.
+//
+// Rounding rules: parsing never rounds, an amount with more than two fractional digits is rejected.
+// Multiplying or dividing by a quantity or a rate rounds half away from zero to the nearest minor unit,
+// as is usual for cash amounts. Amounts are stored in NUMERIC(14,2) columns, so parsed amounts are
+// limited to what those hold, see MaxAmount.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor units, a hundredth of the currency unit. Amounts are added,
+// subtracted and compared with the usual operators.
+type Money int64
+
+const minorUnitsPerUnit = 100
+
+// MaxAmount is the largest amount a NUMERIC(14,2) column holds, the smallest is -MaxAmount.
+const MaxAmount Money = 99_999_999_999_999
+
+var ErrInvalidAmount = errors.New("invalid amount, expected a number with at most two decimal places")
+var ErrAmountOutOfRange = errors.New("amount out of range, at most 999999999999.99 either way")
+
+// FromMinorUnits returns the amount of the given number of minor units.
+func FromMinorUnits(minorUnits int64) Money {
+	return Money(minorUnits)
+}
+
+// FromFloat converts a float amount, rounding half away from zero. It is meant for legacy values only,
+// new amounts should be parsed from their decimal representation.
+func FromFloat(value float64) Money {
+	return fromRat(new(big.Rat).Mul(decimalRat(value), big.NewRat(minorUnitsPerUnit, 1)))
+}
+
+// MinorUnits returns the amount as a number of minor units.
+func (m Money) MinorUnits() int64 {
+	return int64(m)
+}
+
+// Float64 returns the amount in currency units, for spreadsheets, which keep numbers as floats.
+// It is not meant for calculations.
+func (m Money) Float64() float64 {
+	return float64(m) / minorUnitsPerUnit
+}
+
+// Parse parses a decimal amount such as "12", "-0.5" or "1234.56". Amounts beyond MaxAmount
+// are rejected with ErrAmountOutOfRange, since they could not be stored.
+func Parse(value string) (Money, error) {
+	m, err := parse(value)
+	if err != nil {
+		return 0, err
+	}
+	if m > MaxAmount || m < -MaxAmount {
+		return 0, ErrAmountOutOfRange
+	}
+
+	return m, nil
+}
+
+// parse parses a decimal amount of any size that fits in a Money.
+func parse(value string) (Money, error) {
+	value = strings.TrimSpace(value)
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+
+	units, fraction, _ := strings.Cut(value, ".")
+	if units == "" && fraction == "" || len(fraction) > 2 || !isDigits(units) || !isDigits(fraction) {
+		return 0, ErrInvalidAmount
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	// the digits are valid, so only a number too large for a Money fails to parse
+	parsedUnits, err := strconv.ParseInt("0"+units, 10, 64)
+	if err != nil || parsedUnits > (1<<63-1)/minorUnitsPerUnit-1 {
+		return 0, ErrAmountOutOfRange
+	}
+	parsedFraction, _ := strconv.ParseInt(fraction, 10, 64)
+
+	m := Money(parsedUnits*minorUnitsPerUnit + parsedFraction)
+	if negative {
+		m = -m
+	}
+
+	return m, nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String formats the amount with two decimal places, e.g. "-12.50".
+func (m Money) String() string {
+	sign := ""
+	minorUnits := int64(m)
+	if minorUnits < 0 {
+		sign = "-"
+		minorUnits = -minorUnits
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, minorUnits/minorUnitsPerUnit, minorUnits%minorUnitsPerUnit)
+}
+
+// Mul multiplies the amount by a quantity or a rate, rounding half away from zero.
+func (m Money) Mul(factor float64) Money {
+	return fromRat(new(big.Rat).Mul(big.NewRat(int64(m), 1), decimalRat(factor)))
+}
+
+// Div divides the amount by a quantity, rounding half away from zero. Dividing by zero returns zero.
+func (m Money) Div(divisor float64) Money {
+	if divisor == 0 {
+		return 0
+	}
+
+	return fromRat(new(big.Rat).Quo(big.NewRat(int64(m), 1), decimalRat(divisor)))
+}
+
+// decimalRat converts the float to the decimal number it was written as, e.g. 0.1 to exactly 1/10
+// rather than to the binary fraction the float actually holds.
+func decimalRat(value float64) *big.Rat {
+	rat, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'g', -1, 64))
+	return rat
+}
+
+func fromRat(minorUnits *big.Rat) Money {
+	quotient, remainder := new(big.Int).QuoRem(minorUnits.Num(), minorUnits.Denom(), new(big.Int))
+
+	// round half away from zero: |remainder| / denominator >= 1/2
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(minorUnits.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(minorUnits.Sign())))
+	}
+
+	return Money(quotient.Int64())
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimal places.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts the amount as a JSON number or a string.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if value == "null" {
+		return nil
+	}
+
+	parsed, err := Parse(strings.Trim(value, `"`))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+
+	return nil
+}
+
+// Scan reads the amount from a NUMERIC column.
+func (m *Money) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case int64:
+		*m = Money(value * minorUnitsPerUnit)
+		return nil
+	case float64:
+		// only columns that have not been migrated to NUMERIC yet
+		*m = FromFloat(value)
+		return nil
+	case []byte:
+		return m.scanString(string(value))
+	case string:
+		return m.scanString(value)
+	default:
+		return fmt.Errorf("cannot scan %T into money", src)
+	}
+}
+
+func (m *Money) scanString(value string) error {
+	// NUMERIC columns with a larger scale are rounded like any other computation
+	if units, fraction, ok := strings.Cut(value, "."); ok && len(fraction) > 2 {
+		rat, ok := new(big.Rat).SetString(units + "." + fraction)
+		if !ok {
+			return ErrInvalidAmount
+		}
+		*m = fromRat(rat.Mul(rat, big.NewRat(minorUnitsPerUnit, 1)))
+		return nil
+	}
+
+	parsed, err := parse(value)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+
+	return nil
+}
+
+// Value writes the amount to a NUMERIC column.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,196 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Money
+		wantErr error
+	}{
+		{"12", 1200, nil},
+		{"1234.56", 123456, nil},
+		{"-0.5", -50, nil},
+		{"+1.25", 125, nil},
+		{" 3.1 ", 310, nil},
+		{".5", 50, nil},
+		{"5.", 500, nil},
+		{"-0", 0, nil},
+		{"999999999999.99", MaxAmount, nil},
+		{"-999999999999.99", -MaxAmount, nil},
+
+		{"1.234", 0, ErrInvalidAmount},
+		{"-0.001", 0, ErrInvalidAmount},
+		{"", 0, ErrInvalidAmount},
+		{"-", 0, ErrInvalidAmount},
+		{".", 0, ErrInvalidAmount},
+		{"abc", 0, ErrInvalidAmount},
+		{"1,50", 0, ErrInvalidAmount},
+		{"1e3", 0, ErrInvalidAmount},
+		{"--1", 0, ErrInvalidAmount},
+		{"1.-5", 0, ErrInvalidAmount},
+
+		{"1000000000000", 0, ErrAmountOutOfRange},
+		{"-1000000000000.00", 0, ErrAmountOutOfRange},
+		{"92233720368547758", 0, ErrAmountOutOfRange},
+		{"-99999999999999999999.99", 0, ErrAmountOutOfRange},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.value)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("Parse(%q) = %d, %v, want %d, %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{-1250, "-12.50"},
+		{123456, "1234.56"},
+		{MaxAmount, "999999999999.99"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		m      Money
+		factor float64
+		want   Money
+	}{
+		{1000, 0.15, 150},
+		{-1000, 0.15, -150},
+		{5, 0.5, 3},
+		{-5, 0.5, -3},
+		{-5, 0.1, -1},
+		{-4, 0.1, 0},
+		{-333, 3, -999},
+		{199, -0.5, -100},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.Mul(tt.factor); got != tt.want {
+			t.Errorf("Money(%d).Mul(%v) = %d, want %d", tt.m, tt.factor, got, tt.want)
+		}
+	}
+}
+
+func TestDiv(t *testing.T) {
+	tests := []struct {
+		m       Money
+		divisor float64
+		want    Money
+	}{
+		{101, 2, 51},
+		{-101, 2, -51},
+		{-1, 2, -1},
+		{-100, 3, -33},
+		{-200, 3, -67},
+		{200, -3, -67},
+		{-100, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.Div(tt.divisor); got != tt.want {
+			t.Errorf("Money(%d).Div(%v) = %d, want %d", tt.m, tt.divisor, got, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type payload struct {
+		Amount Money `json:"amount"`
+	}
+
+	for _, m := range []Money{0, 5, -5, -1250, 123456, MaxAmount, -MaxAmount} {
+		data, err := json.Marshal(payload{Amount: m})
+		if err != nil {
+			t.Fatalf("Marshal(%d) error = %v", m, err)
+		}
+
+		var decoded payload
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded.Amount != m {
+			t.Errorf("Unmarshal(%s) = %d, %v, want %d", data, decoded.Amount, err, m)
+		}
+	}
+
+	tests := []struct {
+		data    string
+		want    Money
+		wantErr bool
+	}{
+		{`{"amount": 12.5}`, 1250, false},
+		{`{"amount": "-12.50"}`, -1250, false},
+		{`{"amount": null}`, 0, false},
+		{`{}`, 0, false},
+		{`{"amount": 1.234}`, 0, true},
+		{`{"amount": "twelve"}`, 0, true},
+		{`{"amount": 1e3}`, 0, true},
+		{`{"amount": 1000000000000}`, 0, true},
+	}
+
+	for _, tt := range tests {
+		var decoded payload
+		err := json.Unmarshal([]byte(tt.data), &decoded)
+		if (err != nil) != tt.wantErr || decoded.Amount != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, %v, want %d and error %v", tt.data, decoded.Amount, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    Money
+		wantErr bool
+	}{
+		{"12.50", 1250, false},
+		{[]byte("12.50"), 1250, false},
+		{"-0.05", -5, false},
+		{[]byte("-0.05"), -5, false},
+		{"7", 700, false},
+		// NUMERIC results with a larger scale round half away from zero
+		{"1.005", 101, false},
+		{[]byte("-1.005"), -101, false},
+		{"2.0049", 200, false},
+		// sums are not limited to what a single column holds
+		{"1000000000000.00", 100000000000000, false},
+		{int64(3), 300, false},
+		{float64(0.1), 10, false},
+		{nil, 0, false},
+		{"abc", 0, true},
+		{[]byte("1.2.3"), 0, true},
+		{true, 0, true},
+	}
+
+	for _, tt := range tests {
+		m := Money(42)
+		err := m.Scan(tt.src)
+		if (err != nil) != tt.wantErr || !tt.wantErr && m != tt.want {
+			t.Errorf("Scan(%#v) = %d, %v, want %d and error %v", tt.src, m, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	value, err := Money(-1250).Value()
+	if err != nil || value != "-12.50" {
+		t.Fatalf("Value() = %v, %v, want -12.50", value, err)
+	}
+}
@@ -0,0 +1,161 @@
+package payment
+
+import (
+	"Canteen-Backend/pkg/logger"
+	"Canteen-Backend/pkg/money"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const FakeProviderName = "fake"
+
+type FakeConfig struct {
+	WebhookSecret string
+	// CheckoutURL is the page payments are said to be paid on, the fake provider does not serve it
+	CheckoutURL string
+	// WebhookURL, if set, is sent a succeeded event for every payment created, as if the client paid at once
+	WebhookURL string
+}
+
+// FakeProvider is a payment provider for development and tests that takes no money. Its webhook events
+// are signed like a real provider's, and are either sent to WebhookURL as soon as a payment is created
+// or built with Webhook, for a test to send them itself.
+type FakeProvider struct {
+	config FakeConfig
+	client *http.Client
+}
+
+// fakeEvent is the body of the fake provider's webhook requests.
+type fakeEvent struct {
+	Type          string      `json:"type"`
+	TransactionID string      `json:"transaction_id"`
+	PaymentID     string      `json:"payment_id"`
+	Amount        money.Money `json:"amount"`
+}
+
+// the fake provider delivers an event a few times, backing off, until the webhook accepts it
+const fakeDeliveryAttempts = 5
+
+func NewFakeProvider(config FakeConfig) *FakeProvider {
+	return &FakeProvider{config: config, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+func (p *FakeProvider) CreatePayment(_ context.Context, request *Request) (*Payment, error) {
+	id, err := randomID("pay_")
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &Payment{ID: id, CheckoutURL: p.config.CheckoutURL + "?" + url.Values{"payment_id": {id}}.Encode()}
+	if p.config.WebhookURL != "" {
+		transactionID, err := randomID("txn_")
+		if err != nil {
+			return nil, err
+		}
+		go p.deliver(&Event{TransactionID: transactionID, PaymentID: id, Status: StatusSucceeded, Amount: request.Amount})
+	}
+
+	return payment, nil
+}
+
+func (p *FakeProvider) ParseWebhook(header http.Header, body []byte) (*Event, error) {
+	if err := VerifySignature(p.config.WebhookSecret, header.Get(SignatureHeader), body, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var event fakeEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.TransactionID == "" || event.PaymentID == "" {
+		return nil, ErrEventInvalid
+	}
+
+	var status string
+	switch event.Type {
+	case "payment.succeeded":
+		status = StatusSucceeded
+	case "payment.failed":
+		status = StatusFailed
+	default:
+		return nil, ErrEventInvalid
+	}
+
+	return &Event{TransactionID: event.TransactionID, PaymentID: event.PaymentID, Status: status, Amount: event.Amount}, nil
+}
+
+// Webhook builds the signed webhook request the provider would send for the event.
+func (p *FakeProvider) Webhook(event *Event) ([]byte, http.Header, error) {
+	body, err := json.Marshal(&fakeEvent{
+		Type:          "payment." + event.Status,
+		TransactionID: event.TransactionID,
+		PaymentID:     event.PaymentID,
+		Amount:        event.Amount,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(SignatureHeader, Sign(p.config.WebhookSecret, time.Now(), body))
+
+	return body, header, nil
+}
+
+// deliver sends the event to WebhookURL. The first attempt waits a moment, the payment being created
+// is only recorded by the app once CreatePayment returns.
+func (p *FakeProvider) deliver(event *Event) {
+	var err error
+	for attempt := 0; attempt < fakeDeliveryAttempts; attempt++ {
+		time.Sleep(time.Second << attempt)
+		if err = p.send(event); err == nil {
+			return
+		}
+	}
+
+	logger.GetLogger().Warn("fake payment provider could not deliver webhook", zap.String("payment_id", event.PaymentID), zap.Error(err))
+}
+
+func (p *FakeProvider) send(event *Event) error {
+	body, header, err := p.Webhook(event)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, p.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header = header
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+func randomID(prefix string) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return prefix + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,64 @@
+package payment
+
+import (
+	"Canteen-Backend/pkg/money"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFakeProviderWebhookRoundTrip(t *testing.T) {
+	provider := NewFakeProvider(FakeConfig{WebhookSecret: "whsec_test"})
+
+	for _, status := range []string{StatusSucceeded, StatusFailed} {
+		sent := &Event{TransactionID: "txn_1", PaymentID: "pay_1", Status: status, Amount: money.FromMinorUnits(1500)}
+		body, header, err := provider.Webhook(sent)
+		if err != nil {
+			t.Fatalf("Webhook() error = %v", err)
+		}
+
+		received, err := provider.ParseWebhook(header, body)
+		if err != nil {
+			t.Fatalf("ParseWebhook() error = %v", err)
+		}
+		if *received != *sent {
+			t.Fatalf("ParseWebhook() = %+v, want %+v", received, sent)
+		}
+	}
+}
+
+func TestFakeProviderRejectsUnsignedWebhook(t *testing.T) {
+	provider := NewFakeProvider(FakeConfig{WebhookSecret: "whsec_test"})
+	body, _, err := provider.Webhook(&Event{TransactionID: "txn_1", PaymentID: "pay_1", Status: StatusSucceeded, Amount: 100})
+	if err != nil {
+		t.Fatalf("Webhook() error = %v", err)
+	}
+
+	stale := http.Header{}
+	stale.Set(SignatureHeader, Sign("whsec_test", time.Now().Add(-time.Hour), body))
+	forged := http.Header{}
+	forged.Set(SignatureHeader, Sign("not the secret", time.Now(), body))
+
+	for name, header := range map[string]http.Header{"missing": {}, "stale": stale, "forged": forged} {
+		if _, err := provider.ParseWebhook(header, body); !errors.Is(err, ErrSignatureInvalid) {
+			t.Errorf("ParseWebhook() with %s signature error = %v, want %v", name, err, ErrSignatureInvalid)
+		}
+	}
+}
+
+func TestFakeProviderRejectsInvalidEvent(t *testing.T) {
+	provider := NewFakeProvider(FakeConfig{WebhookSecret: "whsec_test"})
+
+	for _, body := range []string{
+		`not json`,
+		`{"type":"payment.refunded","transaction_id":"txn_1","payment_id":"pay_1","amount":100}`,
+		`{"type":"payment.succeeded","payment_id":"pay_1","amount":100}`,
+	} {
+		header := http.Header{}
+		header.Set(SignatureHeader, Sign("whsec_test", time.Now(), []byte(body)))
+		if _, err := provider.ParseWebhook(header, []byte(body)); !errors.Is(err, ErrEventInvalid) {
+			t.Errorf("ParseWebhook(%s) error = %v, want %v", body, err, ErrEventInvalid)
+		}
+	}
+}
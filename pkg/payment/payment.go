@@ -0,0 +1,77 @@
+// Package payment takes payments through an online payment provider: a payment is created for the client
+// to pay on the provider's checkout page, and the provider reports its outcome to a webhook.
+package payment
+
+import (
+	"Canteen-Backend/pkg/helpers"
+	"Canteen-Backend/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// The outcomes of a payment reported by a provider.
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+var ErrSignatureInvalid = errors.New("invalid or expired webhook signature")
+var ErrEventInvalid = errors.New("invalid webhook event")
+
+// Request is a payment to be made. Reference identifies the payment in the app, e.g. the top-up's ID,
+// and is shown to the provider's staff.
+type Request struct {
+	Reference   string
+	Amount      money.Money
+	Description string
+}
+
+// Payment is a payment created with the provider, paid on its checkout page.
+type Payment struct {
+	ID          string
+	CheckoutURL string
+}
+
+// Event is the outcome of a payment, reported by the provider to the webhook. TransactionID is unique
+// to the money movement, a provider may deliver the same event more than once.
+type Event struct {
+	TransactionID string
+	PaymentID     string
+	Status        string
+	Amount        money.Money
+}
+
+// Provider is an online payment provider. FakeProvider is the only implementation, a real provider
+// is plugged in wherever a Provider is expected.
+type Provider interface {
+	// Name identifies the provider in the app's records, e.g. "fake"
+	Name() string
+	CreatePayment(ctx context.Context, request *Request) (*Payment, error)
+	// ParseWebhook verifies the signature of a webhook request and returns its event,
+	// or ErrSignatureInvalid if the request was not sent by the provider
+	ParseWebhook(header http.Header, body []byte) (*Event, error)
+}
+
+// NewProviderFromEnv configures a Provider from the PAYMENT_* environment variables.
+// It returns nil if PAYMENT_PROVIDER is not set, which disables online top-ups.
+func NewProviderFromEnv() (Provider, error) {
+	switch name := helpers.GetEnv("PAYMENT_PROVIDER", ""); name {
+	case "":
+		return nil, nil
+	case FakeProviderName:
+		secret := helpers.GetEnv("PAYMENT_WEBHOOK_SECRET", "")
+		if secret == "" {
+			return nil, errors.New("PAYMENT_WEBHOOK_SECRET is not set")
+		}
+
+		return NewFakeProvider(FakeConfig{
+			WebhookSecret: secret,
+			CheckoutURL:   helpers.GetEnv("PAYMENT_FAKE_CHECKOUT_URL", "http://localhost:8080/fake-checkout"),
+			WebhookURL:    helpers.GetEnv("PAYMENT_FAKE_WEBHOOK_URL", ""),
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+}
@@ -0,0 +1,64 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the header of webhook requests holding their signature, e.g. "t=1700000000,v1=5257a8...".
+const SignatureHeader = "Payment-Signature"
+
+// SignatureTolerance is how old a signature may be, so that a captured request cannot be replayed later.
+const SignatureTolerance = 5 * time.Minute
+
+// Sign signs a webhook request's body with the webhook secret: v1 is the hex HMAC-SHA256 of the timestamp,
+// a dot and the body, so that the timestamp cannot be changed without the secret.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + unix + ",v1=" + signature(secret, unix, body)
+}
+
+// VerifySignature checks that the signature was made with the webhook secret for the body,
+// within SignatureTolerance of now.
+func VerifySignature(secret, header string, body []byte, now time.Time) error {
+	var unix string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	timestamp, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	if age := now.Sub(time.Unix(timestamp, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return ErrSignatureInvalid
+	}
+
+	// a secret being rotated may sign with both the old and the new secret
+	expected := signature(secret, unix, body)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrSignatureInvalid
+}
+
+func signature(secret, unix string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,49 @@
+package payment
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "whsec_test"
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"type":"payment.succeeded","payment_id":"pay_1","transaction_id":"txn_1","amount":1000}`)
+	valid := Sign(secret, now, body)
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+		valid  bool
+	}{
+		{name: "valid", secret: secret, header: valid, body: body, now: now, valid: true},
+		{name: "valid within tolerance", secret: secret, header: valid, body: body, now: now.Add(SignatureTolerance), valid: true},
+		{name: "one of several signatures valid", secret: secret, header: valid + ",v1=" + signature("old secret", "1700000000", body), body: body, now: now, valid: true},
+		{name: "tampered body", secret: secret, header: valid, body: []byte(`{"type":"payment.succeeded","payment_id":"pay_1","transaction_id":"txn_1","amount":100000}`), now: now},
+		{name: "wrong secret", secret: "another secret", header: valid, body: body, now: now},
+		{name: "stale timestamp", secret: secret, header: valid, body: body, now: now.Add(SignatureTolerance + time.Second)},
+		{name: "timestamp in the future", secret: secret, header: valid, body: body, now: now.Add(-SignatureTolerance - time.Second)},
+		{name: "timestamp changed", secret: secret, header: "t=" + strconv.FormatInt(now.Unix()+60, 10) + ",v1=" + signature(secret, "1700000000", body), body: body, now: now},
+		{name: "no timestamp", secret: secret, header: "v1=" + signature(secret, "1700000000", body), body: body, now: now},
+		{name: "no signature", secret: secret, header: "t=1700000000", body: body, now: now},
+		{name: "empty header", secret: secret, header: "", body: body, now: now},
+		{name: "malformed header", secret: secret, header: "garbage", body: body, now: now},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifySignature(test.secret, test.header, test.body, test.now)
+			if test.valid && err != nil {
+				t.Fatalf("VerifySignature() = %v, want nil", err)
+			}
+			if !test.valid && !errors.Is(err, ErrSignatureInvalid) {
+				t.Fatalf("VerifySignature() = %v, want %v", err, ErrSignatureInvalid)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Package pdf writes simple PDF documents: pages of text, filled rectangles and lines, in Helvetica
+// or in a TrueType font embedded in the document.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf16"
+)
+
+// The size of an A4 page in points.
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Color is an RGB colour.
+type Color struct {
+	R, G, B uint8
+}
+
+var Black = Color{}
+
+// Document is a PDF document being drawn page by page. Positions are in points from the top left corner of the page,
+// the position of text is that of its baseline.
+type Document struct {
+	width, height float64
+	font          Font
+	glyphs        map[uint16]rune
+	title         string
+	pages         []*bytes.Buffer
+}
+
+// New starts a document with pages of the given size, with the text in the given font.
+func New(width, height float64, font Font) *Document {
+	return &Document{width: width, height: height, font: font, glyphs: make(map[uint16]rune)}
+}
+
+// SetTitle sets the title PDF readers show in place of the file name.
+func (d *Document) SetTitle(title string) {
+	d.title = title
+}
+
+// AddPage starts a new page, which is drawn on from then on.
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// PageCount returns how many pages the document has.
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// Text draws the text with its left end at x. Bold text is drawn with a thin outline around the letters,
+// so it does not need a font of its own.
+func (d *Document) Text(x, y, size float64, bold bool, color Color, text string) {
+	page := d.page()
+	fmt.Fprintf(page, "q BT /F1 %s Tf %s rg ", formatNumber(size), formatColor(color))
+	if bold {
+		fmt.Fprintf(page, "2 Tr %s w %s RG ", formatNumber(size*0.04), formatColor(color))
+	}
+	fmt.Fprintf(page, "%s %s Td <%X> Tj ET Q\n", formatNumber(x), formatNumber(d.height-y), d.font.encode(text, d.glyphs))
+}
+
+// TextRight draws the text with its right end at x.
+func (d *Document) TextRight(x, y, size float64, bold bool, color Color, text string) {
+	d.Text(x-d.TextWidth(text, size), y, size, bold, color, text)
+}
+
+// TextWidth returns how wide the text is in the given font size.
+func (d *Document) TextWidth(text string, size float64) float64 {
+	return d.font.width(text) * size / 1000
+}
+
+// Rect fills the rectangle whose top left corner is at x, y.
+func (d *Document) Rect(x, y, width, height float64, color Color) {
+	fmt.Fprintf(d.page(), "q %s rg %s %s %s %s re f Q\n", formatColor(color),
+		formatNumber(x), formatNumber(d.height-y-height), formatNumber(width), formatNumber(height))
+}
+
+// Line draws a straight line between the two points.
+func (d *Document) Line(x1, y1, x2, y2, lineWidth float64, color Color) {
+	fmt.Fprintf(d.page(), "q %s RG %s w %s %s m %s %s l S Q\n", formatColor(color), formatNumber(lineWidth),
+		formatNumber(x1), formatNumber(d.height-y1), formatNumber(x2), formatNumber(d.height-y2))
+}
+
+// Write writes the document as a PDF file.
+func (d *Document) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	out := &writer{}
+	out.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	catalog, pages, font := out.newObject(), out.newObject(), out.newObject()
+
+	pageIDs := make([]int, len(d.pages))
+	for i, content := range d.pages {
+		pageIDs[i] = out.newObject()
+		contentID := out.newObject()
+		out.startObject(pageIDs[i])
+		fmt.Fprintf(&out.buf, "<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pages, formatNumber(d.width), formatNumber(d.height), font, contentID)
+		out.endObject()
+		if err := out.stream(contentID, "", content.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	out.startObject(pages)
+	fmt.Fprintf(&out.buf, "<< /Type /Pages /Count %d /Kids [", len(pageIDs))
+	for _, id := range pageIDs {
+		fmt.Fprintf(&out.buf, "%d 0 R ", id)
+	}
+	out.buf.WriteString("] >>")
+	out.endObject()
+
+	out.startObject(catalog)
+	fmt.Fprintf(&out.buf, "<< /Type /Catalog /Pages %d 0 R >>", pages)
+	out.endObject()
+
+	// the font is written last, an embedded font only takes the widths of the letters the pages use
+	if err := d.font.write(out, font, d.glyphs); err != nil {
+		return err
+	}
+
+	info := out.newObject()
+	out.startObject(info)
+	fmt.Fprintf(&out.buf, "<< /Title %s /Producer %s >>", textString(d.title), textString("Canteen-Backend"))
+	out.endObject()
+
+	xref := out.buf.Len()
+	fmt.Fprintf(&out.buf, "xref\n0 %d\n0000000000 65535 f \n", len(out.offsets)+1)
+	for _, offset := range out.offsets {
+		fmt.Fprintf(&out.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(out.offsets)+1, catalog, info, xref)
+
+	_, err := w.Write(out.buf.Bytes())
+	return err
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	return d.pages[len(d.pages)-1]
+}
+
+// writer lays out the objects of a PDF file, keeping track of where each starts for the cross-reference table.
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+// newObject reserves the number of an object that is written later.
+func (w *writer) newObject() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+func (w *writer) startObject(id int) {
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n", id)
+}
+
+func (w *writer) endObject() {
+	w.buf.WriteString("\nendobj\n")
+}
+
+// stream writes a compressed stream object. The entries of dict are added to the stream's dictionary.
+func (w *writer) stream(id int, dict string, data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	w.startObject(id)
+	fmt.Fprintf(&w.buf, "<< /Length %d /Filter /FlateDecode %s>>\nstream\n", compressed.Len(), dict)
+	w.buf.Write(compressed.Bytes())
+	w.buf.WriteString("\nendstream")
+	w.endObject()
+
+	return nil
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(math.Round(value*1000)/1000, 'f', -1, 64)
+}
+
+func formatColor(color Color) string {
+	return fmt.Sprintf("%s %s %s", formatNumber(float64(color.R)/255), formatNumber(float64(color.G)/255), formatNumber(float64(color.B)/255))
+}
+
+// textString encodes text for the document's metadata in UTF-16, which PDF readers take for any language.
+func textString(text string) string {
+	return "<FEFF" + utf16Hex(text) + ">"
+}
+
+func utf16Hex(text string) string {
+	var buf bytes.Buffer
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&buf, "%04X", unit)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,75 @@
+package pdf
+
+import (
+	"fmt"
+)
+
+// Font is the font a document's text is drawn in. A font can be shared by documents written at the same time,
+// what a document uses of it is kept by the document.
+type Font interface {
+	// encode returns the codes of the text's letters in the font, adding the glyphs drawn to used
+	encode(text string, used map[uint16]rune) []byte
+	// width returns how wide the text is, in thousandths of the font size
+	width(text string) float64
+	// write writes the font's objects, the font itself as the object of the given number
+	write(w *writer, id int, used map[uint16]rune) error
+}
+
+// Helvetica returns the Helvetica font every PDF reader has. It only has the letters of Western European languages,
+// any other letter is drawn as a question mark.
+func Helvetica() Font {
+	return helvetica{}
+}
+
+type helvetica struct{}
+
+// helveticaWidths are the widths of the printable ASCII characters, from the space on.
+var helveticaWidths = [95]float64{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// winAnsiPunctuation are the letters Windows-1252 puts where Latin-1 has control characters.
+var winAnsiPunctuation = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+func (helvetica) encode(text string, _ map[uint16]rune) []byte {
+	codes := make([]byte, 0, len(text))
+	for _, r := range text {
+		if code, ok := winAnsiPunctuation[r]; ok {
+			codes = append(codes, code)
+		} else if r >= 0x20 && r < 0x7F || r >= 0xA0 && r <= 0xFF {
+			codes = append(codes, byte(r))
+		} else {
+			codes = append(codes, '?')
+		}
+	}
+
+	return codes
+}
+
+func (f helvetica) width(text string) float64 {
+	var width float64
+	for _, code := range f.encode(text, nil) {
+		if code >= 0x20 && code < 0x7F {
+			width += helveticaWidths[code-0x20]
+		} else {
+			width += 556
+		}
+	}
+
+	return width
+}
+
+func (helvetica) write(w *writer, id int, _ map[uint16]rune) error {
+	w.startObject(id)
+	fmt.Fprint(&w.buf, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	w.endObject()
+
+	return nil
+}
@@ -0,0 +1,286 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidFont = errors.New("invalid or unsupported TrueType font")
+
+// trueType is a TrueType font embedded in the document, for letters Helvetica does not have, e.g. Cyrillic ones.
+// The text is encoded as glyph numbers, and a table mapping them back to letters lets readers copy and search it.
+type trueType struct {
+	data       []byte
+	unitsPerEm float64
+	ascent     int
+	descent    int
+	bbox       [4]int
+	glyphs     map[rune]uint16
+	advances   []uint16
+}
+
+// LoadTrueType reads a TrueType font, a .ttf file, to be embedded in documents. The whole file is embedded,
+// so fonts are best picked for covering the letters needed rather than for their looks.
+func LoadTrueType(data []byte) (Font, error) {
+	tables, err := readTables(data)
+	if err != nil {
+		return nil, err
+	}
+
+	head, hhea, hmtx, cmap := tables["head"], tables["hhea"], tables["hmtx"], tables["cmap"]
+	if len(head) < 54 || len(hhea) < 36 || cmap == nil {
+		return nil, ErrInvalidFont
+	}
+
+	font := &trueType{
+		data:       data,
+		unitsPerEm: float64(binary.BigEndian.Uint16(head[18:])),
+		ascent:     int(int16(binary.BigEndian.Uint16(hhea[4:]))),
+		descent:    int(int16(binary.BigEndian.Uint16(hhea[6:]))),
+	}
+	for i := range font.bbox {
+		font.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	if font.unitsPerEm == 0 {
+		return nil, ErrInvalidFont
+	}
+
+	metrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	if metrics == 0 || len(hmtx) < 4*metrics {
+		return nil, ErrInvalidFont
+	}
+	font.advances = make([]uint16, metrics)
+	for i := range font.advances {
+		font.advances[i] = binary.BigEndian.Uint16(hmtx[4*i:])
+	}
+
+	if font.glyphs, err = readCharacterMap(cmap); err != nil {
+		return nil, err
+	}
+
+	return font, nil
+}
+
+// readTables returns the font's tables by their tags.
+func readTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, ErrInvalidFont
+	}
+
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*count {
+		return nil, ErrInvalidFont
+	}
+
+	tables := make(map[string][]byte, count)
+	for i := 0; i < count; i++ {
+		record := data[12+16*i:]
+		offset, length := int(binary.BigEndian.Uint32(record[8:])), int(binary.BigEndian.Uint32(record[12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) || offset+length < offset {
+			return nil, ErrInvalidFont
+		}
+		tables[string(record[:4])] = data[offset : offset+length]
+	}
+
+	return tables, nil
+}
+
+// readCharacterMap returns the glyphs of the letters, from the font's Unicode character map.
+func readCharacterMap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, ErrInvalidFont
+	}
+
+	// the full Unicode map of Windows is preferred to its map of the Basic Multilingual Plane
+	var subtable []byte
+	best := 0
+	count := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < count && 4+8*i+8 <= len(cmap); i++ {
+		record := cmap[4+8*i:]
+		platform, encoding := binary.BigEndian.Uint16(record), binary.BigEndian.Uint16(record[2:])
+		offset := int(binary.BigEndian.Uint32(record[4:]))
+		rank := 0
+		switch {
+		case platform == 3 && encoding == 10:
+			rank = 3
+		case platform == 3 && encoding == 1:
+			rank = 2
+		case platform == 0:
+			rank = 1
+		}
+		if rank > best && offset+2 <= len(cmap) {
+			best, subtable = rank, cmap[offset:]
+		}
+	}
+	if subtable == nil {
+		return nil, ErrInvalidFont
+	}
+
+	switch binary.BigEndian.Uint16(subtable) {
+	case 4:
+		return readSegmentMap(subtable)
+	case 12:
+		return readGroupMap(subtable)
+	default:
+		return nil, ErrInvalidFont
+	}
+}
+
+// readSegmentMap reads a character map of format 4, which maps ranges of letters to glyphs.
+func readSegmentMap(subtable []byte) (map[rune]uint16, error) {
+	if len(subtable) < 14 {
+		return nil, ErrInvalidFont
+	}
+
+	segments := int(binary.BigEndian.Uint16(subtable[6:])) / 2
+	ends, starts := 14, 16+2*segments
+	deltas, rangeOffsets := starts+2*segments, starts+4*segments
+	if len(subtable) < rangeOffsets+2*segments {
+		return nil, ErrInvalidFont
+	}
+
+	glyphs := make(map[rune]uint16)
+	for i := 0; i < segments; i++ {
+		end := int(binary.BigEndian.Uint16(subtable[ends+2*i:]))
+		start := int(binary.BigEndian.Uint16(subtable[starts+2*i:]))
+		delta := binary.BigEndian.Uint16(subtable[deltas+2*i:])
+		rangeOffset := int(binary.BigEndian.Uint16(subtable[rangeOffsets+2*i:]))
+
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			glyph := uint16(c) + delta
+			if rangeOffset != 0 {
+				at := rangeOffsets + 2*i + rangeOffset + 2*(c-start)
+				if at+2 > len(subtable) {
+					return nil, ErrInvalidFont
+				}
+				if glyph = binary.BigEndian.Uint16(subtable[at:]); glyph != 0 {
+					glyph += delta
+				}
+			}
+			if glyph != 0 {
+				glyphs[rune(c)] = glyph
+			}
+		}
+	}
+
+	return glyphs, nil
+}
+
+// readGroupMap reads a character map of format 12, which maps ranges of letters, of any plane, to ranges of glyphs.
+func readGroupMap(subtable []byte) (map[rune]uint16, error) {
+	if len(subtable) < 16 {
+		return nil, ErrInvalidFont
+	}
+
+	groups := int(binary.BigEndian.Uint32(subtable[12:]))
+	if groups < 0 || len(subtable) < 16+12*groups {
+		return nil, ErrInvalidFont
+	}
+
+	glyphs := make(map[rune]uint16)
+	for i := 0; i < groups; i++ {
+		group := subtable[16+12*i:]
+		start, end, glyph := binary.BigEndian.Uint32(group), binary.BigEndian.Uint32(group[4:]), binary.BigEndian.Uint32(group[8:])
+		if end > 0x10FFFF || start > end {
+			return nil, ErrInvalidFont
+		}
+		for c := start; c <= end; c++ {
+			glyphs[rune(c)] = uint16(glyph + c - start)
+		}
+	}
+
+	return glyphs, nil
+}
+
+func (f *trueType) encode(text string, used map[uint16]rune) []byte {
+	codes := make([]byte, 0, 2*len(text))
+	for _, r := range text {
+		glyph, ok := f.glyphs[r]
+		if !ok {
+			r = '?'
+			glyph = f.glyphs[r]
+		}
+		used[glyph] = r
+		codes = append(codes, byte(glyph>>8), byte(glyph))
+	}
+
+	return codes
+}
+
+func (f *trueType) width(text string) float64 {
+	var width float64
+	for _, r := range text {
+		glyph, ok := f.glyphs[r]
+		if !ok {
+			glyph = f.glyphs['?']
+		}
+		width += f.advance(glyph)
+	}
+
+	return width
+}
+
+// advance returns how far the glyph moves the pen, in thousandths of the font size.
+func (f *trueType) advance(glyph uint16) float64 {
+	advance := f.advances[len(f.advances)-1]
+	if int(glyph) < len(f.advances) {
+		advance = f.advances[glyph]
+	}
+
+	return float64(advance) * 1000 / f.unitsPerEm
+}
+
+func (f *trueType) write(w *writer, id int, used map[uint16]rune) error {
+	descendant, descriptor, file, toUnicode := w.newObject(), w.newObject(), w.newObject(), w.newObject()
+	scale := func(value int) int { return int(float64(value) * 1000 / f.unitsPerEm) }
+
+	glyphs := make([]int, 0, len(used))
+	for glyph := range used {
+		glyphs = append(glyphs, int(glyph))
+	}
+	sort.Ints(glyphs)
+
+	w.startObject(id)
+	fmt.Fprintf(&w.buf, "<< /Type /Font /Subtype /Type0 /BaseFont /EmbeddedFont /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		descendant, toUnicode)
+	w.endObject()
+
+	w.startObject(descendant)
+	fmt.Fprintf(&w.buf, "<< /Type /Font /Subtype /CIDFontType2 /BaseFont /EmbeddedFont /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [",
+		descriptor)
+	for _, glyph := range glyphs {
+		fmt.Fprintf(&w.buf, "%d [%s] ", glyph, formatNumber(f.advance(uint16(glyph))))
+	}
+	w.buf.WriteString("] >>")
+	w.endObject()
+
+	w.startObject(descriptor)
+	fmt.Fprintf(&w.buf, "<< /Type /FontDescriptor /FontName /EmbeddedFont /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		scale(f.bbox[0]), scale(f.bbox[1]), scale(f.bbox[2]), scale(f.bbox[3]), scale(f.ascent), scale(f.descent), scale(f.ascent), file)
+	w.endObject()
+
+	if err := w.stream(file, fmt.Sprintf("/Length1 %d ", len(f.data)), f.data); err != nil {
+		return err
+	}
+
+	var cmap strings.Builder
+	cmap.WriteString("/CIDInit /ProcSet findresource begin 12 dict begin begincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def /CMapType 2 def\n" +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange\n")
+	// a block of mappings holds at most 100 of them
+	for i := 0; i < len(glyphs); i += 100 {
+		block := glyphs[i:min(i+100, len(glyphs))]
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", len(block))
+		for _, glyph := range block {
+			fmt.Fprintf(&cmap, "<%04X> <%s>\n", glyph, utf16Hex(string(used[uint16(glyph)])))
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap CMapName currentdict /CMap defineresource pop end end")
+
+	return w.stream(toUnicode, "", []byte(cmap.String()))
+}
@@ -0,0 +1,266 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// testFont is a TrueType font with the tables LoadTrueType reads and no outlines. Its character map
+// maps '?' to glyph 4, 'A' to 'C' to glyphs 1 to 3 by a delta, and 'Ж' to glyph 5 through the glyph array.
+type testFont struct {
+	head, hhea, hmtx, cmap []byte
+}
+
+func newTestFont() *testFont {
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint16(head[18:], 1000)
+	for i, value := range []int16{-50, -200, 1000, 900} {
+		binary.BigEndian.PutUint16(head[36+2*i:], uint16(value))
+	}
+
+	hhea := make([]byte, 36)
+	binary.BigEndian.PutUint16(hhea[4:], 800)
+	binary.BigEndian.PutUint16(hhea[6:], uint16(0xFFFF-200+1))
+	binary.BigEndian.PutUint16(hhea[34:], 6)
+
+	var hmtx []byte
+	for _, advance := range []uint16{500, 600, 700, 800, 550, 900} {
+		hmtx = binary.BigEndian.AppendUint16(hmtx, advance)
+		hmtx = binary.BigEndian.AppendUint16(hmtx, 0)
+	}
+
+	return &testFont{head: head, hhea: hhea, hmtx: hmtx, cmap: segmentMapTable()}
+}
+
+// segmentMapTable returns a cmap table with a single Windows Unicode BMP subtable of format 4.
+func segmentMapTable() []byte {
+	ends := []uint16{'?', 'C', 'Ж', 0xFFFF}
+	starts := []uint16{'?', 'A', 'Ж', 0xFFFF}
+	deltas := []uint16{glyphDelta(4, '?'), glyphDelta(1, 'A'), 0, 1}
+	rangeOffsets := []uint16{0, 0, 4, 0}
+	glyphArray := []uint16{5}
+
+	var subtable []byte
+	subtable = binary.BigEndian.AppendUint16(subtable, 4)
+	subtable = binary.BigEndian.AppendUint16(subtable, 0) // length, not read
+	subtable = binary.BigEndian.AppendUint16(subtable, 0)
+	subtable = binary.BigEndian.AppendUint16(subtable, uint16(2*len(ends)))
+	subtable = append(subtable, make([]byte, 6)...)
+	for _, values := range [][]uint16{ends, {0}, starts, deltas, rangeOffsets, glyphArray} {
+		for _, value := range values {
+			subtable = binary.BigEndian.AppendUint16(subtable, value)
+		}
+	}
+
+	return cmapTable(3, 1, subtable)
+}
+
+// glyphDelta returns the delta mapping the letter to the glyph, modulo 65536.
+func glyphDelta(glyph, letter int) uint16 {
+	return uint16(glyph - letter)
+}
+
+func cmapTable(platform, encoding uint16, subtable []byte) []byte {
+	var cmap []byte
+	cmap = binary.BigEndian.AppendUint16(cmap, 0)
+	cmap = binary.BigEndian.AppendUint16(cmap, 1)
+	cmap = binary.BigEndian.AppendUint16(cmap, platform)
+	cmap = binary.BigEndian.AppendUint16(cmap, encoding)
+	cmap = binary.BigEndian.AppendUint32(cmap, 12)
+	return append(cmap, subtable...)
+}
+
+// bytes lays the tables out after the table directory, in the order of their tags.
+func (f *testFont) bytes() []byte {
+	tables := []struct {
+		tag  string
+		data []byte
+	}{{"cmap", f.cmap}, {"head", f.head}, {"hhea", f.hhea}, {"hmtx", f.hmtx}}
+
+	var directory, data bytes.Buffer
+	directory.Write([]byte{0, 1, 0, 0})
+	binary.Write(&directory, binary.BigEndian, uint16(len(tables)))
+	directory.Write(make([]byte, 6))
+	offset := 12 + 16*len(tables)
+	for _, table := range tables {
+		directory.WriteString(table.tag)
+		binary.Write(&directory, binary.BigEndian, uint32(0))
+		binary.Write(&directory, binary.BigEndian, uint32(offset+data.Len()))
+		binary.Write(&directory, binary.BigEndian, uint32(len(table.data)))
+		data.Write(table.data)
+	}
+
+	return append(directory.Bytes(), data.Bytes()...)
+}
+
+func TestLoadTrueType(t *testing.T) {
+	font, err := LoadTrueType(newTestFont().bytes())
+	if err != nil {
+		t.Fatalf("LoadTrueType() error = %v", err)
+	}
+
+	tt := font.(*trueType)
+	want := map[rune]uint16{'?': 4, 'A': 1, 'B': 2, 'C': 3, 'Ж': 5}
+	if len(tt.glyphs) != len(want) {
+		t.Fatalf("glyphs = %v, want %v", tt.glyphs, want)
+	}
+	for r, glyph := range want {
+		if tt.glyphs[r] != glyph {
+			t.Errorf("glyph of %q = %d, want %d", r, tt.glyphs[r], glyph)
+		}
+	}
+	if tt.ascent != 800 || tt.descent != -200 || tt.bbox != [4]int{-50, -200, 1000, 900} {
+		t.Errorf("ascent %d, descent %d, bbox %v, want 800, -200, [-50 -200 1000 900]", tt.ascent, tt.descent, tt.bbox)
+	}
+
+	// letters the font does not have are drawn and measured as '?'
+	if width := font.width("AБ"); width != 600+550 {
+		t.Errorf("width(\"AБ\") = %v, want %v", width, 600+550)
+	}
+	used := map[uint16]rune{}
+	if codes := font.encode("CЖБ", used); !bytes.Equal(codes, []byte{0, 3, 0, 5, 0, 4}) {
+		t.Errorf("encode(\"CЖБ\") = %v, want glyphs 3, 5 and 4", codes)
+	}
+	if len(used) != 3 || used[5] != 'Ж' || used[4] != '?' {
+		t.Errorf("used glyphs = %v, want 3, 4 and 5", used)
+	}
+}
+
+func TestLoadTrueTypeRejectsTruncatedFont(t *testing.T) {
+	data := newTestFont().bytes()
+
+	// every table is needed, and the last one ends the file, so any shorter file lacks part of one
+	for length := 0; length < len(data); length++ {
+		if _, err := LoadTrueType(data[:length]); !errors.Is(err, ErrInvalidFont) {
+			t.Fatalf("LoadTrueType() of the first %d of %d bytes error = %v, want %v", length, len(data), err, ErrInvalidFont)
+		}
+	}
+}
+
+func TestLoadTrueTypeRejectsInvalidTables(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(f *testFont, data []byte) []byte
+	}{
+		{"too many tables", func(f *testFont, data []byte) []byte {
+			binary.BigEndian.PutUint16(data[4:], 0xFFFF)
+			return data
+		}},
+		{"table past the end", func(f *testFont, data []byte) []byte {
+			binary.BigEndian.PutUint32(data[12+8:], uint32(len(data)))
+			return data
+		}},
+		{"table length overflowing the offset", func(f *testFont, data []byte) []byte {
+			binary.BigEndian.PutUint32(data[12+12:], 0xFFFFFFFF)
+			return data
+		}},
+		{"head too short", func(f *testFont, data []byte) []byte {
+			f.head = f.head[:53]
+			return f.bytes()
+		}},
+		{"zero units per em", func(f *testFont, data []byte) []byte {
+			binary.BigEndian.PutUint16(f.head[18:], 0)
+			return f.bytes()
+		}},
+		{"no horizontal metrics", func(f *testFont, data []byte) []byte {
+			binary.BigEndian.PutUint16(f.hhea[34:], 0)
+			return f.bytes()
+		}},
+		{"more metrics than hmtx holds", func(f *testFont, data []byte) []byte {
+			binary.BigEndian.PutUint16(f.hhea[34:], 7)
+			return f.bytes()
+		}},
+		{"no Unicode character map", func(f *testFont, data []byte) []byte {
+			binary.BigEndian.PutUint16(f.cmap[4:], 1)
+			return f.bytes()
+		}},
+		{"character map subtable past the end", func(f *testFont, data []byte) []byte {
+			binary.BigEndian.PutUint32(f.cmap[8:], uint32(len(f.cmap)))
+			return f.bytes()
+		}},
+		{"unsupported character map format", func(f *testFont, data []byte) []byte {
+			binary.BigEndian.PutUint16(f.cmap[12:], 6)
+			return f.bytes()
+		}},
+		{"more segments than the subtable holds", func(f *testFont, data []byte) []byte {
+			binary.BigEndian.PutUint16(f.cmap[12+6:], 0xFFFE)
+			return f.bytes()
+		}},
+		{"glyph array offset past the end", func(f *testFont, data []byte) []byte {
+			rangeOffsets := 12 + 16 + 3*2*4
+			binary.BigEndian.PutUint16(f.cmap[rangeOffsets+2*2:], 0xFFF0)
+			return f.bytes()
+		}},
+		{"more groups than the subtable holds", func(f *testFont, data []byte) []byte {
+			f.cmap = cmapTable(3, 10, groupMapSubtable(0xFFFFFFFF, 'A', 'C', 1))
+			return f.bytes()
+		}},
+		{"group past the last Unicode letter", func(f *testFont, data []byte) []byte {
+			f.cmap = cmapTable(3, 10, groupMapSubtable(1, 0x10FFFF, 0x110000, 1))
+			return f.bytes()
+		}},
+		{"group ending before its start", func(f *testFont, data []byte) []byte {
+			f.cmap = cmapTable(3, 10, groupMapSubtable(1, 'C', 'A', 1))
+			return f.bytes()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFont()
+			if _, err := LoadTrueType(tt.corrupt(f, f.bytes())); !errors.Is(err, ErrInvalidFont) {
+				t.Fatalf("LoadTrueType() error = %v, want %v", err, ErrInvalidFont)
+			}
+		})
+	}
+}
+
+func TestLoadTrueTypeReadsGroupMap(t *testing.T) {
+	f := newTestFont()
+	f.cmap = cmapTable(3, 10, groupMapSubtable(1, 'A', 'C', 1))
+
+	font, err := LoadTrueType(f.bytes())
+	if err != nil {
+		t.Fatalf("LoadTrueType() error = %v", err)
+	}
+	if glyphs := font.(*trueType).glyphs; len(glyphs) != 3 || glyphs['A'] != 1 || glyphs['C'] != 3 {
+		t.Fatalf("glyphs = %v, want 'A' to 'C' mapped to 1 to 3", glyphs)
+	}
+}
+
+// groupMapSubtable returns a character map subtable of format 12 declaring the number of groups,
+// with one group mapping start to end to the glyphs from glyph on.
+func groupMapSubtable(groups, start, end, glyph uint32) []byte {
+	var subtable []byte
+	subtable = binary.BigEndian.AppendUint16(subtable, 12)
+	subtable = binary.BigEndian.AppendUint16(subtable, 0)
+	subtable = binary.BigEndian.AppendUint32(subtable, 0) // length, not read
+	subtable = binary.BigEndian.AppendUint32(subtable, 0)
+	subtable = binary.BigEndian.AppendUint32(subtable, groups)
+	subtable = binary.BigEndian.AppendUint32(subtable, start)
+	subtable = binary.BigEndian.AppendUint32(subtable, end)
+	return binary.BigEndian.AppendUint32(subtable, glyph)
+}
+
+func FuzzLoadTrueType(f *testing.F) {
+	f.Add(newTestFont().bytes())
+	groupFont := newTestFont()
+	groupFont.cmap = cmapTable(3, 10, groupMapSubtable(1, 'A', 'C', 1))
+	f.Add(groupFont.bytes())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		font, err := LoadTrueType(data)
+		if err != nil {
+			return
+		}
+
+		// a font that loads can be used to write a document
+		doc := New(A4Width, A4Height, font)
+		doc.Text(10, 10, 12, false, Black, "ABC Жук ?")
+		if err := doc.Write(&bytes.Buffer{}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	})
+}
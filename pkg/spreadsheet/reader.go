@@ -0,0 +1,141 @@
+// Package spreadsheet reads tables from the CSV and XLSX files staff export from their spreadsheet programs.
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported file format, expected a .csv or .xlsx file")
+var ErrTooManyRows = errors.New("file has too many rows")
+var ErrXLSXTooLarge = errors.New("XLSX file is too large once uncompressed")
+
+// maxXLSXSize bounds the uncompressed size of an XLSX file, all of whose parts excelize reads into memory,
+// so that a small file that inflates to gigabytes is refused before it is read.
+const maxXLSXSize = 64 << 20
+
+// ReadRows reads the rows of a CSV file or of the first sheet of an XLSX file, picking the format
+// by the file name's extension. Cells are trimmed, and row i of the result is line i+1 of the file.
+// A file with more than maxRows rows is refused with ErrTooManyRows.
+func ReadRows(filename string, r io.Reader, maxRows int) ([][]string, error) {
+	var rows [][]string
+	var err error
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		rows, err = readCSV(r, maxRows)
+	case ".xlsx":
+		rows, err = readXLSX(r, maxRows)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		for i := range row {
+			row[i] = strings.TrimSpace(row[i])
+		}
+	}
+
+	return rows, nil
+}
+
+// readCSV reads comma or semicolon separated values. Spreadsheet programs in locales with
+// a decimal comma save CSV files with semicolons, and Excel starts UTF-8 files with a byte order mark.
+func readCSV(r io.Reader, maxRows int) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+
+	var rows [][]string
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading CSV file: %w", err)
+		}
+
+		if len(rows) == maxRows {
+			return nil, fmt.Errorf("%w, at most %d", ErrTooManyRows, maxRows)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func readXLSX(r io.Reader, maxRows int) (rows [][]string, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkXLSXSize(data); err != nil {
+		return nil, err
+	}
+
+	file, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error reading XLSX file: %w", err)
+	}
+
+	sheets := file.GetSheetMap()
+	if len(sheets) == 0 {
+		return nil, errors.New("XLSX file has no sheets")
+	}
+	indexes := make([]int, 0, len(sheets))
+	for index := range sheets {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	// excelize panics on some malformed sheets instead of returning an error
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			rows, err = nil, fmt.Errorf("error reading XLSX file: %v", recovered)
+		}
+	}()
+
+	rows = file.GetRows(sheets[indexes[0]])
+	if len(rows) > maxRows {
+		return nil, fmt.Errorf("%w, at most %d", ErrTooManyRows, maxRows)
+	}
+
+	return rows, nil
+}
+
+// checkXLSXSize refuses an XLSX file, a ZIP archive, whose parts add up to more than maxXLSXSize.
+// The sizes the archive declares can be relied on, archive/zip fails to read a part that inflates to more.
+func checkXLSXSize(data []byte) error {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("error reading XLSX file: %w", err)
+	}
+
+	var size uint64
+	for _, part := range archive.File {
+		size += part.UncompressedSize64
+		if size > maxXLSXSize {
+			return ErrXLSXTooLarge
+		}
+	}
+
+	return nil
+}
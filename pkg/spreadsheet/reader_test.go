@@ -0,0 +1,91 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReadRowsCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want [][]string
+	}{
+		{"commas", "email,first_name\n a@example.com ,Ann\n", [][]string{{"email", "first_name"}, {"a@example.com", "Ann"}}},
+		{"semicolons", "email;balance\na@example.com;12,50\n", [][]string{{"email", "balance"}, {"a@example.com", "12,50"}}},
+		{"byte order mark", "\ufeffemail\na@example.com\n", [][]string{{"email"}, {"a@example.com"}}},
+		{"ragged rows", "email,first_name\na@example.com\n", [][]string{{"email", "first_name"}, {"a@example.com"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ReadRows("clients.CSV", strings.NewReader(tt.data), 10)
+			if err != nil || !reflect.DeepEqual(rows, tt.want) {
+				t.Fatalf("ReadRows() = %q, %v, want %q", rows, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRowsLimitsRows(t *testing.T) {
+	var csv strings.Builder
+	xlsx := excelize.NewFile()
+	for i := 1; i <= 4; i++ {
+		csv.WriteString("row" + strconv.Itoa(i) + "\n")
+		xlsx.SetCellValue("Sheet1", "A"+strconv.Itoa(i), "row"+strconv.Itoa(i))
+	}
+	var xlsxData bytes.Buffer
+	if err := xlsx.Write(&xlsxData); err != nil {
+		t.Fatalf("writing XLSX file: %v", err)
+	}
+
+	files := map[string][]byte{"clients.csv": []byte(csv.String()), "clients.xlsx": xlsxData.Bytes()}
+	for filename, data := range files {
+		rows, err := ReadRows(filename, bytes.NewReader(data), 4)
+		if err != nil || len(rows) != 4 || rows[3][0] != "row4" {
+			t.Errorf("ReadRows(%s) with 4 rows allowed = %q, %v, want the 4 rows", filename, rows, err)
+		}
+
+		if _, err := ReadRows(filename, bytes.NewReader(data), 3); !errors.Is(err, ErrTooManyRows) {
+			t.Errorf("ReadRows(%s) with 3 rows allowed error = %v, want %v", filename, err, ErrTooManyRows)
+		}
+	}
+}
+
+func TestReadRowsRefusesXLSXInflatingPastLimit(t *testing.T) {
+	// a few dozen kilobytes that inflate to more than maxXLSXSize
+	var data bytes.Buffer
+	archive := zip.NewWriter(&data)
+	part, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	zeros := make([]byte, 1<<20)
+	for written := 0; written <= maxXLSXSize; written += len(zeros) {
+		if _, err := part.Write(zeros); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if _, err := ReadRows("clients.xlsx", &data, 10); !errors.Is(err, ErrXLSXTooLarge) {
+		t.Fatalf("ReadRows() error = %v, want %v", err, ErrXLSXTooLarge)
+	}
+}
+
+func TestReadRowsRejectsOtherFormats(t *testing.T) {
+	if _, err := ReadRows("clients.xls", strings.NewReader(""), 10); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("ReadRows() error = %v, want %v", err, ErrUnsupportedFormat)
+	}
+	if _, err := ReadRows("clients.xlsx", strings.NewReader("not a ZIP archive"), 10); err == nil {
+		t.Fatal("ReadRows() accepted a file that is not an XLSX file")
+	}
+}
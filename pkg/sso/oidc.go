@@ -0,0 +1,235 @@
+package sso
+
+import (
+	"Canteen-Backend/pkg/helpers"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"strings"
+	"sync"
+)
+
+// Config configures the OpenID Connect provider staff sign in with.
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// GroupsClaim is the ID token claim listing the user's groups
+	GroupsClaim string
+	GroupRoles  GroupRoleMapping
+}
+
+// Identity is a user as asserted by the provider's ID token.
+type Identity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	GivenName         string
+	FamilyName        string
+	Groups            []string
+}
+
+// Provider runs the authorization code flow with PKCE against an OpenID Connect provider.
+// The provider's discovery document is fetched on first use, so that the app starts
+// even while the provider is unreachable.
+type Provider struct {
+	config Config
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func NewProvider(config Config) *Provider {
+	return &Provider{config: config}
+}
+
+// NewProviderFromEnv configures a Provider from the OIDC_* environment variables.
+// It returns nil if OIDC_ISSUER_URL is not set, which disables single sign-on.
+// OIDC_GROUP_ROLES maps provider groups to roles, e.g. "canteen-admins=admin,canteen-staff=cashier".
+func NewProviderFromEnv() (*Provider, error) {
+	issuerURL := helpers.GetEnv("OIDC_ISSUER_URL", "")
+	if issuerURL == "" {
+		return nil, nil
+	}
+
+	groupRoles, err := ParseGroupRoleMapping(helpers.GetEnv("OIDC_GROUP_ROLES", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewProvider(Config{
+		IssuerURL:    issuerURL,
+		ClientID:     helpers.GetEnv("OIDC_CLIENT_ID", ""),
+		ClientSecret: helpers.GetEnv("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:  helpers.GetEnv("OIDC_REDIRECT_URL", "http://localhost:8080/api/auth/oidc/callback"),
+		GroupsClaim:  helpers.GetEnv("OIDC_GROUPS_CLAIM", "groups"),
+		GroupRoles:   groupRoles,
+	}), nil
+}
+
+// RoleName returns the role that the given provider groups map to.
+func (p *Provider) RoleName(groups []string) (string, bool) {
+	return p.config.GroupRoles.RoleName(groups)
+}
+
+func (p *Provider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider == nil {
+		provider, err := oidc.NewProvider(ctx, p.config.IssuerURL)
+		if err != nil {
+			return nil, fmt.Errorf("error discovering OIDC provider: %w", err)
+		}
+		p.provider = provider
+	}
+
+	return p.provider, nil
+}
+
+func (p *Provider) oauth2Config(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+	}
+}
+
+// AuthCodeURL returns the URL the user is sent to for signing in at the provider.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return p.oauth2Config(provider).AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+// Exchange redeems the authorization code and returns the identity from the verified ID token.
+func (p *Provider) Exchange(ctx context.Context, code, nonce, codeVerifier string) (*Identity, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.oauth2Config(provider).Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("error exchanging authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.config.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("error verifying ID token: %w", err)
+	}
+
+	if idToken.Nonce != nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	var claims struct {
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+		PreferredUsername string `json:"preferred_username"`
+		GivenName         string `json:"given_name"`
+		FamilyName        string `json:"family_name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	var allClaims map[string]interface{}
+	if err := idToken.Claims(&allClaims); err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		Issuer:            idToken.Issuer,
+		Subject:           idToken.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		PreferredUsername: claims.PreferredUsername,
+		GivenName:         claims.GivenName,
+		FamilyName:        claims.FamilyName,
+		Groups:            groupsFromClaim(allClaims[p.config.GroupsClaim]),
+	}, nil
+}
+
+// groupsFromClaim accepts the groups claim as a list or as a single, possibly comma separated, string.
+func groupsFromClaim(claim interface{}) []string {
+	var groups []string
+
+	switch value := claim.(type) {
+	case []interface{}:
+		for _, group := range value {
+			if name, ok := group.(string); ok {
+				groups = append(groups, name)
+			}
+		}
+	case string:
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				groups = append(groups, name)
+			}
+		}
+	}
+
+	return groups
+}
+
+// GroupRoleMapping maps provider groups to role names. The order matters: a user in several
+// mapped groups gets the role of the first one.
+type GroupRoleMapping []GroupRole
+
+type GroupRole struct {
+	Group    string
+	RoleName string
+}
+
+// ParseGroupRoleMapping parses a mapping in the form "group=role,group=role".
+func ParseGroupRoleMapping(value string) (GroupRoleMapping, error) {
+	var mapping GroupRoleMapping
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		group, roleName, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(group) == "" || strings.TrimSpace(roleName) == "" {
+			return nil, fmt.Errorf("invalid group role mapping %q", entry)
+		}
+
+		mapping = append(mapping, GroupRole{Group: strings.TrimSpace(group), RoleName: strings.TrimSpace(roleName)})
+	}
+
+	return mapping, nil
+}
+
+// RoleName returns the role of the first mapped group among groups.
+func (m GroupRoleMapping) RoleName(groups []string) (string, bool) {
+	member := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		member[group] = true
+	}
+
+	for _, entry := range m {
+		if member[entry.Group] {
+			return entry.RoleName, true
+		}
+	}
+
+	return "", false
+}
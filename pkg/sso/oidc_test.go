@@ -0,0 +1,131 @@
+package sso_test
+
+import (
+	"Canteen-Backend/pkg/sso"
+	"Canteen-Backend/pkg/sso/ssotest"
+	"context"
+	"golang.org/x/oauth2"
+	"reflect"
+	"testing"
+)
+
+func newTestProvider(issuer *ssotest.Issuer) *sso.Provider {
+	return sso.NewProvider(sso.Config{
+		IssuerURL:    issuer.URL(),
+		ClientID:     issuer.ClientID,
+		ClientSecret: issuer.ClientSecret,
+		RedirectURL:  "http://localhost:8080/api/auth/oidc/callback",
+		GroupsClaim:  "groups",
+		GroupRoles:   sso.GroupRoleMapping{{Group: "canteen-admins", RoleName: "admin"}, {Group: "canteen-staff", RoleName: "cashier"}},
+	})
+}
+
+// signIn runs the authorization code flow for a user with the given claims and returns the identity
+// the provider vouches for. The code is exchanged expecting exchangeNonce in the ID token.
+func signIn(t *testing.T, issuer *ssotest.Issuer, claims map[string]interface{}, exchangeNonce string) (*sso.Identity, error) {
+	t.Helper()
+
+	provider := newTestProvider(issuer)
+	ctx := context.Background()
+	codeVerifier := oauth2.GenerateVerifier()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", codeVerifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	code, state, err := issuer.Authorize(authURL, claims)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	if state != "state" {
+		t.Fatalf("state = %q, want %q", state, "state")
+	}
+
+	return provider.Exchange(ctx, code, exchangeNonce, codeVerifier)
+}
+
+func TestProviderExchange(t *testing.T) {
+	issuer := ssotest.NewIssuer("canteen", "secret")
+	defer issuer.Close()
+
+	identity, err := signIn(t, issuer, map[string]interface{}{
+		"sub":                "alice-subject",
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"preferred_username": "alice",
+		"given_name":         "Alice",
+		"family_name":        "Smith",
+		"groups":             []string{"everyone", "canteen-staff"},
+	}, "nonce")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	want := &sso.Identity{
+		Issuer:            issuer.URL(),
+		Subject:           "alice-subject",
+		Email:             "alice@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "alice",
+		GivenName:         "Alice",
+		FamilyName:        "Smith",
+		Groups:            []string{"everyone", "canteen-staff"},
+	}
+	if !reflect.DeepEqual(identity, want) {
+		t.Fatalf("Exchange() = %+v, want %+v", identity, want)
+	}
+}
+
+func TestProviderExchangeRejectsNonceMismatch(t *testing.T) {
+	issuer := ssotest.NewIssuer("canteen", "secret")
+	defer issuer.Close()
+
+	// an ID token issued for another sign-in, replayed into this one
+	if _, err := signIn(t, issuer, map[string]interface{}{"sub": "alice-subject", "nonce": "another nonce"}, "nonce"); err == nil {
+		t.Fatal("Exchange() with a mismatched nonce succeeded, want an error")
+	}
+}
+
+func TestProviderExchangeGroupsAsString(t *testing.T) {
+	issuer := ssotest.NewIssuer("canteen", "secret")
+	defer issuer.Close()
+
+	identity, err := signIn(t, issuer, map[string]interface{}{"sub": "alice-subject", "groups": "everyone, canteen-admins"}, "nonce")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if want := []string{"everyone", "canteen-admins"}; !reflect.DeepEqual(identity.Groups, want) {
+		t.Fatalf("groups = %v, want %v", identity.Groups, want)
+	}
+}
+
+func TestGroupRoleMapping(t *testing.T) {
+	mapping, err := sso.ParseGroupRoleMapping(" canteen-admins = admin ,canteen-staff=cashier,")
+	if err != nil {
+		t.Fatalf("ParseGroupRoleMapping() error = %v", err)
+	}
+
+	tests := []struct {
+		groups   []string
+		roleName string
+		ok       bool
+	}{
+		{groups: []string{"canteen-staff"}, roleName: "cashier", ok: true},
+		// the first mapped group wins, whatever the order of the user's groups
+		{groups: []string{"canteen-staff", "canteen-admins"}, roleName: "admin", ok: true},
+		{groups: []string{"everyone"}},
+		{groups: nil},
+	}
+	for _, test := range tests {
+		roleName, ok := mapping.RoleName(test.groups)
+		if roleName != test.roleName || ok != test.ok {
+			t.Errorf("RoleName(%v) = %q, %v, want %q, %v", test.groups, roleName, ok, test.roleName, test.ok)
+		}
+	}
+
+	for _, value := range []string{"canteen-admins", "=admin", "canteen-admins="} {
+		if _, err := sso.ParseGroupRoleMapping(value); err == nil {
+			t.Errorf("ParseGroupRoleMapping(%q) succeeded, want an error", value)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package twofactor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"image/png"
+	"strings"
+	"time"
+)
+
+const (
+	// period is the RFC 6238 time step in seconds
+	period = 30
+	// skew is the number of time steps before and after the current one in which a code is still accepted
+	skew = 1
+	// qrCodeSize is the width and height of the provisioning QR code in pixels
+	qrCodeSize = 256
+)
+
+var validateOpts = totp.ValidateOpts{
+	Period:    period,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// Key is a newly generated TOTP secret together with the ways to provision it in an authenticator app.
+type Key struct {
+	Secret          string
+	ProvisioningURI string
+	// QRCode is the provisioning URI as a PNG data URI
+	QRCode string
+}
+
+func GenerateKey(issuer, accountName string) (*Key, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: accountName,
+		Period:      period,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := key.Image(qrCodeSize, qrCodeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		Secret:          key.Secret(),
+		ProvisioningURI: key.URL(),
+		QRCode:          "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// ValidateCode checks the code against the secret at time t and returns the time step it was
+// generated for, so the caller can refuse to accept a code of the same or an earlier step twice.
+func ValidateCode(code, secret string, t time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+
+	for i := -skew; i <= skew; i++ {
+		stepTime := t.Add(time.Duration(i*period) * time.Second)
+
+		expected, err := totp.GenerateCodeCustom(secret, stepTime, validateOpts)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return stepTime.Unix() / period, true
+		}
+	}
+
+	return 0, false
+}
+
+// IsTOTPCode reports whether the code looks like a TOTP code rather than a recovery code.
+func IsTOTPCode(code string) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != otp.DigitsSix.Length() {
+		return false
+	}
+
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GenerateRecoveryCodes returns count random single-use codes formatted as xxxxx-xxxxx.
+// Only their hashes (see HashRecoveryCode) should ever be persisted.
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, count)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+
+	return codes, nil
+}
+
+// HashRecoveryCode returns the hex encoded SHA-256 hash of the recovery code, ignoring case, spaces and dashes.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+
+	hash := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(hash[:])
+}